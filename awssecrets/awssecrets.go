@@ -0,0 +1,44 @@
+// Package awssecrets resolves secret references from AWS Secrets Manager.
+//
+//	c, err := config.FromFile("config.yaml",
+//		config.WithResolver("awssm", awssecrets.NewResolver(client, time.Minute)))
+//
+// Values such as `awssm://prod/db#password` are then resolved to the field
+// `password` of the JSON secret `prod/db`.
+package awssecrets
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/go-apibox/config"
+)
+
+// Client is the part of *secretsmanager.Client used by the resolver.
+type Client interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput,
+		optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// NewResolver create a resolver fetching secrets by name or ARN, secrets are
+// cached for ttl.
+func NewResolver(client Client, ttl time.Duration) config.Resolver {
+	return config.NewSecretResolver(func(name string) (string, error) {
+		out, err := client.GetSecretValue(context.Background(), &secretsmanager.GetSecretValueInput{
+			SecretId: aws.String(name),
+		})
+		if err != nil {
+			return "", err
+		}
+		if out.SecretString != nil {
+			return *out.SecretString, nil
+		}
+		if out.SecretBinary != nil {
+			return string(out.SecretBinary), nil
+		}
+		return "", errors.New("secret `" + name + "` has no value")
+	}, ttl)
+}
@@ -0,0 +1,68 @@
+package awssecrets
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/go-apibox/config"
+)
+
+// fakeClient serves secrets from memory and counts the requests.
+type fakeClient struct {
+	secrets  map[string]*secretsmanager.GetSecretValueOutput
+	requests map[string]int
+}
+
+func (f *fakeClient) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput,
+	optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	name := aws.ToString(params.SecretId)
+	f.requests[name]++
+	out, ok := f.secrets[name]
+	if !ok {
+		return nil, errors.New("secret `" + name + "` not found")
+	}
+	return out, nil
+}
+
+func TestResolver(t *testing.T) {
+	client := &fakeClient{
+		secrets: map[string]*secretsmanager.GetSecretValueOutput{
+			"prod/db":    {SecretString: aws.String(`{"user": "app", "password": "hunter2"}`)},
+			"prod/token": {SecretString: aws.String("s3cr3t")},
+			"prod/cert":  {SecretBinary: []byte("binary")},
+			"prod/empty": {},
+		},
+		requests: make(map[string]int),
+	}
+	yaml := "db: {user: \"awssm://prod/db#user\", password: \"awssm://prod/db#password\"}\n" +
+		"token: awssm://prod/token\ncert: awssm://prod/cert\n"
+	c, err := config.FromString(yaml, config.WithResolver("awssm", NewResolver(client, time.Minute)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"db.user":     "app",
+		"db.password": "hunter2",
+		"token":       "s3cr3t",
+		"cert":        "binary",
+	}
+	for key, want := range tests {
+		if got, err := c.GetString(key); err != nil || got != want {
+			t.Errorf("GetString(%q) = %q, %v, want %q", key, got, err, want)
+		}
+	}
+	// 同一secret的字段只读取一次
+	if n := client.requests["prod/db"]; n != 1 {
+		t.Errorf("secret prod/db requested %d times, want 1", n)
+	}
+
+	for _, ref := range []string{"awssm://prod/empty", "awssm://prod/missing"} {
+		if _, err := config.FromString("v: "+ref+"\n", config.WithResolver("awssm", NewResolver(client, time.Minute))); err == nil {
+			t.Errorf("FromString() error = nil for %s, want an error", ref)
+		}
+	}
+}
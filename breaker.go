@@ -0,0 +1,201 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for the fetches of a remote source skipped
+// while its circuit breaker is open, see WithCircuitBreaker.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of the circuit breaker of a remote source.
+type CircuitState string
+
+// States of circuit breakers.
+const (
+	CircuitClosed   CircuitState = "closed"    // 正常获取
+	CircuitOpen     CircuitState = "open"      // 跳过获取直到冷却结束
+	CircuitHalfOpen CircuitState = "half-open" // 冷却结束，尝试一次获取
+)
+
+// WithRetry retries a failed fetch of the remote source up to attempts
+// times in total, waiting backoff before the first retry and doubling it
+// before each next retry up to maxBackoff. A random half of each wait is
+// jittered, so that the instances of a fleet don't retry at the same time.
+func WithRetry(attempts int, backoff, maxBackoff time.Duration) RemoteOption {
+	return func(o *remoteOptions) {
+		o.retryAttempts = attempts
+		o.retryBackoff = backoff
+		o.retryMaxBackoff = maxBackoff
+	}
+}
+
+// WithCircuitBreaker stops fetching the remote source after threshold
+// consecutive failed fetches, fetches fail with ErrCircuitOpen for cooldown,
+// then one fetch is tried and the breaker is closed if it succeeds, or
+// opened again if it fails. The state is reported by RemoteHealth.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) RemoteOption {
+	return func(o *remoteOptions) {
+		o.breakerThreshold = threshold
+		o.breakerCooldown = cooldown
+	}
+}
+
+// RemoteHealth is the health of a remote source.
+type RemoteHealth struct {
+	Name        string       // the name of the source given to AddRemote
+	State       CircuitState // the state of the circuit breaker
+	Failures    int          // the number of consecutive failed fetches
+	LastError   error        // the error of the last failed fetch
+	LastSuccess time.Time    // the time of the last successful fetch
+}
+
+// RemoteHealth returns the health of the remote sources, in the order they
+// were added, such as for health checks.
+func (c *Config) RemoteHealth() []RemoteHealth {
+	c.mu.Lock()
+	remotes := append([]*layer(nil), c.remoteLayers...)
+	c.mu.Unlock()
+
+	health := make([]RemoteHealth, 0, len(remotes))
+	for _, l := range remotes {
+		if l.health != nil {
+			health = append(health, l.health.status(l.source.Name))
+		}
+	}
+	return health
+}
+
+// remoteHealth applies the retry policy and the circuit breaker to the
+// fetches of a remote source, and tracks its health.
+type remoteHealth struct {
+	opts remoteOptions
+
+	mu          sync.Mutex
+	state       CircuitState
+	failures    int
+	openedAt    time.Time
+	lastErr     error
+	lastSuccess time.Time
+}
+
+func newRemoteHealth(opts remoteOptions) *remoteHealth {
+	return &remoteHealth{opts: opts, state: CircuitClosed}
+}
+
+func (h *remoteHealth) status(name string) RemoteHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	state := h.state
+	if state == CircuitOpen && time.Since(h.openedAt) >= h.opts.breakerCooldown {
+		state = CircuitHalfOpen
+	}
+	return RemoteHealth{
+		Name:        name,
+		State:       state,
+		Failures:    h.failures,
+		LastError:   h.lastErr,
+		LastSuccess: h.lastSuccess,
+	}
+}
+
+// fetch fetches p with retries while the circuit breaker allows.
+func (h *remoteHealth) fetch(ctx context.Context, p RemoteProvider) (map[interface{}]interface{}, error) {
+	attempts := h.opts.retryAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := h.opts.retryBackoff
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			if err := sleepContext(ctx, jitter(backoff)); err != nil {
+				return nil, err
+			}
+			if backoff *= 2; h.opts.retryMaxBackoff > 0 && backoff > h.opts.retryMaxBackoff {
+				backoff = h.opts.retryMaxBackoff
+			}
+		}
+		if !h.allow() {
+			return nil, ErrCircuitOpen
+		}
+		var data map[interface{}]interface{}
+		if data, err = p.Fetch(ctx); err == nil {
+			h.succeed()
+			return data, nil
+		}
+		h.fail(err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, err
+}
+
+// allow returns whether a fetch may be tried, only one fetch is tried once
+// the cooldown of an open breaker passes.
+func (h *remoteHealth) allow() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch h.state {
+	case CircuitOpen:
+		if time.Since(h.openedAt) < h.opts.breakerCooldown {
+			return false
+		}
+		h.state = CircuitHalfOpen
+		return true
+	case CircuitHalfOpen:
+		// 已有一次尝试在进行中
+		return false
+	default:
+		return true
+	}
+}
+
+func (h *remoteHealth) succeed() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.state, h.failures, h.lastSuccess = CircuitClosed, 0, time.Now()
+}
+
+func (h *remoteHealth) fail(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.failures++
+	h.lastErr = err
+	if h.opts.breakerThreshold <= 0 {
+		return
+	}
+	if h.state == CircuitHalfOpen || h.failures >= h.opts.breakerThreshold {
+		h.state, h.openedAt = CircuitOpen, time.Now()
+	}
+}
+
+// jitter returns a random duration between d/2 and d.
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyProvider fails the fetches while err is set.
+type flakyProvider struct {
+	mu      sync.Mutex
+	err     error
+	fetches int
+}
+
+func (p *flakyProvider) Fetch(ctx context.Context) (map[interface{}]interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.fetches++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return map[interface{}]interface{}{"port": 8080}, nil
+}
+
+func (p *flakyProvider) set(err error) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.err = err
+	n := p.fetches
+	p.fetches = 0
+	return n
+}
+
+func TestRetry(t *testing.T) {
+	c, err := FromString("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &flakyProvider{err: errors.New("unavailable")}
+	err = c.AddRemote(context.Background(), "flaky", p, WithRetry(3, time.Millisecond, 2*time.Millisecond))
+	if err == nil {
+		t.Fatal("AddRemote() error = nil, want the error of the last fetch")
+	}
+	if n := p.set(nil); n != 3 {
+		t.Errorf("fetches = %d, want 3", n)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	c, err := FromString("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &flakyProvider{}
+	if err := c.AddRemote(context.Background(), "flaky", p, WithCircuitBreaker(2, 50*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	health := func() RemoteHealth {
+		h := c.RemoteHealth()
+		if len(h) != 1 {
+			t.Fatalf("RemoteHealth() = %v, want 1 source", h)
+		}
+		return h[0]
+	}
+	if h := health(); h.Name != "flaky" || h.State != CircuitClosed || h.LastSuccess.IsZero() {
+		t.Errorf("RemoteHealth() = %+v, want a closed breaker", h)
+	}
+
+	// 连续失败达到阈值后打开
+	p.set(errors.New("unavailable"))
+	for i := 0; i < 2; i++ {
+		if _, err := c.Reload(); err == nil {
+			t.Fatal("Reload() error = nil, want an error")
+		}
+	}
+	if h := health(); h.State != CircuitOpen || h.Failures != 2 || h.LastError == nil {
+		t.Errorf("RemoteHealth() = %+v, want an open breaker after 2 failures", h)
+	}
+	p.set(nil)
+	if _, err := c.Reload(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Reload() error = %v, want ErrCircuitOpen", err)
+	}
+	if n := p.set(nil); n != 0 {
+		t.Errorf("fetches while open = %d, want 0", n)
+	}
+
+	// 冷却结束后尝试一次，成功则关闭
+	time.Sleep(60 * time.Millisecond)
+	if h := health(); h.State != CircuitHalfOpen {
+		t.Errorf("RemoteHealth() = %+v, want a half-open breaker after the cooldown", h)
+	}
+	if _, err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if h := health(); h.State != CircuitClosed || h.Failures != 0 {
+		t.Errorf("RemoteHealth() = %+v, want a closed breaker", h)
+	}
+}
+
+func TestCache(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "remote.cache")
+	c, err := FromString("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddRemote(context.Background(), "flaky", &flakyProvider{}, WithCache(file)); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("mode of the cache = %v, want 0600", mode)
+	}
+
+	// 配置源不可用时从缓存加载
+	c, err = FromString("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &flakyProvider{err: errors.New("unavailable")}
+	if err := c.AddRemote(context.Background(), "flaky", p, WithCache(file)); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetInt("port"); err != nil || got != 8080 {
+		t.Errorf("GetInt(port) = %d, %v, want 8080 from the cache", got, err)
+	}
+	if w := c.Warnings(); len(w) != 1 || w[0].Kind != WarningStaleCache || w[0].Source != "flaky" {
+		t.Errorf("Warnings() = %v, want a warning of the stale cache", w)
+	}
+
+	if err := ioutil.WriteFile(file, []byte("port: [\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err = FromString("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddRemote(context.Background(), "flaky", p, WithCache(file)); err == nil {
+		t.Error("AddRemote() error = nil for an invalid cache, want an error")
+	}
+}
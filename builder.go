@@ -0,0 +1,95 @@
+package config
+
+// Builder constructs a config in code, such as in unit tests:
+//
+//	cfg, err := config.NewBuilder().
+//		Set("server.port", 8080).
+//		SetMap("db", map[string]interface{}{"host": "localhost"}).
+//		Build()
+type Builder struct {
+	config *Config // 用于解析key
+	data   map[interface{}]interface{}
+	opts   []Option
+	err    error
+}
+
+// NewBuilder create a builder, opts are applied to the built config.
+func NewBuilder(opts ...Option) *Builder {
+	return &Builder{
+		config: newConfig(opts...),
+		data:   make(map[interface{}]interface{}),
+		opts:   opts,
+	}
+}
+
+// Set sets the value of a given key, missing maps are created and an index
+// equal to the length of a slice appends to it.
+// Support multi-level key which concat with '.'.
+func (b *Builder) Set(key string, value interface{}) *Builder {
+	if b.err != nil {
+		return b
+	}
+	keyArr, err := b.config.parseKey(key)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	value = normalizeValue(value)
+	if err := checkValue(value, key, b.config.Delimiter); err != nil {
+		b.err = err
+		return b
+	}
+	if _, err := setKeys(b.data, keyArr, value, b.config.Delimiter); err != nil {
+		b.err = err
+	}
+	return b
+}
+
+// SetMap deep merges m into the map of a given key, an empty key merges m
+// into the whole config.
+func (b *Builder) SetMap(key string, m map[string]interface{}) *Builder {
+	if b.err != nil {
+		return b
+	}
+	src, _ := normalizeValue(m).(map[interface{}]interface{})
+	if err := checkValue(src, key, b.config.Delimiter); err != nil {
+		b.err = err
+		return b
+	}
+	if key == "" {
+		b.config.newMerger().merge(b.data, src)
+		return b
+	}
+
+	keyArr, err := b.config.parseKey(key)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	dst, ok := lookupKeys(b.data, keyArr)
+	dstMap, isMap := dst.(map[interface{}]interface{})
+	if !ok || !isMap {
+		dstMap = make(map[interface{}]interface{}, len(src))
+	}
+	b.config.newMerger().merge(dstMap, src)
+	return b.Set(key, dstMap)
+}
+
+// Build creates the config, the first error of the setters is returned if
+// any of them failed.
+func (b *Builder) Build() (*Config, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	cfgData, _ := copyTree(b.data).(map[interface{}]interface{})
+	return fromData(cfgData, b.opts...)
+}
+
+// MustBuild is like Build but panics if error occur.
+func (b *Builder) MustBuild() *Config {
+	c, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	c, err := NewBuilder(WithDelimiter("/")).
+		Set("server/port", 8080).
+		Set("hosts", []string{"a"}).
+		Set("hosts[1]", "b").
+		SetMap("db", map[string]interface{}{"host": "localhost"}).
+		SetMap("db", map[string]interface{}{"port": 3306}).
+		SetMap("", map[string]interface{}{"name": "app"}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]interface{}{
+		"server/port": 8080,
+		"hosts":       []interface{}{"a", "b"},
+		"db":          map[interface{}]interface{}{"host": "localhost", "port": 3306},
+		"name":        "app",
+	}
+	for key, want := range tests {
+		if got, err := c.Get(key); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("Get(%q) = %#v, %v, want %#v", key, got, err, want)
+		}
+	}
+
+	invalid := []struct {
+		name string
+		b    *Builder
+	}{
+		{name: "invalid key", b: NewBuilder().Set("[0]", 1)},
+		{name: "index out of range", b: NewBuilder().Set("hosts", []string{}).Set("hosts[2]", 1)},
+		{name: "invalid value", b: NewBuilder().Set("ch", make(chan int))},
+		{name: "first error", b: NewBuilder().Set("[0]", 1).Set("a", 1)},
+	}
+	for _, tt := range invalid {
+		if _, err := tt.b.Build(); err == nil {
+			t.Errorf("Build() of %s error = nil, want an error", tt.name)
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustBuild() doesn't panic for an invalid key")
+		}
+	}()
+	NewBuilder().Set("[0]", 1).MustBuild()
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// WarningStaleCache is the kind of the warning for a remote source loaded
+// from its cache file, see WithCache.
+const WarningStaleCache = "stale_cache"
+
+// WithCache saves the last config tree fetched from the remote source to a
+// local file, and loads the file if the source is unreachable when it is
+// added, so that an outage of the config service doesn't prevent services
+// from starting. A warning with the time of the cache is reported in that
+// case, see Warnings. The file is written with mode 0600 since it may
+// contain secrets, signatures are kept and verified on loading.
+func WithCache(file string) RemoteOption {
+	return func(o *remoteOptions) {
+		o.cacheFile = file
+	}
+}
+
+// fetchRemote fetches and verifies the config tree of a remote source, the
+// retry policy and the circuit breaker of h apply. If cached is true, a copy
+// of the tree as fetched is returned as well to be saved by writeCache once
+// the tree is used.
+func (c *Config) fetchRemote(ctx context.Context, name string, p RemoteProvider, h *remoteHealth, cached bool) (data, raw map[interface{}]interface{}, err error) {
+	if data, err = h.fetch(ctx, p); err != nil {
+		return nil, nil, err
+	}
+	if cached {
+		raw = cacheCopy(data)
+	}
+	if err := c.verifyTree(data, "remote source `"+name+"`"); err != nil {
+		return nil, nil, err
+	}
+	return data, raw, nil
+}
+
+// cacheCopy returns a copy of a fetched config tree to cache, the copy is
+// taken before verifying so that the signature is kept.
+func cacheCopy(data map[interface{}]interface{}) map[interface{}]interface{} {
+	return copyTree(data).(map[interface{}]interface{})
+}
+
+// loadCache loads the cache file of a remote source unreachable because of
+// fetchErr, and reports a warning.
+func (c *Config) loadCache(cacheFile, name string, fetchErr error) (map[interface{}]interface{}, error) {
+	info, err := os.Stat(cacheFile)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(cacheFile)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.parseYAML(b, cacheFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.verifyTree(data, "cache `"+cacheFile+"`"); err != nil {
+		return nil, err
+	}
+
+	msg := fmt.Sprintf("remote source `%s` is unreachable, using the cache `%s` saved at %s: %s",
+		name, cacheFile, info.ModTime().Format(time.RFC3339), fetchErr)
+	c.warn(Warning{Kind: WarningStaleCache, Source: name, Message: msg})
+	c.log().Printf("%s", msg)
+	return data, nil
+}
+
+// writeCache saves the config tree of a remote source, failures are logged.
+func (c *Config) writeCache(cacheFile, name string, data map[interface{}]interface{}) {
+	b, err := yaml.Marshal(data)
+	if err == nil {
+		// 先写临时文件再替换，避免读到写了一半的缓存
+		tmp := cacheFile + ".tmp"
+		if err = ioutil.WriteFile(tmp, b, 0600); err == nil {
+			err = os.Rename(tmp, cacheFile)
+		}
+	}
+	if err != nil {
+		c.log().Printf("save cache of remote source `%s` failed: %s", name, err)
+	}
+}
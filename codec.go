@@ -0,0 +1,145 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Codec decodes and encodes config documents of a format, such as CUE or a
+// proprietary format.
+type Codec interface {
+	// Decode returns the config tree of a document.
+	Decode(b []byte) (map[interface{}]interface{}, error)
+	// Encode returns the document of a config tree.
+	Encode(data map[interface{}]interface{}) ([]byte, error)
+}
+
+var codecs = struct {
+	sync.RWMutex
+	m map[string]Codec
+}{m: map[string]Codec{
+	".yaml": yamlCodec{},
+	".yml":  yamlCodec{},
+	".json": jsonCodec{},
+}}
+
+// RegisterCodec registers a codec for the config files with a given
+// extension, such as `.cue`, so that FromFile, the overlays of
+// FromFileForEnv, SaveToFile and SaveMigrated use it for the files. The
+// codec is also used by the sources returning the format of the extension
+// without the dot. The yaml and JSON codecs are registered by default, files
+// with unknown extensions are yaml.
+//
+//	config.RegisterCodec(".toml", tomlCodec{})
+func RegisterCodec(ext string, codec Codec) {
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	codecs.Lock()
+	defer codecs.Unlock()
+
+	codecs.m[strings.ToLower(ext)] = codec
+}
+
+// lookupCodec returns the codec registered for an extension.
+func lookupCodec(ext string) (Codec, bool) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+
+	codec, ok := codecs.m[strings.ToLower(ext)]
+	return codec, ok
+}
+
+// codecOf returns the codec of a file by its extension.
+func codecOf(file string) Codec {
+	if codec, ok := lookupCodec(filepath.Ext(file)); ok {
+		return codec
+	}
+	return yamlCodec{}
+}
+
+// parseFile decodes a config file by the codec of its extension.
+func (c *Config) parseFile(cfgBytes []byte, file string) (map[interface{}]interface{}, error) {
+	return c.parseCodec(codecOf(file), cfgBytes, file)
+}
+
+// parseCodec decodes a config document with a codec, yaml documents are
+// decoded by parseYAML. name describes the document in errors.
+func (c *Config) parseCodec(codec Codec, cfgBytes []byte, name string) (map[interface{}]interface{}, error) {
+	if _, ok := codec.(yamlCodec); ok {
+		return c.parseYAML(cfgBytes, name)
+	}
+	if c.maxFileSize > 0 && int64(len(cfgBytes)) > c.maxFileSize {
+		return nil, limitError(name, "is larger than the max file size of %d bytes", c.maxFileSize)
+	}
+
+	data, err := codec.Decode(trimBOM(cfgBytes))
+	if err != nil {
+		return nil, err
+	}
+	// 第三方codec可能返回以string为key的map
+	cfgData, _ := normalizeValue(data).(map[interface{}]interface{})
+	if cfgData == nil {
+		cfgData = make(map[interface{}]interface{})
+	}
+	if c.maxDepth > 0 && treeDepth(cfgData, c.maxDepth) > c.maxDepth {
+		return nil, limitError(name, "is nested deeper than the max depth of %d", c.maxDepth)
+	}
+	return cfgData, nil
+}
+
+// SaveToFile writes the effective config to a file, encoded by the codec
+// of its extension. The encrypted values and the secret references are kept
+// as they are in the files, so that the file can be loaded again.
+func (c *Config) SaveToFile(file string) error {
+	snap := c.snap()
+	data := snap.raw
+	if data == nil {
+		data = snap.data
+	}
+	b, err := codecOf(file).Encode(data)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, b, 0644)
+}
+
+type yamlCodec struct{}
+
+func (yamlCodec) Decode(b []byte) (map[interface{}]interface{}, error) {
+	data, _, err := decodeYAML(b, "", 0, 0)
+	return data, err
+}
+
+func (yamlCodec) Encode(data map[interface{}]interface{}) ([]byte, error) {
+	return yaml.Marshal(data)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Decode(b []byte) (map[interface{}]interface{}, error) {
+	v, err := decodeJSON(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	data, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("invalid JSON: should be a JSON object")
+	}
+	return data, nil
+}
+
+func (jsonCodec) Encode(data map[interface{}]interface{}) ([]byte, error) {
+	b, err := json.MarshalIndent(stringKeyed(data), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
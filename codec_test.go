@@ -0,0 +1,104 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// kvCodec decodes `key=value` lines to a flat config tree.
+type kvCodec struct{}
+
+func (kvCodec) Decode(b []byte) (map[interface{}]interface{}, error) {
+	data := make(map[interface{}]interface{})
+	for _, line := range strings.Split(string(b), "\n") {
+		if line == "" {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, errors.New("invalid line: " + line)
+		}
+		data[kv[0]] = kv[1]
+	}
+	return data, nil
+}
+
+func (kvCodec) Encode(data map[interface{}]interface{}) ([]byte, error) {
+	lines := make([]string, 0, len(data))
+	for k, v := range data {
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New("value of `" + k.(string) + "` is not a string")
+		}
+		lines = append(lines, k.(string)+"="+s)
+	}
+	sort.Strings(lines)
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+func TestRegisterCodec(t *testing.T) {
+	RegisterCodec("KV", kvCodec{})
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.kv")
+	if err := ioutil.WriteFile(file, []byte("host=localhost\nport=8080\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := FromFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetInt("port"); err != nil || got != 8080 {
+		t.Errorf("GetInt(port) = %d, %v, want 8080", got, err)
+	}
+
+	out := filepath.Join(dir, "out.kv")
+	if err := c.SaveToFile(out); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(out); string(b) != "host=localhost\nport=8080\n" {
+		t.Errorf("SaveToFile() wrote %q", b)
+	}
+
+	if err := ioutil.WriteFile(file, []byte("host\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FromFile(file); err == nil {
+		t.Error("FromFile() error = nil for an invalid document, want an error")
+	}
+}
+
+func TestSaveToFile(t *testing.T) {
+	c, err := FromString("server: {host: localhost, port: 8080}\nhosts: [a, b]\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"config.json", "config.yaml", "config.conf"} {
+		file := filepath.Join(dir, name)
+		if err := c.SaveToFile(file); err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if json := bytes.HasPrefix(b, []byte("{")); json != (name == "config.json") {
+			t.Errorf("SaveToFile(%q) wrote %s", name, b)
+		}
+		saved, err := FromFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := saved.GetInt("server.port"); err != nil || got != 8080 {
+			t.Errorf("GetInt(server.port) of %s = %d, %v, want 8080", name, got, err)
+		}
+		if got, err := saved.GetStringArray("hosts"); err != nil || len(got) != 2 {
+			t.Errorf("GetStringArray(hosts) of %s = %v, %v, want [a b]", name, got, err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// CoercionPolicy decides which conversions the getters apply to values of
+// other types. GetSlice follows it as well, Unmarshal only converts with
+// WithWeakDecoding.
+type CoercionPolicy struct {
+	// StringToNumber converts strings to numbers, such as "8080" for GetInt.
+	StringToNumber bool
+	// StringToBool converts the strings accepted by strconv.ParseBool, such
+	// as "true" and "0", for GetBool.
+	StringToBool bool
+	// ExtendedBool converts yes/no, on/off and y/n for GetBool.
+	ExtendedBool bool
+	// NumberToString converts numbers to strings for GetString.
+	NumberToString bool
+	// ScalarToList converts a scalar to a list with one element for the
+	// getters of lists, such as "a" for GetStringArray.
+	ScalarToList bool
+}
+
+var (
+	// DefaultCoercion is the policy of configs created without options.
+	DefaultCoercion = CoercionPolicy{StringToNumber: true, StringToBool: true}
+	// StrictCoercion converts nothing.
+	StrictCoercion = CoercionPolicy{}
+)
+
+// WithCoercion sets the conversions the getters apply, the default is
+// DefaultCoercion.
+func WithCoercion(policy CoercionPolicy) Option {
+	return func(c *Config) {
+		c.coercion = policy
+	}
+}
+
+// WithStrictTypes disables converting strings to numbers and booleans in
+// getters, such as GetInt("port") for `port: "8080"`, so that type errors
+// in config files are reported. It is the same as WithCoercion(StrictCoercion).
+func WithStrictTypes() Option {
+	return WithCoercion(StrictCoercion)
+}
+
+// stringToInt converts a string to int for getters.
+func (c *Config) stringToInt(s string) (int, bool) {
+	if !c.coercion.StringToNumber {
+		return 0, false
+	}
+	i, err := strconv.Atoi(s)
+	return i, err == nil
+}
+
+// stringToFloat converts a string to float64 for getters.
+func (c *Config) stringToFloat(s string) (float64, bool) {
+	if !c.coercion.StringToNumber {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
+
+// stringToBool converts a string to bool for getters.
+func (c *Config) stringToBool(s string) (bool, bool) {
+	if c.coercion.StringToBool {
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b, true
+		}
+	}
+	if c.coercion.ExtendedBool {
+		switch strings.ToLower(s) {
+		case "yes", "y", "on":
+			return true, true
+		case "no", "n", "off":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// toString returns a string value for getters.
+func (c *Config) toString(v interface{}) (string, bool) {
+	switch vv := v.(type) {
+	case string:
+		return vv, true
+	case int:
+		if c.coercion.NumberToString {
+			return strconv.Itoa(vv), true
+		}
+	case float64:
+		if c.coercion.NumberToString {
+			return strconv.FormatFloat(vv, 'g', -1, 64), true
+		}
+	}
+	return "", false
+}
+
+// toList returns a list value for getters.
+func (c *Config) toList(v interface{}) ([]interface{}, bool) {
+	switch vv := v.(type) {
+	case []interface{}:
+		return vv, true
+	case string, int, float64, bool:
+		if c.coercion.ScalarToList {
+			return []interface{}{vv}, true
+		}
+	}
+	return nil, false
+}
+
+// coerceScalar converts strings to numbers and booleans, as the getters do.
+func (c *Config) coerceScalar(value interface{}, target reflect.Type) (interface{}, error) {
+	if target == durationType {
+		return value, nil
+	}
+
+	switch target.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if str, ok := value.(string); ok {
+			if i, ok := c.stringToInt(str); ok {
+				return i, nil
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		if str, ok := value.(string); ok {
+			if f, ok := c.stringToFloat(str); ok {
+				return f, nil
+			}
+		}
+	case reflect.Bool:
+		if str, ok := value.(string); ok {
+			if b, ok := c.stringToBool(str); ok {
+				return b, nil
+			}
+		}
+	case reflect.String:
+		if str, ok := c.toString(value); ok {
+			return str, nil
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCoercionPolicy(t *testing.T) {
+	const yaml = "port: \"8080\"\nratio: \"0.5\"\ndebug: \"true\"\nverbose: \"yes\"\nname: 42\nhosts: a\n"
+	tests := []struct {
+		name   string
+		opts   []Option
+		valid  []string // 能转换的key
+		strict []string // 不能转换的key
+	}{
+		{
+			name:   "default",
+			valid:  []string{"port", "ratio", "debug"},
+			strict: []string{"verbose", "name", "hosts"},
+		},
+		{
+			name:   "strict",
+			opts:   []Option{WithStrictTypes()},
+			strict: []string{"port", "ratio", "debug", "verbose", "name", "hosts"},
+		},
+		{
+			name: "all",
+			opts: []Option{WithCoercion(CoercionPolicy{
+				StringToNumber: true,
+				StringToBool:   true,
+				ExtendedBool:   true,
+				NumberToString: true,
+				ScalarToList:   true,
+			})},
+			valid: []string{"port", "ratio", "debug", "verbose", "name", "hosts"},
+		},
+	}
+	get := map[string]func(c *Config) (interface{}, error){
+		"port":    func(c *Config) (interface{}, error) { return c.GetInt("port") },
+		"ratio":   func(c *Config) (interface{}, error) { return c.GetFloat("ratio") },
+		"debug":   func(c *Config) (interface{}, error) { return c.GetBool("debug") },
+		"verbose": func(c *Config) (interface{}, error) { return c.GetBool("verbose") },
+		"name":    func(c *Config) (interface{}, error) { return c.GetString("name") },
+		"hosts":   func(c *Config) (interface{}, error) { return c.GetStringArray("hosts") },
+	}
+	want := map[string]interface{}{
+		"port":    8080,
+		"ratio":   0.5,
+		"debug":   true,
+		"verbose": true,
+		"name":    "42",
+		"hosts":   []string{"a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := FromString(yaml, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, key := range tt.valid {
+				if got, err := get[key](c); err != nil || !reflect.DeepEqual(got, want[key]) {
+					t.Errorf("get %s = %v, %v, want %v", key, got, err, want[key])
+				}
+			}
+			for _, key := range tt.strict {
+				if got, err := get[key](c); err == nil {
+					t.Errorf("get %s = %v, want an error", key, got)
+				}
+			}
+		})
+	}
+}
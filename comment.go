@@ -0,0 +1,167 @@
+package config
+
+import (
+	"errors"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// GetComment returns the comment of a given key in the config files, the
+// comment lines above the key come first, then the comment at the end of
+// its line. The leading `#` of every line is removed. The comment is taken
+// from the file of the highest precedence commenting the key, an empty
+// string is returned if the key is not commented.
+// Support multi-level key which concat with '.'.
+func (c *Config) GetComment(key string) (string, error) {
+	if _, err := c.get(key); err != nil && !errors.Is(err, ErrNullValue) {
+		return "", err
+	}
+	keyArr, err := c.parseKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	layers := make([]*layer, 0, len(c.defaultLayers)+len(c.fileLayers))
+	layers = append(layers, c.defaultLayers...)
+	layers = append(layers, c.fileLayers...)
+	profile := c.profile
+	c.mu.Unlock()
+
+	// 所选profile中的注释优先
+	paths := [][]interface{}{keyArr}
+	if profile != "" {
+		paths = append(paths, append([]interface{}{profilesKey, profile}, keyArr...))
+	}
+	for i := len(paths) - 1; i >= 0; i-- {
+		for j := len(layers) - 1; j >= 0; j-- {
+			if layers[j].doc == nil {
+				continue
+			}
+			comment, err := findComment(layers[j].doc, paths[i])
+			if err != nil {
+				return "", err
+			}
+			if comment != "" {
+				return comment, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// findComment parses a config document and returns the comment of a key.
+func findComment(doc []byte, keyArr []interface{}) (string, error) {
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(trimBOM(doc), &root); err != nil {
+		return "", err
+	}
+	if root.Kind != yamlv3.DocumentNode || len(root.Content) == 0 {
+		return "", nil
+	}
+	keyNode, valueNode := lookupNode(root.Content[0], keyArr)
+	if valueNode == nil {
+		return "", nil
+	}
+
+	var lines []string
+	if keyNode != nil {
+		lines = append(lines, commentLines(keyNode.HeadComment)...)
+		lines = append(lines, commentLines(keyNode.LineComment)...)
+	} else {
+		lines = append(lines, commentLines(valueNode.HeadComment)...)
+	}
+	// 标量值的行尾注释也可能记录在值上
+	lines = append(lines, commentLines(valueNode.LineComment)...)
+	return strings.Join(lines, "\n"), nil
+}
+
+// lookupNode returns the key node and value node of a key path, keyNode is
+// nil for the elements of a sequence.
+func lookupNode(node *yamlv3.Node, keyArr []interface{}) (keyNode, valueNode *yamlv3.Node) {
+	valueNode = node
+	for _, k := range keyArr {
+		for valueNode.Kind == yamlv3.AliasNode {
+			valueNode = valueNode.Alias
+		}
+
+		switch key := k.(type) {
+		case string:
+			if valueNode.Kind != yamlv3.MappingNode {
+				return nil, nil
+			}
+			keyNode, valueNode = lookupMappingNode(valueNode, key)
+			if valueNode == nil {
+				return nil, nil
+			}
+		case int:
+			if valueNode.Kind != yamlv3.SequenceNode {
+				return nil, nil
+			}
+			index, ok := sliceIndex(key, len(valueNode.Content))
+			if !ok {
+				return nil, nil
+			}
+			keyNode, valueNode = nil, valueNode.Content[index]
+		}
+	}
+	return keyNode, valueNode
+}
+
+// lookupMappingNode finds a key in a mapping node, including the maps
+// merged by `<<`.
+func lookupMappingNode(node *yamlv3.Node, key string) (keyNode, valueNode *yamlv3.Node) {
+	var merged []*yamlv3.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		if k.Kind != yamlv3.ScalarNode {
+			continue
+		}
+		if k.Tag == "!!merge" {
+			merged = append(merged, v)
+			continue
+		}
+		if name, _, _ := parseMergeDirective(k.Value); name == key {
+			return k, v
+		}
+	}
+
+	// 本地的key优先于合并的key
+	for _, m := range merged {
+		for m.Kind == yamlv3.AliasNode {
+			m = m.Alias
+		}
+		sources := []*yamlv3.Node{m}
+		if m.Kind == yamlv3.SequenceNode {
+			sources = m.Content
+		}
+		for _, src := range sources {
+			for src.Kind == yamlv3.AliasNode {
+				src = src.Alias
+			}
+			if src.Kind != yamlv3.MappingNode {
+				continue
+			}
+			if k, v := lookupMappingNode(src, key); v != nil {
+				return k, v
+			}
+		}
+	}
+	return nil, nil
+}
+
+// commentLines splits a comment into lines, without the leading `#`.
+func commentLines(comment string) []string {
+	if comment == "" {
+		return nil
+	}
+	lines := strings.Split(comment, "\n")
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		result = append(result, line)
+	}
+	return result
+}
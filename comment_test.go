@@ -0,0 +1,65 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetComment(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	doc := `# the server
+server:
+  # listen host
+  # of the server
+  host: localhost # or 0.0.0.0
+  port: 8080
+base: &base
+  # shared user
+  user: root
+db:
+  <<: *base
+hosts:
+  # first host
+  - a
+profiles:
+  prod:
+    server:
+      # production port
+      port: 443
+`
+	if err := ioutil.WriteFile(file, []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := FromFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "server", want: "the server"},
+		{key: "server.host", want: "listen host\nof the server\nor 0.0.0.0"},
+		{key: "server.port", want: ""},
+		{key: "db.user", want: "shared user"},
+		{key: "hosts[0]", want: "first host"},
+	}
+	for _, tt := range tests {
+		if got, err := c.GetComment(tt.key); err != nil || got != tt.want {
+			t.Errorf("GetComment(%q) = %q, %v, want %q", tt.key, got, err, tt.want)
+		}
+	}
+	if _, err := c.GetComment("server.user"); err == nil {
+		t.Error("GetComment() error = nil for a missing key, want an error")
+	}
+
+	prod, err := FromFile(file, WithProfile("prod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := prod.GetComment("server.port"); err != nil || got != "production port" {
+		t.Errorf("GetComment(server.port) of profile prod = %q, %v, want production port", got, err)
+	}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// CompiledKey is a key parsed by Compile, reading it with the getters for
+// compiled keys doesn't parse the key again. It is safe for concurrent use.
+type CompiledKey struct {
+	key       string
+	keyArr    []interface{}
+	namespace string
+	indexKey  string // 索引中的key，为空时按路径查找
+	delimiter string
+}
+
+// Compile parses a key once for the getters for compiled keys, it is useful
+// for keys read in hot loops, such as per request limits.
+// Support multi-level key which concat with '.'.
+func (c *Config) Compile(key string) (*CompiledKey, error) {
+	keyArr, err := c.parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &CompiledKey{key: key, keyArr: keyArr, delimiter: c.Delimiter}
+	k.namespace, _ = keyArr[0].(string)
+	for _, item := range keyArr {
+		switch v := item.(type) {
+		case string:
+			if !indexable(v) {
+				k.indexKey = ""
+				return k, nil
+			}
+			k.indexKey = joinKey(k.indexKey, v, c.Delimiter)
+		case int:
+			// 负数索引不在索引中
+			if v < 0 {
+				k.indexKey = ""
+				return k, nil
+			}
+			k.indexKey = fmt.Sprintf("%s[%d]", k.indexKey, v)
+		}
+	}
+	return k, nil
+}
+
+// MustCompile is like Compile but panics if error occur.
+func (c *Config) MustCompile(key string) *CompiledKey {
+	k, err := c.Compile(key)
+	if err != nil {
+		mustPanic(key, err)
+	}
+	return k
+}
+
+// String returns the key as given to Compile.
+func (k *CompiledKey) String() string {
+	return k.key
+}
+
+// GetCompiled is like Get but reads a compiled key.
+func (c *Config) GetCompiled(k *CompiledKey) (interface{}, error) {
+	v, err := c.getCompiled(k)
+	for _, fn := range c.accessHooks {
+		fn(k.key, err == nil)
+	}
+	return v, err
+}
+
+func (c *Config) getCompiled(k *CompiledKey) (interface{}, error) {
+	c.checkStat()
+	if err := c.loadNamespace(k.namespace); err != nil {
+		return nil, err
+	}
+	snap := c.current()
+	if k.indexKey != "" {
+		if v, ok := snap.lookupIndex(k.indexKey, k.delimiter); ok && v != nil {
+			return v, nil
+		}
+	}
+	return c.getKeys(snap.data, k.keyArr)
+}
+
+// GetStringCompiled is like GetString but reads a compiled key.
+func (c *Config) GetStringCompiled(k *CompiledKey) (string, error) {
+	v, err := c.GetCompiled(k)
+	if err != nil {
+		return "", err
+	}
+	return c.asString(k.key, v)
+}
+
+// GetIntCompiled is like GetInt but reads a compiled key.
+func (c *Config) GetIntCompiled(k *CompiledKey) (int, error) {
+	v, err := c.GetCompiled(k)
+	if err != nil {
+		return 0, err
+	}
+	return c.asInt(k.key, v)
+}
+
+// GetBoolCompiled is like GetBool but reads a compiled key.
+func (c *Config) GetBoolCompiled(k *CompiledKey) (bool, error) {
+	v, err := c.GetCompiled(k)
+	if err != nil {
+		return false, err
+	}
+	return c.asBool(k.key, v)
+}
+
+// GetFloatCompiled is like GetFloat but reads a compiled key.
+func (c *Config) GetFloatCompiled(k *CompiledKey) (float64, error) {
+	v, err := c.GetCompiled(k)
+	if err != nil {
+		return 0, err
+	}
+	return c.asFloat(k.key, v)
+}
+
+// GetDurationCompiled is like GetDuration but reads a compiled key.
+func (c *Config) GetDurationCompiled(k *CompiledKey) (time.Duration, error) {
+	v, err := c.GetCompiled(k)
+	if err != nil {
+		return 0, err
+	}
+	return c.asDuration(k.key, v)
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompile(t *testing.T) {
+	c, err := FromString("server: {port: \"8080\", debug: true, ratio: 0.5, timeout: 5s}\nhosts: [a, b]\n\"a.b\": {c: x}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := c.MustCompile("server.port")
+	if port.String() != "server.port" {
+		t.Errorf("String() = %q, want server.port", port.String())
+	}
+	if got, err := c.GetIntCompiled(port); err != nil || got != 8080 {
+		t.Errorf("GetIntCompiled(server.port) = %d, %v, want 8080", got, err)
+	}
+	if got, err := c.GetBoolCompiled(c.MustCompile("server.debug")); err != nil || !got {
+		t.Errorf("GetBoolCompiled(server.debug) = %v, %v, want true", got, err)
+	}
+	if got, err := c.GetFloatCompiled(c.MustCompile("server.ratio")); err != nil || got != 0.5 {
+		t.Errorf("GetFloatCompiled(server.ratio) = %v, %v, want 0.5", got, err)
+	}
+	if got, err := c.GetDurationCompiled(c.MustCompile("server.timeout")); err != nil || got != 5*time.Second {
+		t.Errorf("GetDurationCompiled(server.timeout) = %v, %v, want 5s", got, err)
+	}
+	for key, want := range map[string]string{"hosts[1]": "b", "hosts[-1]": "b", `"a.b".c`: "x"} {
+		if got, err := c.GetStringCompiled(c.MustCompile(key)); err != nil || got != want {
+			t.Errorf("GetStringCompiled(%q) = %q, %v, want %q", key, got, err, want)
+		}
+	}
+	if _, err := c.GetCompiled(c.MustCompile("server.host")); err == nil {
+		t.Error("GetCompiled() error = nil for a missing key, want an error")
+	}
+
+	// 编译的key读取修改后的配置
+	if err := c.SetWithSource("server.port", 9090, SourceInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetIntCompiled(port); err != nil || got != 9090 {
+		t.Errorf("GetIntCompiled(server.port) after SetWithSource = %d, %v, want 9090", got, err)
+	}
+
+	if _, err := c.Compile("[0]"); err == nil {
+		t.Error("Compile() error = nil for an invalid key, want an error")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustCompile() doesn't panic for an invalid key")
+		}
+	}()
+	c.MustCompile("[0]")
+}
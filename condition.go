@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+)
+
+// the keys of a conditional value
+const (
+	whenKey  = "when"
+	valueKey = "value"
+	elseKey  = "else"
+)
+
+// WithConditions evaluates the conditional values of the config files
+// against env, a conditional value is a map of a `when` expression, the
+// `value` used if it is true and an optional `else` value:
+//
+//	pool_size: {when: "env == 'prod'", value: 10, else: 1}
+//
+// If the expression is false and there is no `else`, the file doesn't set
+// the key, so the value of a lower precedence file is used. Expressions are
+// in the syntax of github.com/expr-lang/expr and can only use the names in env.
+func WithConditions(env map[string]interface{}) Option {
+	return func(c *Config) {
+		c.conditionEnv = env
+		c.programs = make(map[string]*vm.Program)
+	}
+}
+
+// applyConditions returns a copy of a config tree with the conditional
+// values replaced by the values chosen, the first error is recorded.
+func (c *Config) applyConditions(data map[interface{}]interface{}) map[interface{}]interface{} {
+	if c.conditionEnv == nil || data == nil {
+		return data
+	}
+	result, _ := c.evalConditions(data, "").(map[interface{}]interface{})
+	return result
+}
+
+func (c *Config) evalConditions(v interface{}, key string) interface{} {
+	switch vv := v.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[interface{}]interface{}, len(vv))
+		for k, item := range vv {
+			cKey := joinKey(key, fmt.Sprint(k), c.Delimiter)
+			if m, ok := item.(map[interface{}]interface{}); ok && isConditional(m) {
+				chosen, ok := c.chooseValue(m, cKey)
+				if !ok {
+					continue
+				}
+				item = chosen
+			}
+			result[k] = c.evalConditions(item, cKey)
+		}
+		return result
+
+	case []interface{}:
+		result := make([]interface{}, 0, len(vv))
+		for i, item := range vv {
+			cKey := key + "[" + strconv.Itoa(i) + "]"
+			if m, ok := item.(map[interface{}]interface{}); ok && isConditional(m) {
+				chosen, ok := c.chooseValue(m, cKey)
+				if !ok {
+					// 条件不成立且没有else时，从slice中去掉
+					continue
+				}
+				item = chosen
+			}
+			result = append(result, c.evalConditions(item, cKey))
+		}
+		return result
+
+	default:
+		return v
+	}
+}
+
+// isConditional returns whether a map is a conditional value.
+func isConditional(m map[interface{}]interface{}) bool {
+	if _, ok := m[whenKey].(string); !ok {
+		return false
+	}
+	if _, ok := m[valueKey]; !ok {
+		return false
+	}
+	for k := range m {
+		switch k {
+		case whenKey, valueKey, elseKey:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// chooseValue evaluates a conditional value, ok is false if the condition
+// is false and there is no else value.
+func (c *Config) chooseValue(m map[interface{}]interface{}, key string) (interface{}, bool) {
+	when := m[whenKey].(string)
+	program, ok := c.programs[when]
+	if !ok {
+		var err error
+		program, err = expr.Compile(when, expr.Env(c.conditionEnv), expr.AsBool())
+		if err != nil {
+			c.recordEvalErr(fmt.Errorf("condition of `%s` is invalid: %w", key, err))
+			return nil, false
+		}
+		// 缓存编译结果，重建配置时不再编译
+		c.programs[when] = program
+	}
+
+	out, err := expr.Run(program, c.conditionEnv)
+	if err != nil {
+		c.recordEvalErr(fmt.Errorf("condition of `%s` failed: %w", key, err))
+		return nil, false
+	}
+	if out.(bool) {
+		return m[valueKey], true
+	}
+	v, ok := m[elseKey]
+	return v, ok
+}
+
+// recordEvalErr records the first error of evaluating the config.
+func (c *Config) recordEvalErr(err error) {
+	if c.evalErr == nil {
+		c.evalErr = err
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithConditions(t *testing.T) {
+	const yaml = `pool_size: {when: "env == 'prod'", value: 10, else: 1}
+debug: {when: "env != 'prod'", value: true}
+hosts:
+  - a
+  - {when: "region == 'eu'", value: b}
+  - {when: "region == 'us'", value: c}
+plain: {when: x, value: 1, other: 2}
+`
+	env := map[string]interface{}{"env": "prod", "region": "eu"}
+	c, err := FromString(yaml, WithDefaults([]byte("debug: false\n")), WithConditions(env))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]interface{}{
+		"pool_size": 10,
+		"debug":     false, // 条件不成立且没有else时使用默认值
+		"hosts":     []interface{}{"a", "b"},
+		"plain":     map[interface{}]interface{}{"when": "x", "value": 1, "other": 2},
+	}
+	for key, want := range tests {
+		if got, err := c.Get(key); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("Get(%q) = %#v, %v, want %#v", key, got, err, want)
+		}
+	}
+
+	c, err = FromString(yaml, WithConditions(map[string]interface{}{"env": "dev", "region": "us"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetInt("pool_size"); err != nil || got != 1 {
+		t.Errorf("GetInt(pool_size) = %d, %v, want 1", got, err)
+	}
+	if got, err := c.GetStringArray("hosts"); err != nil || !reflect.DeepEqual(got, []string{"a", "c"}) {
+		t.Errorf("GetStringArray(hosts) = %v, %v, want [a c]", got, err)
+	}
+
+	// 不使用WithConditions时保留原样
+	c, err = FromString(yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetString("pool_size.when"); err != nil || got != "env == 'prod'" {
+		t.Errorf("GetString(pool_size.when) = %q, %v, want the expression", got, err)
+	}
+}
+
+func TestWithConditionsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+	}{
+		{name: "syntax", yaml: "a: {when: \"env ==\", value: 1}\n"},
+		{name: "unknown name", yaml: "a: {when: \"region == 'eu'\", value: 1}\n"},
+		{name: "not bool", yaml: "a: {when: \"env\", value: 1}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FromString(tt.yaml, WithConditions(map[string]interface{}{"env": "prod"})); err == nil {
+				t.Error("FromString() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -3,118 +3,627 @@
 package config
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"net"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
 
-	"gopkg.in/yaml.v2"
+	"github.com/expr-lang/expr/vm"
 )
 
 type Config struct {
-	Delimiter string
-	cfgData   map[interface{}]interface{}
+	// Delimiter separates the levels of keys, the default is ".". Set it by
+	// WithDelimiter, changing it after the config is created is not safe
+	// while the config is being read.
+	Delimiter  string
+	state      atomic.Value // *snapshot，当前的有效配置
+	mu         sync.Mutex   // 修改配置时加锁
+	arrayMerge ArrayMergeStrategy
+	strictKeys bool
+	coercion   CoercionPolicy
+	profile    string
+	profileEnv string
+
+	keyProvider KeyProvider
+	resolvers   map[string]Resolver
+	resolved    map[string]string // 密文或引用对应的明文
+	resolveErr  error
+
+	conditionEnv map[string]interface{}
+	expressions  bool
+	programs     map[string]*vm.Program // 编译过的表达式
+	evalErr      error
+
+	secrets      [][]interface{} // MarkSecret标记的key模式
+	decodeHooks  []DecodeHook
+	weakDecoding bool
+
+	defaultLayers []*layer                    // 默认配置文档，优先级最低
+	defaults      map[interface{}]interface{} // 代码中设置的默认值
+	fileLayers    []*layer                    // 配置文件及其include的文件
+	remoteLayers  []*layer                    // 远程配置源
+	edits         []edit                      // 运行时的修改，优先级最高
+
+	defaultsDocs     []defaultsDoc
+	validators       []keyValidator
+	schema           Schema
+	logger           Logger
+	accessHooks      []func(key string, found bool)
+	changeHooks      []func(changes []Change)
+	reloadErrorHooks []func(err error)
+	reloadErr        error
+
+	historySize int
+	history     []*revision
+	version     int
+	accessed    map[string]bool // 读取过的key
+	accessMu    sync.Mutex
+
+	migrations       []migration
+	deprecated       []keyMapping
+	deprecatedWarned map[string]bool
+	warnings         []Warning
+	warned           map[Warning]bool
+	warnMu           sync.Mutex
+	coerced          sync.Map // 已警告转换的key到转换的类型
+
+	configFile   string   // 加载的配置文件，用于重新加载
+	overlays     []string // 合并在配置文件之上的文件，可能不存在
+	lazyIncludes bool
+	includeDepth int
+	envPrefix    string
+	lazyPending  int32 // 尚未加载的include文件数
+
+	statInterval time.Duration
+	statChecked  int64                // 上次检查文件修改时间的UnixNano
+	mtimes       map[string]time.Time // 配置文件的修改时间
+	statMu       sync.Mutex
+
+	signatureKey ed25519.PublicKey
+
+	maxFileSize int64
+	maxDepth    int
+	maxNodes    int
+}
+
+// Option configures a Config while it is being created.
+type Option func(*Config)
+
+// WithDelimiter sets the delimiter separating the levels of keys, such as
+// "/" for keys like `server/port`.
+func WithDelimiter(delimiter string) Option {
+	return func(c *Config) {
+		c.Delimiter = delimiter
+	}
+}
+
+// WithIncludeDepth lets the included files include other files, up to depth
+// levels of includes. The default depth is 1, only the files included by
+// the config file are loaded. The files included by lazy includes are not
+// loaded.
+func WithIncludeDepth(depth int) Option {
+	return func(c *Config) {
+		c.includeDepth = depth
+	}
+}
+
+// WithArrayMerge sets the default strategy used to merge slices of included config.
+func WithArrayMerge(strategy ArrayMergeStrategy) Option {
+	return func(c *Config) {
+		c.arrayMerge = strategy
+	}
+}
+
+// WithStrictKeys rejects keys the application doesn't know about:
+// Validate reports keys not declared in the schema, and Unmarshal fails on
+// keys without a matching struct field.
+func WithStrictKeys() Option {
+	return func(c *Config) {
+		c.strictKeys = true
+	}
+}
+
+// WithAccessTracking records the keys read through getters, so that
+// UnusedKeys can report the keys never read.
+func WithAccessTracking() Option {
+	return func(c *Config) {
+		c.accessed = make(map[string]bool)
+		c.OnAccess(func(key string, found bool) {
+			if found {
+				c.accessMu.Lock()
+				c.accessed[key] = true
+				c.accessMu.Unlock()
+			}
+		})
+	}
+}
+
+// WithProfile selects a profile of the `profiles` section, its values
+// override the other values of the config files.
+//
+//	port: 80
+//	profiles:
+//	  prod:
+//	    port: 443
+func WithProfile(name string) Option {
+	return func(c *Config) {
+		c.profile = name
+	}
+}
+
+// WithProfileEnv selects the profile named by an environment variable, such
+// as APP_PROFILE. If the variable is set, it takes precedence over WithProfile.
+func WithProfileEnv(envVar string) Option {
+	return func(c *Config) {
+		c.profileEnv = envVar
+	}
+}
+
+// WithDefaults merges a yaml document beneath the config file, it is useful
+// for shipping defaults with the binary.
+func WithDefaults(cfgBytes []byte) Option {
+	return func(c *Config) {
+		c.defaultsDocs = append(c.defaultsDocs, defaultsDoc{"", func(*Config) ([]byte, error) {
+			return cfgBytes, nil
+		}})
+	}
+}
+
+// WithDefaultsFile merges the specified yaml file beneath the config file.
+func WithDefaultsFile(file string) Option {
+	return func(c *Config) {
+		c.defaultsDocs = append(c.defaultsDocs, defaultsDoc{file, func(c *Config) ([]byte, error) {
+			return c.readFile(file)
+		}})
+	}
+}
+
+type defaultsDoc struct {
+	name string
+	load func(c *Config) ([]byte, error)
 }
 
 // FromFile create a config with specified config file.
-func FromFile(configFile string) (*Config, error) {
-	cfgBytes, err := ioutil.ReadFile(configFile)
+func FromFile(configFile string, opts ...Option) (*Config, error) {
+	config, err := loadFile(configFile, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.finish(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// FromFileForEnv create a config with specified config file, then merges the
+// config file of the environment and the local config file over it if they
+// exist. For example, FromFileForEnv("config.yaml", "staging") loads
+// config.yaml, config.staging.yaml and config.local.yaml in order.
+func FromFileForEnv(configFile string, env string, opts ...Option) (*Config, error) {
+	config, err := loadFile(configFile, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := filepath.Ext(configFile)
+	base := strings.TrimSuffix(configFile, ext)
+	names := []string{"local"}
+	if env != "" {
+		names = []string{env, "local"}
+	}
+	for _, name := range names {
+		file := base + "." + name + ext
+		l, err := config.readOverlay(file)
+		if err != nil {
+			return nil, err
+		}
+		if l != nil {
+			config.fileLayers = append(config.fileLayers, l)
+		}
+		config.overlays = append(config.overlays, file)
+	}
+	if err := config.finish(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// readOverlay reads the specified config file merged over the loaded files,
+// nil is returned if the file does not exist.
+func (c *Config) readOverlay(file string) (*layer, error) {
+	cfgBytes, err := c.readFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	cfgData, err := c.parseSigned(cfgBytes, file)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := takePolicies(cfgData)
 	if err != nil {
 		return nil, err
 	}
+	return &layer{
+		source:     SourceInfo{LayerFile, file},
+		data:       cfgData,
+		doc:        cfgBytes,
+		policies:   policies,
+		arrayMerge: c.arrayMerge,
+		directives: true,
+	}, nil
+}
 
-	config, err := newConfigWithBytes(cfgBytes)
+// loadFile loads the config file and its included files.
+func loadFile(configFile string, opts ...Option) (*Config, error) {
+	config := newConfig(opts...)
+	if err := config.loadDefaults(); err != nil {
+		return nil, err
+	}
+	config.configFile = configFile
+	layers, pending, err := config.readFiles(config.lazyIncludes)
 	if err != nil {
 		return nil, err
 	}
+	config.fileLayers, config.lazyPending = layers, pending
+	return config, nil
+}
+
+// readFiles reads the config file, its included files and the overlay
+// files. If lazy is true, the included files are not loaded but counted.
+func (c *Config) readFiles(lazy bool) ([]*layer, int32, error) {
+	cfgBytes, err := c.readFile(c.configFile)
+	if err != nil {
+		return nil, 0, err
+	}
+	cfgData, err := c.parseSigned(cfgBytes, c.configFile)
+	if err != nil {
+		return nil, 0, err
+	}
+	layers := []*layer{{source: SourceInfo{LayerFile, c.configFile}, data: cfgData, doc: cfgBytes}}
+	var pending int32
+	depth := c.includeDepth
+	if depth <= 0 {
+		depth = 1
+	}
+	if err := c.readIncludes(cfgData, c.configFile, depth, lazy, &layers, &pending); err != nil {
+		return nil, 0, err
+	}
+
+	for _, file := range c.overlays {
+		l, err := c.readOverlay(file)
+		if err != nil {
+			return nil, 0, err
+		}
+		if l != nil {
+			layers = append(layers, l)
+		}
+	}
+	return layers, pending, nil
+}
 
+// readIncludes reads the files included by the file of data, and the files
+// included by them up to depth levels, their layers are appended in order.
+func (c *Config) readIncludes(data map[interface{}]interface{}, file string, depth int, lazy bool, layers *[]*layer, pending *int32) error {
 	// include sub config
-	incItems := make([]string, 0, 5)
-	if v, ok := config.cfgData["include"]; ok {
+	incItems := make([]include, 0, 5)
+	if v, ok := data["include"]; ok {
 		switch vv := v.(type) {
-		case string:
-			incItems = append(incItems, vv)
 		case []interface{}:
 			for _, vvv := range vv {
-				if item, ok := vvv.(string); !ok {
-					return nil, errors.New("unrecoginzed config value of `include`")
+				if item, ok := parseInclude(vvv); !ok {
+					return errors.New("unrecoginzed config value of `include`")
 				} else {
 					incItems = append(incItems, item)
 				}
 			}
 		default:
-			return nil, errors.New("unrecoginzed config value of `include`")
+			if item, ok := parseInclude(vv); !ok {
+				return errors.New("unrecoginzed config value of `include`")
+			} else {
+				incItems = append(incItems, item)
+			}
 		}
 	}
 
-	configDir := filepath.Dir(configFile)
+	configDir := filepath.Dir(file)
 	for _, incItem := range incItems {
-		incFile := filepath.Join(configDir, incItem+".yaml")
-		incCfgBytes, err := ioutil.ReadFile(incFile)
-		if err != nil {
-			return nil, err
+		l := &layer{
+			source:     SourceInfo{LayerInclude, filepath.Join(configDir, incItem.file+".yaml")},
+			vars:       incItem.vars,
+			optional:   incItem.optional,
+			arrayMerge: c.arrayMerge,
+			directives: true,
 		}
-		incCfgData := make(map[interface{}]interface{})
-		err = yaml.Unmarshal(incCfgBytes, &incCfgData)
-		if err != nil {
-			return nil, err
+		// 带变量的include文件可能设置任意的key，不延迟加载
+		if lazy && incItem.vars == nil {
+			// 延迟到读取其命名空间下的key时加载
+			l.pending, l.namespace = true, filepath.Base(incItem.file)
+			*pending++
+			*layers = append(*layers, l)
+			continue
+		}
+		if err := c.loadInclude(l); err != nil {
+			return err
+		}
+		*layers = append(*layers, l)
+		if depth > 1 {
+			if err := c.readIncludes(l.data, l.source.Name, depth-1, lazy, layers, pending); err != nil {
+				return err
+			}
 		}
-		configDeepMerge(config.cfgData, incCfgData)
 	}
+	return nil
+}
 
-	return config, nil
+// loadInclude loads the data of an included file into its layer.
+func (c *Config) loadInclude(l *layer) error {
+	incCfgBytes, err := c.readFile(l.source.Name)
+	if err != nil {
+		if l.optional && os.IsNotExist(err) {
+			c.warn(Warning{
+				Kind:    WarningMissingInclude,
+				Source:  l.source.Name,
+				Message: "optional included file `" + l.source.Name + "` does not exist",
+			})
+			l.data, l.doc, l.policies = make(map[interface{}]interface{}), nil, nil
+			return nil
+		}
+		return err
+	}
+	var incCfgData map[interface{}]interface{}
+	if l.vars != nil {
+		// 模板文件只能使用单独的签名文件
+		if signed, err := c.verifyFile(incCfgBytes, l.source.Name); err != nil {
+			return err
+		} else if c.signatureKey != nil && !signed {
+			return fmt.Errorf("%w: config `%s` is not signed", ErrInvalidSignature, l.source.Name)
+		}
+		if incCfgBytes, err = renderInclude(l.source.Name, incCfgBytes, l.vars); err != nil {
+			return err
+		}
+		incCfgData, err = c.parseYAML(incCfgBytes, l.source.Name)
+	} else {
+		incCfgData, err = c.parseSigned(incCfgBytes, l.source.Name)
+	}
+	if err != nil {
+		return err
+	}
+	policies, err := takePolicies(incCfgData)
+	if err != nil {
+		return err
+	}
+	if l.namespace != "" {
+		for k := range incCfgData {
+			if key := fmt.Sprint(k); key != l.namespace {
+				return errors.New("included file `" + l.source.Name + "` sets key `" + key + "` outside of its namespace `" + l.namespace + "`")
+			}
+		}
+		for key := range policies {
+			if keyArr, err := c.parseKey(key); err != nil || keyArr[0] != l.namespace {
+				return errors.New("included file `" + l.source.Name + "` sets key `" + key + "` outside of its namespace `" + l.namespace + "`")
+			}
+		}
+	}
+	l.data, l.doc, l.policies = incCfgData, incCfgBytes, policies
+	return nil
+}
+
+// include is an entry of the `include` section, either a file name or a
+// map of the file name and the variables its content is templated with.
+// Optional files are skipped with a warning if they don't exist:
+//
+//	include:
+//	  - db
+//	  - {file: worker, vars: {queue: payments, concurrency: 8}}
+//	  - {file: local, optional: true}
+type include struct {
+	file     string
+	vars     map[string]interface{}
+	optional bool
+}
+
+func parseInclude(v interface{}) (include, bool) {
+	switch vv := v.(type) {
+	case string:
+		return include{file: vv}, true
+	case map[interface{}]interface{}:
+		file, ok := vv["file"].(string)
+		if !ok {
+			return include{}, false
+		}
+		var vars map[string]interface{}
+		if v, ok := vv["vars"]; ok {
+			if vars, ok = stringKeyed(v).(map[string]interface{}); !ok {
+				return include{}, false
+			}
+		}
+		optional := false
+		if v, ok := vv["optional"]; ok {
+			if optional, ok = v.(bool); !ok {
+				return include{}, false
+			}
+		}
+		for k := range vv {
+			if k != "file" && k != "vars" && k != "optional" {
+				return include{}, false
+			}
+		}
+		return include{file: file, vars: vars, optional: optional}, true
+	default:
+		return include{}, false
+	}
+}
+
+// renderInclude executes the content of an included file as a text/template
+// with its variables, such as `queue: {{ .queue }}`.
+func renderInclude(name string, content []byte, vars map[string]interface{}) ([]byte, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("parse included file `%s` failed: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return nil, fmt.Errorf("render included file `%s` failed: %w", name, err)
+	}
+	return buf.Bytes(), nil
 }
 
 // FromString create a config by specified yaml string.
-func FromString(yamlStr string) (*Config, error) {
+func FromString(yamlStr string, opts ...Option) (*Config, error) {
 	cfgBytes := []byte(yamlStr)
 
-	return newConfigWithBytes(cfgBytes)
+	config := newConfig(opts...)
+	if err := config.loadBytes(cfgBytes, SourceInfo{LayerFile, ""}); err != nil {
+		return nil, err
+	}
+	if err := config.finish(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
 }
 
-func newConfigWithBytes(cfgBytes []byte) (*Config, error) {
-	// slice the BOM
-	if len(cfgBytes) >= 3 && cfgBytes[0] == 239 && cfgBytes[1] == 187 && cfgBytes[2] == 191 {
-		cfgBytes = cfgBytes[3:]
+// FromMap create a config by specified map, nested maps and slices of any
+// type are converted to the types of a config loaded from yaml, as well as
+// values of integer, float and string types, durations and values
+// implementing encoding.TextMarshaler. Structs are converted to maps keyed
+// like Unmarshal. Other types, such as functions, and unsigned integers
+// overflowing int are rejected.
+func FromMap(m map[string]interface{}, opts ...Option) (*Config, error) {
+	cfgData, _ := normalizeValue(m).(map[interface{}]interface{})
+	if cfgData == nil {
+		cfgData = make(map[interface{}]interface{})
+	}
+	if err := checkValue(cfgData, "", "."); err != nil {
+		return nil, err
 	}
+	return fromData(cfgData, opts...)
+}
 
-	cfgData := make(map[interface{}]interface{})
-	err := yaml.Unmarshal(cfgBytes, &cfgData)
-	if err != nil {
+// fromData create a config by a config tree.
+func fromData(cfgData map[interface{}]interface{}, opts ...Option) (*Config, error) {
+	config := newConfig(opts...)
+	if err := config.loadDefaults(); err != nil {
+		return nil, err
+	}
+	config.fileLayers = []*layer{{source: SourceInfo{LayerFile, ""}, data: cfgData}}
+	if err := config.finish(); err != nil {
 		return nil, err
 	}
 
-	return &Config{".", cfgData}, nil
+	return config, nil
 }
 
-// merge two config maps
-func configDeepMerge(dst map[interface{}]interface{}, src map[interface{}]interface{}) {
-	for k, v := range src {
-		// 目标不存在该key，则直接复制
-		if _, ok := dst[k]; !ok {
-			dst[k] = v
-			continue
+func newConfig(opts ...Option) *Config {
+	config := &Config{Delimiter: ".", arrayMerge: ArrayReplace, coercion: DefaultCoercion}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return config
+}
+
+// loadBytes loads the defaults documents and the config document.
+func (c *Config) loadBytes(cfgBytes []byte, source SourceInfo) error {
+	if err := c.loadDefaults(); err != nil {
+		return err
+	}
+
+	cfgData, err := c.parseYAML(cfgBytes, source.Name)
+	if err != nil {
+		return err
+	}
+	c.fileLayers = []*layer{{source: source, data: cfgData, doc: cfgBytes}}
+
+	return nil
+}
+
+// loadDefaults loads the defaults documents.
+func (c *Config) loadDefaults() error {
+	for _, doc := range c.defaultsDocs {
+		docBytes, err := doc.load(c)
+		if err != nil {
+			return err
+		}
+		var docData map[interface{}]interface{}
+		if doc.name != "" {
+			docData, err = c.parseSigned(docBytes, doc.name)
+		} else {
+			docData, err = c.parseYAML(docBytes, doc.name)
+		}
+		if err != nil {
+			return err
 		}
+		c.defaultLayers = append(c.defaultLayers, &layer{
+			source: SourceInfo{LayerDefaults, doc.name},
+			data:   docData,
+			doc:    docBytes,
+		})
+	}
+	return nil
+}
 
-		// 目标存在该key，但不是map，则直接覆盖
-		dstV, ok := dst[k].(map[interface{}]interface{})
-		if !ok {
-			dst[k] = v
-			continue
+// finish builds the effective config after all the files are loaded.
+func (c *Config) finish() error {
+	if c.profileEnv != "" {
+		if profile := os.Getenv(c.profileEnv); profile != "" {
+			c.profile = profile
+		}
+	}
+	if c.profile != "" && !profileDefined(c.fileLayers, c.profile) {
+		return errors.New("profile `" + c.profile + "` is not defined")
+	}
+
+	c.rebuild()
+	if c.evalErr != nil {
+		return c.evalErr
+	}
+	if c.resolveErr != nil {
+		return c.resolveErr
+	}
+	if c.schema != nil {
+		if err := c.Validate(c.schema); err != nil {
+			return err
 		}
+	}
+	c.recordRevision("load")
+	if c.statInterval > 0 && c.configFile != "" {
+		c.mtimes = c.statFiles()
+		c.statChecked = time.Now().UnixNano()
+	}
+	return nil
+}
 
-		// 源不是map，直接覆盖
-		srcV, ok := v.(map[interface{}]interface{})
-		if !ok {
-			dst[k] = v
-			continue
+// profileDefined returns whether a profile is declared in the config files.
+func profileDefined(layers []*layer, profile string) bool {
+	for _, l := range layers {
+		if _, ok := lookupPath(l.data, []string{profilesKey, profile}); ok {
+			return true
 		}
+	}
+	return false
+}
 
-		// 目标存在该key，而且是map，且源是map，则递归合并
-		configDeepMerge(dstV, srcV)
+// trimBOM slices the BOM
+func trimBOM(cfgBytes []byte) []byte {
+	if len(cfgBytes) >= 3 && cfgBytes[0] == 239 && cfgBytes[1] == 187 && cfgBytes[2] == 191 {
+		return cfgBytes[3:]
 	}
+	return cfgBytes
 }
 
 // GetString returns the string value for a given key.
@@ -123,10 +632,14 @@ func (c *Config) GetString(key string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	return c.asString(key, v)
+}
 
-	str, ok := v.(string)
+// asString converts the value of a given key to string.
+func (c *Config) asString(key string, v interface{}) (string, error) {
+	str, ok := c.toString(v)
 	if !ok {
-		return "", errors.New("value of `" + key + "` is not string")
+		return "", typeError(key, "string", v)
 	}
 
 	return str, nil
@@ -135,6 +648,9 @@ func (c *Config) GetString(key string) (string, error) {
 // GetDefaultString returns the string value for a given key.
 // if error occur, return defaultVal
 func (c *Config) GetDefaultString(key string, defaultVal string) string {
+	if c.missing(key) {
+		return defaultVal
+	}
 	if v, err := c.GetString(key); err != nil {
 		return defaultVal
 	} else {
@@ -149,17 +665,17 @@ func (c *Config) GetStringArray(key string) ([]string, error) {
 		return nil, err
 	}
 
-	t, ok := v.([]interface{})
+	t, ok := c.toList(v)
 	if !ok {
-		return nil, errors.New("value of `" + key + "` is not a string list")
+		return nil, typeError(key, "a string list", v)
 	}
 
 	vArr := make([]string, 0, len(t))
 	for _, vv := range t {
-		if vvv, ok := vv.(string); ok {
+		if vvv, ok := c.toString(vv); ok {
 			vArr = append(vArr, vvv)
 		} else {
-			return nil, errors.New("some value in key `" + key + "` is not string")
+			return nil, elemTypeError(key, "string", vv)
 		}
 	}
 	return vArr, nil
@@ -175,30 +691,65 @@ func (c *Config) GetDefaultStringArray(key string, defaultVal []string) []string
 	}
 }
 
+// GetStringOrSlice returns the []string value for a given key, a single
+// string is returned as a list of it, so that both `hosts: a.example.com`
+// and `hosts: [a.example.com, b.example.com]` can be read.
+func (c *Config) GetStringOrSlice(key string) ([]string, error) {
+	v, err := c.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if str, ok := v.(string); ok {
+		return []string{str}, nil
+	}
+	t, ok := c.toList(v)
+	if !ok {
+		return nil, typeError(key, "a string or a string list", v)
+	}
+	vArr := make([]string, 0, len(t))
+	for _, vv := range t {
+		if vvv, ok := c.toString(vv); ok {
+			vArr = append(vArr, vvv)
+		} else {
+			return nil, elemTypeError(key, "string", vv)
+		}
+	}
+	return vArr, nil
+}
+
 // GetInt returns the int value for a given key.
 func (c *Config) GetInt(key string) (int, error) {
 	v, err := c.Get(key)
 	if err != nil {
 		return 0, err
 	}
+	return c.asInt(key, v)
+}
 
+// asInt converts the value of a given key to int.
+func (c *Config) asInt(key string, v interface{}) (int, error) {
 	switch vv := v.(type) {
 	case int:
 		return vv, nil
 	case string:
-		if vvv, err := strconv.Atoi(vv); err == nil {
+		if vvv, ok := c.stringToInt(vv); ok {
+			c.warnCoerced(key, "int")
 			return vvv, nil
 		} else {
-			return 0, errors.New("value of `" + key + "` is not int")
+			return 0, typeError(key, "int", v)
 		}
 	default:
-		return 0, errors.New("value of `" + key + "` is not int")
+		return 0, typeError(key, "int", v)
 	}
 }
 
 // GetDefaultInt returns the int value for a given key.
 // if error occur, return defaultVal
 func (c *Config) GetDefaultInt(key string, defaultVal int) int {
+	if c.missing(key) {
+		return defaultVal
+	}
 	if v, err := c.GetInt(key); err != nil {
 		return defaultVal
 	} else {
@@ -213,9 +764,9 @@ func (c *Config) GetIntArray(key string) ([]int, error) {
 		return nil, err
 	}
 
-	t, ok := v.([]interface{})
+	t, ok := c.toList(v)
 	if !ok {
-		return nil, errors.New("value of `" + key + "` is not a int list")
+		return nil, typeError(key, "a int list", v)
 	}
 
 	vArr := make([]int, 0, len(t))
@@ -224,13 +775,14 @@ func (c *Config) GetIntArray(key string) ([]int, error) {
 		case int:
 			vArr = append(vArr, vvv)
 		case string:
-			if vvvv, err := strconv.Atoi(vvv); err == nil {
+			if vvvv, ok := c.stringToInt(vvv); ok {
+				c.warnCoerced(key, "int")
 				vArr = append(vArr, vvvv)
 			} else {
-				return nil, errors.New("some value in key `" + key + "` is not int")
+				return nil, elemTypeError(key, "int", vv)
 			}
 		default:
-			return nil, errors.New("some value in key `" + key + "` is not int")
+			return nil, elemTypeError(key, "int", vv)
 		}
 	}
 	return vArr, nil
@@ -252,24 +804,32 @@ func (c *Config) GetBool(key string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	return c.asBool(key, v)
+}
 
+// asBool converts the value of a given key to bool.
+func (c *Config) asBool(key string, v interface{}) (bool, error) {
 	switch vv := v.(type) {
 	case bool:
 		return vv, nil
 	case string:
-		if vvv, err := strconv.ParseBool(vv); err == nil {
+		if vvv, ok := c.stringToBool(vv); ok {
+			c.warnCoerced(key, "boolean")
 			return vvv, nil
 		} else {
-			return false, errors.New("value of `" + key + "` is not boolean")
+			return false, typeError(key, "boolean", v)
 		}
 	default:
-		return false, errors.New("value of `" + key + "` is not boolean")
+		return false, typeError(key, "boolean", v)
 	}
 }
 
 // GetDefaultBool returns the boolean value for a given key.
 // if error occur, return defaultVal
 func (c *Config) GetDefaultBool(key string, defaultVal bool) bool {
+	if c.missing(key) {
+		return defaultVal
+	}
 	if v, err := c.GetBool(key); err != nil {
 		return defaultVal
 	} else {
@@ -284,9 +844,9 @@ func (c *Config) GetBoolArray(key string) ([]bool, error) {
 		return nil, err
 	}
 
-	t, ok := v.([]interface{})
+	t, ok := c.toList(v)
 	if !ok {
-		return nil, errors.New("value of `" + key + "` is not a boolean list")
+		return nil, typeError(key, "a boolean list", v)
 	}
 
 	vArr := make([]bool, 0, len(t))
@@ -295,13 +855,14 @@ func (c *Config) GetBoolArray(key string) ([]bool, error) {
 		case bool:
 			vArr = append(vArr, vvv)
 		case string:
-			if vvvv, err := strconv.ParseBool(vvv); err == nil {
+			if vvvv, ok := c.stringToBool(vvv); ok {
+				c.warnCoerced(key, "boolean")
 				vArr = append(vArr, vvvv)
 			} else {
-				return nil, errors.New("some value in key `" + key + "` is not boolean")
+				return nil, elemTypeError(key, "boolean", vv)
 			}
 		default:
-			return nil, errors.New("some value in key `" + key + "` is not boolean")
+			return nil, elemTypeError(key, "boolean", vv)
 		}
 	}
 	return vArr, nil
@@ -323,26 +884,34 @@ func (c *Config) GetFloat(key string) (float64, error) {
 	if err != nil {
 		return 0, err
 	}
+	return c.asFloat(key, v)
+}
 
+// asFloat converts the value of a given key to float64.
+func (c *Config) asFloat(key string, v interface{}) (float64, error) {
 	switch vv := v.(type) {
 	case int:
 		return float64(vv), nil
 	case float64:
 		return vv, nil
 	case string:
-		if vvv, err := strconv.ParseFloat(vv, 64); err == nil {
+		if vvv, ok := c.stringToFloat(vv); ok {
+			c.warnCoerced(key, "float64")
 			return vvv, nil
 		} else {
-			return 0, errors.New("value of `" + key + "` is not float64")
+			return 0, typeError(key, "float64", v)
 		}
 	default:
-		return 0, errors.New("value of `" + key + "` is not float64")
+		return 0, typeError(key, "float64", v)
 	}
 }
 
 // GetDefaultFloat returns the float64 value for a given key.
 // if error occur, return defaultVal
 func (c *Config) GetDefaultFloat(key string, defaultVal float64) float64 {
+	if c.missing(key) {
+		return defaultVal
+	}
 	if v, err := c.GetFloat(key); err != nil {
 		return defaultVal
 	} else {
@@ -357,9 +926,9 @@ func (c *Config) GetFloatArray(key string) ([]float64, error) {
 		return nil, err
 	}
 
-	t, ok := v.([]interface{})
+	t, ok := c.toList(v)
 	if !ok {
-		return nil, errors.New("value of `" + key + "` is not a boolean list")
+		return nil, typeError(key, "a float64 list", v)
 	}
 
 	vArr := make([]float64, 0, len(t))
@@ -370,13 +939,14 @@ func (c *Config) GetFloatArray(key string) ([]float64, error) {
 		case float64:
 			vArr = append(vArr, vvv)
 		case string:
-			if vvvv, err := strconv.ParseFloat(vvv, 64); err == nil {
+			if vvvv, ok := c.stringToFloat(vvv); ok {
+				c.warnCoerced(key, "float64")
 				vArr = append(vArr, vvvv)
 			} else {
-				return nil, errors.New("some value in key `" + key + "` is not float64")
+				return nil, elemTypeError(key, "float64", vv)
 			}
 		default:
-			return nil, errors.New("some value in key `" + key + "` is not float64")
+			return nil, elemTypeError(key, "float64", vv)
 		}
 	}
 	return vArr, nil
@@ -392,6 +962,178 @@ func (c *Config) GetDefaultFloatArray(key string, defaultVal []float64) []float6
 	}
 }
 
+// GetDuration returns the time.Duration value for a given key.
+// Strings are parsed by time.ParseDuration, such as "1h30m",
+// integers are taken as nanoseconds.
+func (c *Config) GetDuration(key string) (time.Duration, error) {
+	v, err := c.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	return c.asDuration(key, v)
+}
+
+// asDuration converts the value of a given key to time.Duration.
+func (c *Config) asDuration(key string, v interface{}) (time.Duration, error) {
+	switch vv := v.(type) {
+	case int:
+		return time.Duration(vv), nil
+	case string:
+		if vvv, err := time.ParseDuration(vv); err == nil {
+			return vvv, nil
+		} else {
+			return 0, typeError(key, "duration", v)
+		}
+	default:
+		return 0, typeError(key, "duration", v)
+	}
+}
+
+// GetDefaultDuration returns the time.Duration value for a given key.
+// if error occur, return defaultVal
+func (c *Config) GetDefaultDuration(key string, defaultVal time.Duration) time.Duration {
+	if c.missing(key) {
+		return defaultVal
+	}
+	if v, err := c.GetDuration(key); err != nil {
+		return defaultVal
+	} else {
+		return v
+	}
+}
+
+// the prefix of base64 encoded values read by GetBytes
+const base64Prefix = "base64:"
+
+// GetBytes returns the []byte value for a given key. Values of the yaml
+// `!!binary` tag are decoded by the yaml decoder, strings with the prefix
+// `base64:` are base64 decoded, other strings are returned as is.
+func (c *Config) GetBytes(key string) ([]byte, error) {
+	v, err := c.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	str, ok := v.(string)
+	if !ok {
+		return nil, typeError(key, "bytes", v)
+	}
+	if !strings.HasPrefix(str, base64Prefix) {
+		return []byte(str), nil
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(str[len(base64Prefix):]))
+	if err != nil {
+		return nil, typeError(key, "base64", v)
+	}
+	return b, nil
+}
+
+// GetDefaultBytes returns the []byte value for a given key.
+// if error occur, return defaultVal
+func (c *Config) GetDefaultBytes(key string, defaultVal []byte) []byte {
+	if c.missing(key) {
+		return defaultVal
+	}
+	if v, err := c.GetBytes(key); err != nil {
+		return defaultVal
+	} else {
+		return v
+	}
+}
+
+// GetFileContent returns the content of the file whose path is the value of
+// a given key, such as `tls.cert_file`. A relative path is relative to the
+// directory of the config file setting the key, or to the working directory
+// if the key is not set by a file.
+func (c *Config) GetFileContent(key string) ([]byte, error) {
+	file, err := c.GetString(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !filepath.IsAbs(file) {
+		switch source := c.Source(key); source.Layer {
+		case LayerFile, LayerInclude, LayerDefaults:
+			if source.Name != "" {
+				file = filepath.Join(filepath.Dir(source.Name), file)
+			}
+		}
+	}
+	b, err := c.readFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read file `%s` of `%s` failed: %w", file, key, err)
+	}
+	return b, nil
+}
+
+// GetPort returns the port number for a given key, it should be between 1
+// and 65535.
+func (c *Config) GetPort(key string) (int, error) {
+	port, err := c.GetInt(key)
+	if err != nil {
+		return 0, err
+	}
+	if port < 1 || port > 65535 {
+		return 0, fmt.Errorf("value of `%s` should be between 1 and 65535, got %d", key, port)
+	}
+	return port, nil
+}
+
+// GetHostPort returns the host and the port for a given key of the form
+// `host:port`, such as `localhost:8080` or `[::1]:8080`, the port should be
+// between 1 and 65535. The host may be empty, such as in `:8080`.
+func (c *Config) GetHostPort(key string) (string, int, error) {
+	str, err := c.GetString(key)
+	if err != nil {
+		return "", 0, err
+	}
+
+	host, portStr, err := net.SplitHostPort(str)
+	if err != nil {
+		return "", 0, typeError(key, "host:port", str)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return "", 0, typeError(key, "host:port", str)
+	}
+	if port < 1 || port > 65535 {
+		return "", 0, fmt.Errorf("port of `%s` should be between 1 and 65535, got %d", key, port)
+	}
+	return host, port, nil
+}
+
+// GetLocation returns the time zone for a given key, such as `Asia/Shanghai`,
+// `UTC` or `Local`, it is loaded by time.LoadLocation.
+func (c *Config) GetLocation(key string) (*time.Location, error) {
+	v, err := c.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	str, ok := v.(string)
+	if !ok {
+		return nil, typeError(key, "location", v)
+	}
+	loc, err := time.LoadLocation(str)
+	if err != nil {
+		return nil, typeError(key, "location", v)
+	}
+	return loc, nil
+}
+
+// GetDefaultLocation returns the time zone for a given key.
+// if error occur, return defaultVal
+func (c *Config) GetDefaultLocation(key string, defaultVal *time.Location) *time.Location {
+	if c.missing(key) {
+		return defaultVal
+	}
+	if v, err := c.GetLocation(key); err != nil {
+		return defaultVal
+	} else {
+		return v
+	}
+}
+
 // GetMap returns the map[string]interface{} value for a given key.
 func (c *Config) GetMap(key string) (map[string]interface{}, error) {
 	v, err := c.Get(key)
@@ -401,7 +1143,7 @@ func (c *Config) GetMap(key string) (map[string]interface{}, error) {
 
 	t, ok := v.(map[interface{}]interface{})
 	if !ok {
-		return nil, errors.New("value of `" + key + "` is not a map")
+		return nil, typeError(key, "a map", v)
 	}
 
 	vMap := make(map[string]interface{})
@@ -421,6 +1163,79 @@ func (c *Config) GetDefaultMap(key string, defaultVal map[string]interface{}) ma
 	}
 }
 
+// GetMapArray returns the []map[string]interface{} value for a given key.
+func (c *Config) GetMapArray(key string) ([]map[string]interface{}, error) {
+	v, err := c.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := v.([]interface{})
+	if !ok {
+		return nil, typeError(key, "a map list", v)
+	}
+
+	vArr := make([]map[string]interface{}, 0, len(t))
+	for _, vv := range t {
+		vvv, ok := vv.(map[interface{}]interface{})
+		if !ok {
+			return nil, elemTypeError(key, "a map", vv)
+		}
+		vMap := make(map[string]interface{}, len(vvv))
+		for kk, vvvv := range vvv {
+			vMap[fmt.Sprint(kk)] = vvvv
+		}
+		vArr = append(vArr, vMap)
+	}
+	return vArr, nil
+}
+
+// GetDefaultMapArray returns the []map[string]interface{} value for a given key.
+// if error occur, return defaultVal
+func (c *Config) GetDefaultMapArray(key string, defaultVal []map[string]interface{}) []map[string]interface{} {
+	if v, err := c.GetMapArray(key); err != nil {
+		return defaultVal
+	} else {
+		return v
+	}
+}
+
+// GetStringMapStringSlice returns the map[string][]string value for a given key.
+// A scalar value is converted to a slice with one element.
+func (c *Config) GetStringMapStringSlice(key string) (map[string][]string, error) {
+	v, err := c.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil, typeError(key, "a map", v)
+	}
+
+	vMap := make(map[string][]string, len(t))
+	for kk, vv := range t {
+		switch vvv := vv.(type) {
+		case []interface{}:
+			vArr := make([]string, 0, len(vvv))
+			for _, vvvv := range vvv {
+				switch vvvv.(type) {
+				case string, int, float64, bool:
+					vArr = append(vArr, fmt.Sprint(vvvv))
+				default:
+					return nil, elemTypeError(key, "a string list", vv)
+				}
+			}
+			vMap[fmt.Sprint(kk)] = vArr
+		case string, int, float64, bool:
+			vMap[fmt.Sprint(kk)] = []string{fmt.Sprint(vvv)}
+		default:
+			return nil, elemTypeError(key, "a string list", vv)
+		}
+	}
+	return vMap, nil
+}
+
 // GetSubKeys returns the subkey array of a given key.
 // Support multi-level key which concat with '.'.
 func (c *Config) GetSubKeys(key string) ([]string, error) {
@@ -461,53 +1276,268 @@ func (c *Config) Len(key string) (int, error) {
 	}
 }
 
-// Get returns the interface{} value for a given key.
+// AllSettings returns the whole config as map[string]interface{}, maps at
+// every level have string keys.
+func (c *Config) AllSettings() map[string]interface{} {
+	return stringKeyed(c.data()).(map[string]interface{})
+}
+
+// AllKeys returns the keys of all leaf values in sorted order, elements of
+// slices are addressed by index, such as `servers[0].host`.
+func (c *Config) AllKeys() []string {
+	keys := make([]string, 0)
+	c.Walk(func(key string, v interface{}) error {
+		keys = append(keys, key)
+		return nil
+	})
+	return keys
+}
+
+// Walk calls fn for every leaf value with its key, in the order of AllKeys.
+// Empty maps and slices are leaves too. Walking stops at the first error
+// returned by fn, which is returned by Walk.
+func (c *Config) Walk(fn func(key string, value interface{}) error) error {
+	return walkLeaves(c.data(), "", c.Delimiter, fn)
+}
+
+// Has returns whether a given key exists, even if its value is empty.
 // Support multi-level key which concat with '.'.
-func (c *Config) Get(key string) (interface{}, error) {
+func (c *Config) Has(key string) bool {
+	if err := c.loadIncludes(key); err != nil {
+		c.log().Printf("%s", err)
+	}
+	snap := c.current()
+	if _, ok := snap.lookupIndex(key, c.Delimiter); ok {
+		return true
+	} else if canonicalKey(key, c.Delimiter) {
+		return false
+	}
+
+	keyArr, err := c.parseKey(key)
+	if err != nil {
+		return false
+	}
+	_, ok := lookupKeys(snap.data, keyArr)
+	return ok
+}
+
+// parseKey splits a key into map keys (string) and slice indexes (int).
+// Negative indexes count from the end of the slice, such as `servers[-1]`.
+// A map key containing the delimiter can be quoted, such as `hosts."db.internal".port`.
+func (c *Config) parseKey(key string) ([]interface{}, error) {
 	if len(key) == 0 {
-		return nil, errors.New("key should not be empty")
+		return nil, ErrEmptyKey
 	}
 	if key[0] == '[' {
-		return nil, errors.New("wrong key format")
+		return nil, ErrKeyFormat
 	}
 
-	// 每级使用.分隔
-	tKeyArr := strings.Split(key, c.Delimiter)
-	keyArr := make([]interface{}, 0, len(tKeyArr))
+	keyArr := make([]interface{}, 0, 4)
+	for {
+		var v string
+		if key != "" && key[0] == '"' {
+			// 引号内为原样的key
+			end := strings.IndexByte(key[1:], '"')
+			if end == -1 {
+				return nil, ErrKeyFormat
+			}
+			keyArr = append(keyArr, key[1:end+1])
+			key = key[end+2:]
 
-	// 将[数字]形式的进行分隔
-	for _, v := range tKeyArr {
-		indexs := make([]uint16, 0)
-		for {
-			if !strings.HasSuffix(v, "]") {
-				keyArr = append(keyArr, v)
-				goto next
+			pos := strings.Index(key, c.Delimiter)
+			if pos == -1 {
+				v, key = key, ""
+			} else {
+				v, key = key[:pos], key[pos+len(c.Delimiter):]
 			}
-			startPos := strings.LastIndex(v, "[")
-			if startPos == -1 {
-				keyArr = append(keyArr, v)
-				goto next
+			indexs, rest := parseIndexs(v)
+			if rest != "" {
+				return nil, ErrKeyFormat
 			}
-			indexStr := v[startPos+1 : len(v)-1]
-			index, err := strconv.ParseUint(indexStr, 10, 16)
-			if err != nil { // 非uint16
-				keyArr = append(keyArr, v)
-				goto next
+			keyArr = append(keyArr, indexs...)
+		} else {
+			// 每级使用.分隔
+			pos := strings.Index(key, c.Delimiter)
+			if pos == -1 {
+				v, key = key, ""
+			} else {
+				v, key = key[:pos], key[pos+len(c.Delimiter):]
 			}
+			indexs, name := parseIndexs(v)
+			keyArr = append(keyArr, name)
+			keyArr = append(keyArr, indexs...)
+		}
+
+		if key == "" {
+			break
+		}
+	}
+
+	return keyArr, nil
+}
+
+// parseIndexs splits the [数字] suffixes from a key segment.
+func parseIndexs(v string) ([]interface{}, string) {
+	indexs := make([]interface{}, 0)
+	for strings.HasSuffix(v, "]") {
+		startPos := strings.LastIndex(v, "[")
+		if startPos == -1 {
+			break
+		}
+		indexStr := v[startPos+1 : len(v)-1]
+		index, err := strconv.Atoi(indexStr)
+		if err != nil { // 非数字
+			break
+		}
+
+		// 索引是从后往前解析的
+		indexs = append([]interface{}{index}, indexs...)
+		v = v[:startPos]
+	}
+	return indexs, v
+}
 
-			indexs = append(indexs, uint16(index))
-			v = v[:startPos]
+// GetPath returns the interface{} value for a path of map keys, the keys are
+// used as is, so they can contain the delimiter. Numeric keys index slices.
+func (c *Config) GetPath(path []string) (interface{}, error) {
+	namespace := ""
+	if len(path) > 0 {
+		namespace = path[0]
+	}
+	if err := c.loadNamespace(namespace); err != nil {
+		return nil, err
+	}
+
+	var node interface{} = c.current().data
+	var pKey string
+	for _, key := range path {
+		cKey := key
+		if pKey != "" {
+			cKey = pKey + c.Delimiter + key
+		}
+
+		switch t := node.(type) {
+		case map[interface{}]interface{}:
+			v, ok := t[key]
+			if !ok || v == nil {
+				return nil, notFoundError(cKey)
+			}
+			node = v
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil {
+				return nil, notFoundError(cKey)
+			}
+			index, ok := sliceIndex(i, len(t))
+			if !ok {
+				return nil, indexError(pKey, i, len(t))
+			}
+			node = t[index]
+		default:
+			return nil, nodeError(pKey, ErrNotAMapOrSlice, node)
 		}
+		pKey = cKey
+	}
+	return node, nil
+}
 
-	next:
-		for _, index := range indexs {
-			keyArr = append(keyArr, index)
+// sliceIndex resolves a possibly negative index of a slice with length n.
+func sliceIndex(index, n int) (int, bool) {
+	if index < 0 {
+		index += n
+	}
+	if index < 0 || index >= n {
+		return 0, false
+	}
+	return index, true
+}
+
+// lookupKeys walks the parsed key from node, without building errors.
+func lookupKeys(node interface{}, keyArr []interface{}) (interface{}, bool) {
+	for _, v := range keyArr {
+		switch key := v.(type) {
+		case string:
+			tMap, ok := node.(map[interface{}]interface{})
+			if !ok {
+				return nil, false
+			}
+			if node, ok = tMap[key]; !ok {
+				return nil, false
+			}
+		case int:
+			tSlice, ok := node.([]interface{})
+			if !ok {
+				return nil, false
+			}
+			index, ok := sliceIndex(key, len(tSlice))
+			if !ok {
+				return nil, false
+			}
+			node = tSlice[index]
 		}
-		continue
+	}
+	return node, true
+}
+
+// OnAccess registers a hook invoked by Get on every read, with the key and
+// whether it was found. It can be used to log or count the keys being read.
+func (c *Config) OnAccess(fn func(key string, found bool)) {
+	c.accessHooks = append(c.accessHooks, fn)
+}
+
+// Get returns the interface{} value for a given key.
+// Support multi-level key which concat with '.'.
+func (c *Config) Get(key string) (interface{}, error) {
+	v, err := c.get(key)
+	c.access(key, err == nil)
+	return v, err
+}
+
+// access calls the access hooks for a key read.
+func (c *Config) access(key string, found bool) {
+	for _, fn := range c.accessHooks {
+		fn(key, found)
+	}
+}
+
+// GetOptional returns the interface{} value for a given key, and whether
+// the key is present. A key set to null is present with a nil value, a
+// missing key is not present and no error is returned.
+func (c *Config) GetOptional(key string) (interface{}, bool, error) {
+	v, err := c.Get(key)
+	switch {
+	case err == nil:
+		return v, true, nil
+	case errors.Is(err, ErrNullValue):
+		return nil, true, nil
+	case errors.Is(err, ErrKeyNotFound):
+		return nil, false, nil
+	default:
+		return nil, false, err
+	}
+}
+
+func (c *Config) get(key string) (interface{}, error) {
+	if err := c.loadIncludes(key); err != nil {
+		return nil, err
+	}
+	snap := c.current()
+	if v, ok := snap.lookupIndex(key, c.Delimiter); ok && v != nil {
+		return v, nil
 	}
 
+	// 索引中没有的key按路径查找，以返回准确的错误
+	keyArr, err := c.parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return c.getKeys(snap.data, keyArr)
+}
+
+// getKeys returns the value at the parsed key of a config tree.
+func (c *Config) getKeys(data map[interface{}]interface{}, keyArr []interface{}) (interface{}, error) {
 	var pKey, cKey string
-	var tNode interface{} = c.cfgData
+	var tNode interface{} = data
 	lasti := len(keyArr) - 1
 
 	for i, v := range keyArr {
@@ -521,47 +1551,64 @@ func (c *Config) Get(key string) (interface{}, error) {
 
 			tMap, ok := tNode.(map[interface{}]interface{})
 			if !ok {
-				return nil, errors.New("key `" + pKey + "` is not a map")
+				return nil, nodeError(pKey, ErrNotAMap, tNode)
+			}
+
+			child, exists := tMap[key]
+			if !exists {
+				return nil, notFoundError(cKey)
 			}
 
 			// 检测类型，必须为map或slice
-			switch t := tMap[key].(type) {
+			switch t := child.(type) {
 			case map[interface{}]interface{}:
 				tNode = interface{}(t)
 			case []interface{}:
 				tNode = interface{}(t)
 			case nil:
-				return nil, errors.New("key `" + cKey + "` is not exists")
+				if i == lasti {
+					// 显式设置为null的key
+					return nil, nullError(cKey)
+				}
+				return nil, notFoundError(cKey)
 			default:
 				if i == lasti {
 					// path最后一个部分
 					return t, nil
 				}
-				return nil, errors.New("key `" + cKey + "` is not a map or slice")
+				return nil, nodeError(cKey, ErrNotAMapOrSlice, t)
 			}
 
-		case uint16:
-			cKey = pKey + fmt.Sprint("[%d]", key)
+		case int:
+			cKey = pKey + fmt.Sprintf("[%d]", key)
 
 			tSlice, ok := tNode.([]interface{})
 			if !ok {
-				return nil, errors.New("key `" + pKey + "` is not a map")
+				return nil, nodeError(pKey, ErrNotASlice, tNode)
+			}
+			index, ok := sliceIndex(key, len(tSlice))
+			if !ok {
+				return nil, indexError(pKey, key, len(tSlice))
 			}
 
 			// 检测类型，必须为slice
-			switch t := tSlice[key].(type) {
+			switch t := tSlice[index].(type) {
 			case map[interface{}]interface{}:
 				tNode = interface{}(t)
 			case []interface{}:
 				tNode = interface{}(t)
 			case nil:
-				return nil, errors.New("key `" + cKey + "` is not exists")
+				if i == lasti {
+					// 显式设置为null的key
+					return nil, nullError(cKey)
+				}
+				return nil, notFoundError(cKey)
 			default:
 				if i == lasti {
 					// path最后一个部分
 					return t, nil
 				}
-				return nil, errors.New("key `" + cKey + "` is not a map or slice")
+				return nil, nodeError(cKey, ErrNotAMapOrSlice, t)
 			}
 		}
 		pKey = cKey
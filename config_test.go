@@ -0,0 +1,148 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestFromFileForEnv(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		env   string
+		want  map[string]interface{}
+	}{
+		{
+			name:  "base",
+			files: map[string]string{"config.yaml": "db: {host: base, port: 3306}\n"},
+			env:   "staging",
+			want:  map[string]interface{}{"db.host": "base", "db.port": 3306},
+		},
+		{
+			name: "env",
+			files: map[string]string{
+				"config.yaml":         "db: {host: base, port: 3306}\n",
+				"config.staging.yaml": "db: {host: staging}\n",
+				"config.prod.yaml":    "db: {host: prod}\n",
+			},
+			env:  "staging",
+			want: map[string]interface{}{"db.host": "staging", "db.port": 3306},
+		},
+		{
+			name: "local",
+			files: map[string]string{
+				"config.yaml":         "db: {host: base, port: 3306}\n",
+				"config.staging.yaml": "db: {host: staging, user: staging}\n",
+				"config.local.yaml":   "db: {host: local}\n",
+			},
+			env:  "staging",
+			want: map[string]interface{}{"db.host": "local", "db.port": 3306, "db.user": "staging"},
+		},
+		{
+			name: "no env",
+			files: map[string]string{
+				"config.yaml":         "db: {host: base}\n",
+				"config.staging.yaml": "db: {host: staging}\n",
+				"config.local.yaml":   "db: {port: 3307}\n",
+			},
+			want: map[string]interface{}{"db.host": "base", "db.port": 3307},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			c, err := FromFileForEnv(filepath.Join(dir, "config.yaml"), tt.env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for key, want := range tt.want {
+				if got, err := c.Get(key); err != nil || got != want {
+					t.Errorf("Get(%q) = %v, %v, want %v", key, got, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFromFileForEnvInvalid(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte("a: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.staging.yaml"), []byte("a: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FromFileForEnv(filepath.Join(dir, "config.yaml"), "staging"); err == nil {
+		t.Error("FromFileForEnv() error = nil, want an error for the invalid env file")
+	}
+	if _, err := FromFileForEnv(filepath.Join(dir, "missing.yaml"), "staging"); err == nil {
+		t.Error("FromFileForEnv() error = nil, want an error for the missing base file")
+	}
+}
+
+func TestGetAllocs(t *testing.T) {
+	c, err := FromString("server: {host: localhost, port: 8080, workers: \"4\"}\nhosts: [a, b]\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{"GetString", func() { c.GetString("server.host") }},
+		{"GetString slice", func() { c.GetString("hosts[1]") }},
+		{"GetInt", func() { c.GetInt("server.port") }},
+		{"GetInt coerced", func() { c.GetInt("server.workers") }},
+		{"Has", func() { c.Has("server.port") }},
+		{"Has missing", func() { c.Has("server.user") }},
+	}
+	for _, tt := range tests {
+		if allocs := testing.AllocsPerRun(100, tt.fn); allocs != 0 {
+			t.Errorf("%s allocates %v times, want 0", tt.name, allocs)
+		}
+	}
+	if warnings := c.Warnings(); len(warnings) != 1 || warnings[0].Kind != WarningCoercedValue {
+		t.Errorf("Warnings() = %v, want a warning of the coerced value", warnings)
+	}
+}
+
+func TestWithProfile(t *testing.T) {
+	const yaml = "port: 80\nhost: localhost\nprofiles:\n  prod: {port: 443}\n  dev: {port: 8080, debug: true}\n"
+	tests := []struct {
+		name    string
+		opts    []Option
+		env     string
+		port    int
+		wantErr bool
+	}{
+		{name: "none", port: 80},
+		{name: "profile", opts: []Option{WithProfile("prod")}, port: 443},
+		{name: "env", opts: []Option{WithProfile("prod"), WithProfileEnv("TEST_PROFILE")}, env: "dev", port: 8080},
+		{name: "env unset", opts: []Option{WithProfile("prod"), WithProfileEnv("TEST_PROFILE")}, port: 443},
+		{name: "undefined", opts: []Option{WithProfile("staging")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_PROFILE", tt.env)
+			c, err := FromString(yaml, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got, err := c.GetInt("port"); err != nil || got != tt.port {
+				t.Errorf("GetInt(port) = %d, %v, want %d", got, err, tt.port)
+			}
+			if got, _ := c.GetString("host"); got != "localhost" {
+				t.Errorf("GetString(host) = %q, want localhost", got)
+			}
+		})
+	}
+}
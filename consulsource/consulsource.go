@@ -0,0 +1,141 @@
+// Package consulsource loads config from the Consul KV store, either a yaml
+// document stored in a single key or a tree of keys under a prefix.
+package consulsource
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-apibox/config"
+	"github.com/hashicorp/consul/api"
+)
+
+// Source is a config.RemoteWatcher backed by Consul.
+type Source struct {
+	kv         *api.KV
+	key        string
+	prefix     bool
+	datacenter string
+	token      string
+	waitTime   time.Duration
+}
+
+// Option configures a Source.
+type Option func(*Source)
+
+// WithDatacenter reads the keys of the specified datacenter instead of the
+// datacenter of the agent.
+func WithDatacenter(dc string) Option {
+	return func(s *Source) {
+		s.datacenter = dc
+	}
+}
+
+// WithToken reads the keys with the specified ACL token instead of the
+// token of the client.
+func WithToken(token string) Option {
+	return func(s *Source) {
+		s.token = token
+	}
+}
+
+// WithWaitTime sets the maximum duration of a blocking query when watching,
+// the default is decided by Consul.
+func WithWaitTime(d time.Duration) Option {
+	return func(s *Source) {
+		s.waitTime = d
+	}
+}
+
+// New create a source reading the yaml document stored in key.
+func New(client *api.Client, key string, opts ...Option) *Source {
+	return newSource(client, key, false, opts)
+}
+
+// NewPrefix create a source reading the keys under prefix, the rest of the
+// keys are split into config levels by slashes, such as `app/db/port` with
+// prefix `app/` for `db.port`.
+func NewPrefix(client *api.Client, prefix string, opts ...Option) *Source {
+	return newSource(client, prefix, true, opts)
+}
+
+func newSource(client *api.Client, key string, prefix bool, opts []Option) *Source {
+	s := &Source{kv: client.KV(), key: key, prefix: prefix}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Fetch implements config.RemoteProvider.
+func (s *Source) Fetch(ctx context.Context) (map[interface{}]interface{}, error) {
+	data, _, err := s.fetch(ctx, 0)
+	return data, err
+}
+
+// Watch implements config.RemoteWatcher with blocking queries.
+func (s *Source) Watch(ctx context.Context, update func(map[interface{}]interface{})) error {
+	_, index, err := s.fetch(ctx, 0)
+	if err != nil {
+		return err
+	}
+	for ctx.Err() == nil {
+		data, newIndex, err := s.fetch(ctx, index)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return err
+		}
+		// 阻塞查询超时返回时index不变
+		if newIndex == index {
+			continue
+		}
+		// index回退时需要重置
+		if newIndex < index {
+			newIndex = 0
+		}
+		index = newIndex
+		update(data)
+	}
+	return ctx.Err()
+}
+
+// fetch reads the config tree, it blocks until the index of the keys is
+// greater than waitIndex if waitIndex is not 0.
+func (s *Source) fetch(ctx context.Context, waitIndex uint64) (map[interface{}]interface{}, uint64, error) {
+	q := &api.QueryOptions{
+		Datacenter: s.datacenter,
+		Token:      s.token,
+		WaitIndex:  waitIndex,
+		WaitTime:   s.waitTime,
+	}
+	q = q.WithContext(ctx)
+
+	if !s.prefix {
+		pair, meta, err := s.kv.Get(s.key, q)
+		if err != nil {
+			return nil, 0, err
+		}
+		if pair == nil {
+			return nil, meta.LastIndex, errors.New("consul key `" + s.key + "` not found")
+		}
+		data, err := config.ParseYAML(pair.Value, s.key)
+		if err != nil {
+			return nil, meta.LastIndex, err
+		}
+		return data, meta.LastIndex, nil
+	}
+
+	pairs, meta, err := s.kv.List(s.key, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	kvs := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		kvs[strings.TrimPrefix(pair.Key, s.key)] = string(pair.Value)
+	}
+	return config.TreeFromKeys(kvs, "/"), meta.LastIndex, nil
+}
@@ -0,0 +1,183 @@
+package consulsource
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// fakeConsul serves the KV endpoints of Consul from memory, blocking
+// queries wait until the index is changed.
+type fakeConsul struct {
+	mu      sync.Mutex
+	values  map[string]string
+	index   uint64
+	changed chan struct{}
+	queries []*http.Request
+}
+
+func newFakeConsul(t *testing.T, values map[string]string) (*fakeConsul, *api.Client) {
+	f := &fakeConsul{values: values, index: 1, changed: make(chan struct{})}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	client, err := api.NewClient(&api.Config{Address: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, client
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	q := r.URL.Query()
+	f.mu.Lock()
+	f.queries = append(f.queries, r)
+	if wait, _ := strconv.ParseUint(q.Get("index"), 10, 64); wait >= f.index {
+		changed := f.changed
+		f.mu.Unlock()
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		case <-time.After(time.Second):
+		}
+		f.mu.Lock()
+	}
+	defer f.mu.Unlock()
+
+	var pairs []*api.KVPair
+	for k, v := range f.values {
+		if k == key || (q.Has("recurse") && strings.HasPrefix(k, key)) {
+			pairs = append(pairs, &api.KVPair{Key: k, Value: []byte(v)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key < pairs[j].Key })
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(f.index, 10))
+	if len(pairs) == 0 && !q.Has("recurse") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(pairs)
+}
+
+func (f *fakeConsul) put(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.values[key] = value
+	f.index++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func TestFetch(t *testing.T) {
+	f, client := newFakeConsul(t, map[string]string{
+		"app/config":  "db: {host: localhost, port: 3306}\n",
+		"app/db/host": "db1",
+		"app/db/port": "3307",
+	})
+	tests := []struct {
+		name    string
+		source  *Source
+		want    map[interface{}]interface{}
+		wantErr bool
+	}{
+		{
+			name:   "document",
+			source: New(client, "app/config"),
+			want: map[interface{}]interface{}{
+				"db": map[interface{}]interface{}{"host": "localhost", "port": 3306},
+			},
+		},
+		{
+			name:   "prefix",
+			source: NewPrefix(client, "app/db/"),
+			want:   map[interface{}]interface{}{"host": "db1", "port": 3307},
+		},
+		{
+			name:    "missing key",
+			source:  New(client, "app/missing"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.source.Fetch(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Fetch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	s := New(client, "app/config", WithDatacenter("dc2"), WithToken("secret"))
+	if _, err := s.Fetch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	f.mu.Lock()
+	r := f.queries[len(f.queries)-1]
+	f.mu.Unlock()
+	if dc := r.URL.Query().Get("dc"); dc != "dc2" {
+		t.Errorf("datacenter of the query = %q, want dc2", dc)
+	}
+	if token := r.Header.Get("X-Consul-Token"); token != "secret" {
+		t.Errorf("token of the query = %q, want secret", token)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	f, client := newFakeConsul(t, map[string]string{"app/port": "8080"})
+	s := NewPrefix(client, "app/", WithWaitTime(time.Second))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates := make(chan map[interface{}]interface{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Watch(ctx, func(data map[interface{}]interface{}) {
+			updates <- data
+		})
+	}()
+	// 等待阻塞查询开始
+	for {
+		f.mu.Lock()
+		n := len(f.queries)
+		f.mu.Unlock()
+		if n >= 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	f.put("app/port", "9090")
+	select {
+	case data := <-updates:
+		if data["port"] != 9090 {
+			t.Errorf("update = %v, want port 9090", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update after the key changed")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Watch() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch() does not return after ctx is done")
+	}
+}
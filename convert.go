@@ -0,0 +1,211 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// normalizeValue converts a go value to the form yaml decodes into, so that
+// values set from code can be read by all getters:
+// maps become map[interface{}]interface{}, slices become []interface{},
+// integers become int, floats become float64, durations and values
+// implementing encoding.TextMarshaler, such as time.Time, become strings.
+// Structs become maps keyed like Unmarshal, with nil fields omitted.
+// Pointers, maps and slices referring to a value being converted become
+// nil. Unsigned integers overflowing int are kept, checkValue rejects them.
+func normalizeValue(v interface{}) interface{} {
+	switch v.(type) {
+	case nil, string, bool, int, float64:
+		return v
+	}
+	return normalize(v, make(map[visit]bool))
+}
+
+// visit is a pointer, map or slice being converted by normalize.
+type visit struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+// normalize converts v like normalizeValue, visiting holds the pointers,
+// maps and slices being converted, so that cyclic values are not converted
+// forever.
+func normalize(v interface{}, visiting map[visit]bool) interface{} {
+	switch vv := v.(type) {
+	case nil, string, bool, int, float64:
+		return v
+	case []byte:
+		return string(vv)
+	case time.Duration:
+		return vv.String()
+	case encoding.TextMarshaler:
+		if text, err := vv.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if !rv.IsNil() {
+			key := visit{rv.Pointer(), 0, rv.Type()}
+			if rv.Kind() == reflect.Slice {
+				key.len = rv.Len()
+			}
+			if visiting[key] {
+				// 引用了正在转换的值
+				return nil
+			}
+			visiting[key] = true
+			defer delete(visiting, key)
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := rv.Uint()
+		if i := int(u); i < 0 || uint64(i) != u {
+			return v
+		}
+		return int(u)
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Map:
+		m := make(map[interface{}]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[normalize(iter.Key().Interface(), visiting)] = normalize(iter.Value().Interface(), visiting)
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		s := make([]interface{}, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			s = append(s, normalize(rv.Index(i).Interface(), visiting))
+		}
+		return s
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return normalize(rv.Elem().Interface(), visiting)
+	case reflect.Struct:
+		m := make(map[interface{}]interface{}, rv.NumField())
+		structValues(rv, m, visiting)
+		return m
+	}
+	return v
+}
+
+// structValues collects the field values of a struct by config key, fields
+// of embedded structs are collected as if they were in the outer one.
+func structValues(rv reflect.Value, m map[interface{}]interface{}, visiting map[visit]bool) {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			// 未导出字段
+			continue
+		}
+		name, ok := fieldKey(field)
+		if !ok {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if field.Anonymous && field.Tag.Get("config") == "" {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				structValues(fv, m, visiting)
+				continue
+			}
+		}
+		if field.PkgPath != "" || !fv.CanInterface() {
+			continue
+		}
+		if _, ok := m[name]; ok {
+			continue
+		}
+		switch fv.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+			if fv.IsNil() {
+				continue
+			}
+		}
+		m[name] = normalize(fv.Interface(), visiting)
+	}
+}
+
+// checkValue returns an error if a normalized value contains values of
+// types yaml doesn't decode into, such as functions and channels.
+func checkValue(v interface{}, key, delimiter string) error {
+	switch vv := v.(type) {
+	case nil, string, bool, int, float64:
+		return nil
+	case map[interface{}]interface{}:
+		for k, item := range vv {
+			if err := checkValue(item, joinKey(key, fmt.Sprint(k), delimiter), delimiter); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		for i, item := range vv {
+			if err := checkValue(item, fmt.Sprintf("%s[%d]", key, i), delimiter); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		switch reflect.ValueOf(v).Kind() {
+		case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+			// normalizeValue保留了超出int范围的无符号整数
+			if key == "" {
+				return fmt.Errorf("value %v overflows int", v)
+			}
+			return fmt.Errorf("value %v of `%s` overflows int", v, key)
+		}
+		if key == "" {
+			return fmt.Errorf("type %T is not supported", v)
+		}
+		return fmt.Errorf("value of `%s` is of unsupported type %T", key, v)
+	}
+}
+
+// stringKeyed converts the maps in a config value to map[string]interface{},
+// at every level, as required by encoders.
+func stringKeyed(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(vv))
+		for k, item := range vv {
+			if key, ok := k.(string); ok {
+				m[key] = stringKeyed(item)
+			} else {
+				m[fmt.Sprint(k)] = stringKeyed(item)
+			}
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, 0, len(vv))
+		for _, item := range vv {
+			s = append(s, stringKeyed(item))
+		}
+		return s
+	default:
+		return v
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+type convertNode struct {
+	Name string       `config:"name"`
+	Next *convertNode `config:"next"`
+}
+
+func TestFromMapValues(t *testing.T) {
+	cyclic := &convertNode{Name: "a"}
+	cyclic.Next = &convertNode{Name: "b", Next: cyclic}
+	cyclicMap := map[string]interface{}{"name": "m"}
+	cyclicMap["self"] = cyclicMap
+	shared := []interface{}{"x"}
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name:  "struct",
+			value: convertNode{Name: "a"},
+			want:  map[interface{}]interface{}{"name": "a"},
+		},
+		{
+			name:  "cyclic pointer",
+			value: cyclic,
+			want: map[interface{}]interface{}{"name": "a", "next": map[interface{}]interface{}{
+				"name": "b", "next": nil,
+			}},
+		},
+		{
+			name:  "cyclic map",
+			value: cyclicMap,
+			want:  map[interface{}]interface{}{"name": "m", "self": nil},
+		},
+		{
+			name:  "shared slice",
+			value: []interface{}{shared, shared},
+			want:  []interface{}{[]interface{}{"x"}, []interface{}{"x"}},
+		},
+		{
+			name:  "uint",
+			value: uint64(42),
+			want:  42,
+		},
+		{
+			name:    "uint overflow",
+			value:   uint64(math.MaxUint64),
+			wantErr: true,
+		},
+		{
+			name:    "func",
+			value:   func() {},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := FromMap(map[string]interface{}{"v": tt.value})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromMap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got, err := c.Get("v"); err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Get(v) = %#v, %v, want %#v", got, err, tt.want)
+			}
+		})
+	}
+}
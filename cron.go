@@ -0,0 +1,57 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// the parser of cron expressions with a seconds field
+var cronSecondsParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// CronSchedule is a validated cron expression.
+type CronSchedule struct {
+	Spec     string // the cron expression as in the config
+	schedule cron.Schedule
+}
+
+// Next returns the next time the schedule is activated after t.
+func (s CronSchedule) Next(t time.Time) time.Time {
+	return s.schedule.Next(t)
+}
+
+func (s CronSchedule) String() string {
+	return s.Spec
+}
+
+// GetCron returns the cron schedule for a given key. Standard 5 fields
+// expressions, 6 fields expressions starting with seconds and descriptors
+// such as `@daily` or `@every 1h` are accepted, as well as a `CRON_TZ=`
+// prefix selecting the time zone.
+func (c *Config) GetCron(key string) (CronSchedule, error) {
+	v, err := c.Get(key)
+	if err != nil {
+		return CronSchedule{}, err
+	}
+
+	spec, ok := v.(string)
+	if !ok {
+		return CronSchedule{}, typeError(key, "cron expression", v)
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) > 0 && (strings.HasPrefix(fields[0], "CRON_TZ=") || strings.HasPrefix(fields[0], "TZ=")) {
+		fields = fields[1:]
+	}
+	// 6个字段的表达式包含秒
+	parse := cron.ParseStandard
+	if len(fields) == 6 {
+		parse = cronSecondsParser.Parse
+	}
+	schedule, err := parse(spec)
+	if err != nil {
+		return CronSchedule{}, typeError(key, "cron expression", v)
+	}
+	return CronSchedule{spec, schedule}, nil
+}
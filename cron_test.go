@@ -0,0 +1,50 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetCron(t *testing.T) {
+	c, err := FromString(`jobs:
+  standard: "30 2 * * *"
+  seconds: "15 30 2 * * *"
+  daily: "@daily"
+  every: "@every 1h"
+  tz: "CRON_TZ=Asia/Shanghai 0 8 * * *"
+  invalid: "61 * * * *"
+  number: 5
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		key  string
+		want time.Time
+	}{
+		{key: "jobs.standard", want: time.Date(2024, 1, 1, 2, 30, 0, 0, time.UTC)},
+		{key: "jobs.seconds", want: time.Date(2024, 1, 1, 2, 30, 15, 0, time.UTC)},
+		{key: "jobs.daily", want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{key: "jobs.every", want: now.Add(time.Hour)},
+		{key: "jobs.tz", want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := c.GetCron(tt.key)
+		if err != nil {
+			t.Errorf("GetCron(%q) error = %v", tt.key, err)
+			continue
+		}
+		if got := s.Next(now); !got.Equal(tt.want) {
+			t.Errorf("GetCron(%q).Next() = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+	if s, _ := c.GetCron("jobs.daily"); s.String() != "@daily" {
+		t.Errorf("String() = %q, want @daily", s.String())
+	}
+	for _, key := range []string{"jobs.invalid", "jobs.number", "jobs.missing"} {
+		if _, err := c.GetCron(key); err == nil {
+			t.Errorf("GetCron(%q) error = nil, want an error", key)
+		}
+	}
+}
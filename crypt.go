@@ -0,0 +1,85 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+const (
+	encryptedPrefix = "ENC["
+	encryptedSuffix = "]"
+)
+
+// KeyProvider decrypts the values of the form `ENC[...]`, the text between
+// the brackets is passed to Decrypt. Implement it to use a KMS, age, etc.
+type KeyProvider interface {
+	Decrypt(cipherText string) (string, error)
+}
+
+// WithKeyProvider decrypts the encrypted values of the config with the
+// specified key provider when the config is loaded.
+func WithKeyProvider(p KeyProvider) Option {
+	return func(c *Config) {
+		c.keyProvider = p
+	}
+}
+
+// StaticKeyProvider encrypts and decrypts values with a static AES key,
+// cipher texts are base64 encoded AES-GCM sealed values.
+type StaticKeyProvider struct {
+	aead cipher.AEAD
+}
+
+// NewStaticKeyProvider create a key provider with an AES key, the key must
+// be 16, 24 or 32 bytes long.
+func NewStaticKeyProvider(key []byte) (*StaticKeyProvider, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &StaticKeyProvider{aead}, nil
+}
+
+// Encrypt returns the encrypted value of the form `ENC[...]`.
+func (p *StaticKeyProvider) Encrypt(plainText string) (string, error) {
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := p.aead.Seal(nonce, nonce, []byte(plainText), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed) + encryptedSuffix, nil
+}
+
+// Decrypt implements KeyProvider.
+func (p *StaticKeyProvider) Decrypt(cipherText string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(cipherText)
+	if err != nil {
+		return "", err
+	}
+	size := p.aead.NonceSize()
+	if len(data) < size {
+		return "", errors.New("cipher text is too short")
+	}
+	plain, err := p.aead.Open(nil, data[:size], data[size:], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// cipherText returns the text between the brackets of `ENC[...]`.
+func cipherText(s string) (string, bool) {
+	if strings.HasPrefix(s, encryptedPrefix) && strings.HasSuffix(s, encryptedSuffix) {
+		return s[len(encryptedPrefix) : len(s)-len(encryptedSuffix)], true
+	}
+	return "", false
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStaticKeyProvider(t *testing.T) {
+	p, err := NewStaticKeyProvider([]byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := p.Encrypt("s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(enc, "ENC[") || !strings.HasSuffix(enc, "]") {
+		t.Fatalf("Encrypt() = %q, want ENC[...]", enc)
+	}
+
+	c, err := FromString("db: {password: \""+enc+"\", user: root}\n", WithKeyProvider(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetString("db.password"); err != nil || got != "s3cret" {
+		t.Errorf("GetString(db.password) = %q, %v, want s3cret", got, err)
+	}
+	if got, err := c.GetString("db.user"); err != nil || got != "root" {
+		t.Errorf("GetString(db.user) = %q, %v, want root", got, err)
+	}
+
+	// 没有KeyProvider时保留密文
+	c, err = FromString("db: {password: \"" + enc + "\"}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.GetString("db.password"); got != enc {
+		t.Errorf("GetString(db.password) = %q, want the encrypted value", got)
+	}
+
+	other, err := NewStaticKeyProvider([]byte("fedcba9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{enc, "ENC[!!]", "ENC[YWJj]"} {
+		if _, err := FromString("password: \""+v+"\"\n", WithKeyProvider(other)); err == nil {
+			t.Errorf("FromString() error = nil for %q, want an error", v)
+		}
+	}
+	if _, err := NewStaticKeyProvider([]byte("short")); err == nil {
+		t.Error("NewStaticKeyProvider() error = nil for a short key, want an error")
+	}
+}
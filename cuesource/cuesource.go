@@ -0,0 +1,112 @@
+// Package cuesource loads config from CUE, a `.cue` file or the package in
+// a directory is evaluated, so that the schema and the data of the config
+// can be declared in one file.
+package cuesource
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	cueerrors "cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/load"
+	"github.com/go-apibox/config"
+)
+
+// Source is a config.Source evaluating CUE.
+type Source struct {
+	path string
+	tags []string
+}
+
+// Option configures a Source.
+type Option func(*Source)
+
+// WithTags sets the values of the fields declared by `@tag()` attributes,
+// like the `-t` flag of cue, such as `env=prod`.
+func WithTags(tags ...string) Option {
+	return func(s *Source) {
+		s.tags = append(s.tags, tags...)
+	}
+}
+
+// New create a source evaluating the `.cue` file or the package in the
+// directory at path, the result must be concrete.
+func New(path string, opts ...Option) *Source {
+	s := &Source{path: path}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// FromCUE create a config with the result of a CUE source, the source is
+// evaluated again by Config.Reload. Constraint violations are returned in
+// a *config.ValidationError.
+func FromCUE(ctx context.Context, s *Source, opts ...config.Option) (*config.Config, error) {
+	c, err := config.FromString("", opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.AddSource(ctx, s.path, s); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Load implements config.Source, constraint violations are returned in a
+// *config.ValidationError with a violation per error reported by CUE.
+func (s *Source) Load(ctx context.Context) ([]byte, config.Format, error) {
+	path, err := filepath.Abs(s.path)
+	if err != nil {
+		return nil, "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, "", err
+	}
+	// 目录按包加载，文件只加载该文件
+	cfg := &load.Config{Dir: path, Tags: s.tags}
+	args := []string{"."}
+	if !info.IsDir() {
+		cfg.Dir, args = filepath.Dir(path), []string{filepath.Base(path)}
+	}
+
+	insts := load.Instances(args, cfg)
+	if len(insts) == 0 {
+		return nil, "", errors.New("no CUE instance found at `" + s.path + "`")
+	}
+	if err := insts[0].Err; err != nil {
+		return nil, "", err
+	}
+	v := cuecontext.New().BuildInstance(insts[0])
+	if err := v.Err(); err != nil {
+		return nil, "", violations(err)
+	}
+	if err := v.Validate(cue.Concrete(true)); err != nil {
+		return nil, "", violations(err)
+	}
+	b, err := v.MarshalJSON()
+	if err != nil {
+		return nil, "", violations(err)
+	}
+	return b, config.FormatJSON, nil
+}
+
+// violations lists the errors reported by CUE in a *config.ValidationError,
+// each error is prefixed by its position in the CUE files.
+func violations(err error) error {
+	list := cueerrors.Errors(err)
+	errs := make([]error, 0, len(list))
+	for _, e := range list {
+		msg := e.Error()
+		if pos := e.Position(); pos.IsValid() {
+			msg = pos.String() + ": " + msg
+		}
+		errs = append(errs, errors.New(msg))
+	}
+	return &config.ValidationError{Errors: errs}
+}
@@ -0,0 +1,166 @@
+package cuesource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-apibox/config"
+)
+
+// writeFile writes a file in dir and returns its path.
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	file := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "config.cue", `
+env: *"dev" | string @tag(env)
+server: {
+	host: string | *"localhost"
+	port: int & >0 & <65536
+}
+server: port: 8080
+db: host: env + ".db"
+`)
+	pkg := t.TempDir()
+	writeFile(t, pkg, "schema.cue", "package app\n\n#Server: {host: string, port: int & >0}\nserver: #Server\n")
+	writeFile(t, pkg, "data.cue", "package app\n\nserver: {host: \"example.com\", port: 443}\n")
+
+	tests := []struct {
+		name string
+		s    *Source
+		want map[string]interface{}
+	}{
+		{
+			name: "file",
+			s:    New(file),
+			want: map[string]interface{}{
+				"env":    "dev",
+				"server": map[string]interface{}{"host": "localhost", "port": 8080.0},
+				"db":     map[string]interface{}{"host": "dev.db"},
+			},
+		},
+		{
+			name: "tags",
+			s:    New(file, WithTags("env=prod")),
+			want: map[string]interface{}{
+				"env":    "prod",
+				"server": map[string]interface{}{"host": "localhost", "port": 8080.0},
+				"db":     map[string]interface{}{"host": "prod.db"},
+			},
+		},
+		{
+			name: "package",
+			s:    New(pkg),
+			want: map[string]interface{}{
+				"server": map[string]interface{}{"host": "example.com", "port": 443.0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, format, err := tt.s.Load(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != config.FormatJSON {
+				t.Errorf("Load() format = %q, want %q", format, config.FormatJSON)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name      string
+		path      string
+		violation bool // the error is a *config.ValidationError
+	}{
+		{
+			name:      "constraint",
+			path:      writeFile(t, dir, "constraint.cue", "port: int & <1024\nport: 8080\n"),
+			violation: true,
+		},
+		{
+			name:      "not concrete",
+			path:      writeFile(t, dir, "concrete.cue", "host: string\nport: 8080\n"),
+			violation: true,
+		},
+		{
+			name: "syntax",
+			path: writeFile(t, dir, "syntax.cue", "port: {\n"),
+		},
+		{
+			name: "missing",
+			path: filepath.Join(dir, "missing.cue"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := New(tt.path).Load(context.Background())
+			if err == nil {
+				t.Fatal("Load() error = nil, want an error")
+			}
+			var verr *config.ValidationError
+			if got := errors.As(err, &verr); got != tt.violation {
+				t.Fatalf("Load() error = %v, want a *config.ValidationError %v", err, tt.violation)
+			}
+			if tt.violation && len(verr.Errors) == 0 {
+				t.Error("Load() returns a *config.ValidationError without violations")
+			}
+		})
+	}
+}
+
+func TestFromCUE(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "config.cue", "server: {host: \"localhost\", port: int & <10000}\nserver: port: 8080\n")
+
+	c, err := FromCUE(context.Background(), New(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetString("server.host"); err != nil || got != "localhost" {
+		t.Errorf("GetString(server.host) = %q, %v, want localhost", got, err)
+	}
+	if got, err := c.GetInt("server.port"); err != nil || got != 8080 {
+		t.Errorf("GetInt(server.port) = %d, %v, want 8080", got, err)
+	}
+	if got := c.Source("server.port"); got.Name != file {
+		t.Errorf("Source(server.port) = %v, want the cue file", got)
+	}
+
+	// 违反约束时重新加载失败，保留原配置
+	writeFile(t, dir, "config.cue", "server: {host: \"localhost\", port: int & <10000}\nserver: port: 80800\n")
+	_, err = c.Reload()
+	var verr *config.ValidationError
+	if !errors.As(err, &verr) {
+		t.Errorf("Reload() error = %v, want a *config.ValidationError", err)
+	}
+	if got, err := c.GetInt("server.port"); err != nil || got != 8080 {
+		t.Errorf("GetInt(server.port) after Reload = %d, %v, want 8080", got, err)
+	}
+
+	if _, err := FromCUE(context.Background(), New(filepath.Join(dir, "missing.cue"))); err == nil {
+		t.Error("FromCUE() error = nil for a missing file, want an error")
+	}
+}
@@ -0,0 +1,506 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// DecodeHook converts a config value before it is decoded into a value of
+// the target type, such as parsing a string into a net.IP. It returns the
+// value unchanged if the conversion doesn't apply.
+type DecodeHook func(value interface{}, target reflect.Type) (interface{}, error)
+
+// WithDecodeHook adds a hook used by Unmarshal, UnmarshalKey and GetSlice.
+func WithDecodeHook(hook DecodeHook) Option {
+	return func(c *Config) {
+		c.decodeHooks = append(c.decodeHooks, hook)
+	}
+}
+
+// WithWeakDecoding converts strings to numbers and booleans, and numbers to
+// strings, in Unmarshal and UnmarshalKey when the type of the target field
+// differs, following the CoercionPolicy of the getters.
+func WithWeakDecoding() Option {
+	return func(c *Config) {
+		c.weakDecoding = true
+	}
+}
+
+// GetSlice returns the []T value for a given key, the elements are decoded
+// like Unmarshal. Strings are converted to numbers and booleans as the
+// XxxArray getters do.
+func GetSlice[T any](c *Config, key string) ([]T, error) {
+	v, err := c.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := c.toList(v)
+	if !ok {
+		return nil, typeError(key, "a list", v)
+	}
+
+	d := c.newDecoder(true)
+	vArr := make([]T, len(items))
+	for i, item := range items {
+		if err := d.decode(item, reflect.ValueOf(&vArr[i]).Elem(), fmt.Sprintf("%s[%d]", key, i)); err != nil {
+			d.fail(err)
+		}
+	}
+	if err := d.err(); err != nil {
+		return nil, err
+	}
+	return vArr, nil
+}
+
+// Unmarshal decodes the whole config into v, which should be a pointer.
+// Struct fields are matched by their `config` tag, or the lowercased field
+// name if the tag is absent. Fields with an `env` tag are read from the
+// environment variable named by the tag if their keys are absent, such as
+// `env:"DATABASE_URL"`. Decoding goes on after a field fails, if more than
+// one field fails, such as unknown keys with WithStrictKeys, all errors are
+// returned in a *ValidationError. The `validate` tags of the fields are
+// checked after decoding, all violations are returned in a *ValidationError.
+func (c *Config) Unmarshal(v interface{}) error {
+	return c.decodeTo(c.data(), "", v)
+}
+
+// UnmarshalKey decodes the value of a given key into v, which should be a pointer.
+// Support multi-level key which concat with '.'.
+func (c *Config) UnmarshalKey(key string, v interface{}) error {
+	// 解码时逐个记录读取的key
+	value, err := c.get(key)
+	if err != nil {
+		c.access(key, false)
+		return err
+	}
+	return c.decodeTo(value, key, v)
+}
+
+func (c *Config) decodeTo(value interface{}, key string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("unmarshal target should be a non-nil pointer")
+	}
+
+	d := c.newDecoder(c.weakDecoding)
+	if len(c.accessHooks) > 0 {
+		d.access = func(key string) {
+			c.access(key, true)
+		}
+	}
+	d.read(key, value, rv.Elem().Type())
+	if err := d.decode(value, rv.Elem(), key); err != nil {
+		d.fail(err)
+	}
+	if err := d.err(); err != nil {
+		return err
+	}
+	if errs := c.validateStruct(rv.Elem(), key); len(errs) > 0 {
+		return &ValidationError{errs}
+	}
+	return nil
+}
+
+type decoder struct {
+	delimiter  string
+	strictKeys bool
+	hooks      []DecodeHook
+	access     func(key string) // 记录读取的key，可能为nil
+	errs       []error          // 解码失败的字段，解码完成后一起返回
+}
+
+// newDecoder returns a decoder, values are converted as the getters do if
+// weak is true.
+func (c *Config) newDecoder(weak bool) *decoder {
+	d := &decoder{delimiter: c.Delimiter, strictKeys: c.strictKeys, hooks: c.decodeHooks}
+	if weak {
+		d.hooks = append([]DecodeHook{c.coerceScalar}, d.hooks...)
+	}
+	return d
+}
+
+// fail records an error of a field, decoding goes on with the other fields.
+func (d *decoder) fail(err error) {
+	d.errs = append(d.errs, err)
+}
+
+// err returns the errors recorded by fail, more than one error are returned
+// in a *ValidationError.
+func (d *decoder) err() error {
+	switch len(d.errs) {
+	case 0:
+		return nil
+	case 1:
+		return d.errs[0]
+	default:
+		return &ValidationError{d.errs}
+	}
+}
+
+// read reports a key decoded into a value of type t to the access hooks.
+// The keys of maps, lists and structs are not reported, their elements and
+// fields are reported as they are decoded, so that the keys without fields
+// are not counted as read.
+func (d *decoder) read(key string, value interface{}, t reflect.Type) {
+	if d.access == nil || key == "" {
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		switch value.(type) {
+		case map[interface{}]interface{}, []interface{}:
+			return
+		}
+	case reflect.Struct:
+		if _, ok := value.(map[interface{}]interface{}); ok {
+			return
+		}
+	}
+	d.access(key)
+}
+
+func (d *decoder) join(pKey, key string) string {
+	if pKey == "" {
+		return key
+	}
+	return pKey + d.delimiter + key
+}
+
+func (d *decoder) decode(value interface{}, rv reflect.Value, key string) error {
+	// 值为null时保留原值
+	if value == nil {
+		return nil
+	}
+
+	for _, hook := range d.hooks {
+		var err error
+		if value, err = hook(value, rv.Type()); err != nil {
+			return fmt.Errorf("value of `%s` is invalid: %w", key, err)
+		}
+	}
+	if value == nil {
+		return nil
+	}
+
+	// hook已转换为目标类型，配置中的map和slice仍需复制
+	switch value.(type) {
+	case map[interface{}]interface{}, []interface{}:
+	default:
+		if vv := reflect.ValueOf(value); vv.Type() == rv.Type() {
+			rv.Set(vv)
+			return nil
+		}
+	}
+
+	if rv.Type() == durationType {
+		switch vv := value.(type) {
+		case string:
+			t, err := time.ParseDuration(vv)
+			if err != nil {
+				return typeError(key, "duration", value)
+			}
+			rv.SetInt(int64(t))
+			return nil
+		case int:
+			rv.SetInt(int64(vv))
+			return nil
+		}
+		return typeError(key, "duration", value)
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return d.decode(value, rv.Elem(), key)
+
+	case reflect.Interface:
+		vv := reflect.ValueOf(stringKeyed(value))
+		if !vv.Type().AssignableTo(rv.Type()) {
+			return typeError(key, rv.Type().String(), value)
+		}
+		rv.Set(vv)
+		return nil
+
+	case reflect.Struct:
+		return d.decodeStruct(value, rv, key)
+
+	case reflect.Map:
+		return d.decodeMap(value, rv, key)
+
+	case reflect.Slice:
+		items, ok := value.([]interface{})
+		if !ok {
+			return typeError(key, "a list", value)
+		}
+		s := reflect.MakeSlice(rv.Type(), len(items), len(items))
+		for i, item := range items {
+			iKey := fmt.Sprintf("%s[%d]", key, i)
+			d.read(iKey, item, rv.Type().Elem())
+			if err := d.decode(item, s.Index(i), iKey); err != nil {
+				d.fail(err)
+			}
+		}
+		rv.Set(s)
+		return nil
+
+	case reflect.Array:
+		items, ok := value.([]interface{})
+		if !ok {
+			return typeError(key, "a list", value)
+		}
+		if len(items) > rv.Len() {
+			return fmt.Errorf("value of `%s` has more than %d items", key, rv.Len())
+		}
+		for i, item := range items {
+			iKey := fmt.Sprintf("%s[%d]", key, i)
+			d.read(iKey, item, rv.Type().Elem())
+			if err := d.decode(item, rv.Index(i), iKey); err != nil {
+				d.fail(err)
+			}
+		}
+		return nil
+
+	case reflect.String:
+		str, ok := value.(string)
+		if !ok {
+			return typeError(key, "string", value)
+		}
+		rv.SetString(str)
+		return nil
+
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return typeError(key, "boolean", value)
+		}
+		rv.SetBool(b)
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := value.(int)
+		if !ok || rv.OverflowInt(int64(i)) {
+			return typeError(key, rv.Type().String(), value)
+		}
+		rv.SetInt(int64(i))
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, ok := value.(int)
+		if !ok || i < 0 || rv.OverflowUint(uint64(i)) {
+			return typeError(key, rv.Type().String(), value)
+		}
+		rv.SetUint(uint64(i))
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		switch vv := value.(type) {
+		case int:
+			rv.SetFloat(float64(vv))
+			return nil
+		case float64:
+			rv.SetFloat(vv)
+			return nil
+		}
+		return typeError(key, rv.Type().String(), value)
+	}
+
+	return fmt.Errorf("can not unmarshal `%s` into %s", key, rv.Type())
+}
+
+func (d *decoder) decodeStruct(value interface{}, rv reflect.Value, key string) error {
+	m, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return typeError(key, "a map", value)
+	}
+
+	fields := make(map[string]structField)
+	structFields(rv, fields)
+
+	for _, k := range sortedKeys(m) {
+		name, _ := k.(string)
+		field, ok := fields[name]
+		if !ok {
+			if d.strictKeys {
+				d.fail(unknownKeyError(d.join(key, fmt.Sprint(k))))
+			}
+			continue
+		}
+		d.read(d.join(key, name), m[k], field.value.Type())
+		if err := d.decode(m[k], field.value, d.join(key, name)); err != nil {
+			d.fail(err)
+		}
+	}
+
+	// 配置中不存在的字段从环境变量读取
+	for name, field := range fields {
+		if _, ok := m[name]; ok {
+			continue
+		}
+		if err := d.decodeEnv(field, d.join(key, name)); err != nil {
+			d.fail(err)
+		}
+	}
+	return nil
+}
+
+// decodeEnv decodes a field absent from the config from the environment
+// variable of its `env` tag, the fields of a nested struct are handled
+// the same way.
+func (d *decoder) decodeEnv(field structField, key string) error {
+	if field.env != "" {
+		str, ok := os.LookupEnv(field.env)
+		if !ok {
+			return nil
+		}
+		value, err := parseTagValue(field.value.Type(), str)
+		if err != nil {
+			return fmt.Errorf("value of environment variable `%s` for `%s` is invalid: %w", field.env, key, err)
+		}
+		return d.decode(value, field.value, key)
+	}
+	if field.value.Kind() == reflect.Struct {
+		return d.decodeStruct(map[interface{}]interface{}{}, field.value, key)
+	}
+	return nil
+}
+
+type structField struct {
+	value reflect.Value
+	env   string // `env`标签指定的环境变量
+}
+
+// structFields collects the settable fields of a struct by config key,
+// fields of embedded structs are collected as if they were in the outer one.
+func structFields(rv reflect.Value, fields map[string]structField) {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			// 未导出字段
+			continue
+		}
+		name, ok := fieldKey(field)
+		if !ok {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if field.Anonymous && field.Tag.Get("config") == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if fv.Kind() == reflect.Ptr {
+					if fv.IsNil() {
+						if !fv.CanSet() {
+							continue
+						}
+						fv.Set(reflect.New(ft))
+					}
+					fv = fv.Elem()
+				}
+				structFields(fv, fields)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if _, ok := fields[name]; !ok {
+			fields[name] = structField{fv, field.Tag.Get("env")}
+		}
+	}
+}
+
+func (d *decoder) decodeMap(value interface{}, rv reflect.Value, key string) error {
+	m, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return typeError(key, "a map", value)
+	}
+
+	t := rv.Type()
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMapWithSize(t, len(m)))
+	}
+	for _, k := range sortedKeys(m) {
+		cKey := d.join(key, fmt.Sprint(k))
+		kv := reflect.New(t.Key()).Elem()
+		if err := d.decode(k, kv, cKey); err != nil {
+			if t.Key().Kind() != reflect.String {
+				d.fail(err)
+				continue
+			}
+			// 非字符串的key转为字符串
+			kv.SetString(fmt.Sprint(k))
+		}
+		vv := reflect.New(t.Elem()).Elem()
+		d.read(cKey, m[k], t.Elem())
+		if err := d.decode(m[k], vv, cKey); err != nil {
+			d.fail(err)
+			continue
+		}
+		rv.SetMapIndex(kv, vv)
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a map in order, so that the errors of the
+// fields are reported in order.
+func sortedKeys(m map[interface{}]interface{}) []interface{} {
+	keys := make([]interface{}, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys
+}
+
+// unknownKeys returns the keys in the config that are not declared in the schema.
+// Keys under a declared key without declared sub keys are all known.
+func (c *Config) unknownKeys(schema Schema) []string {
+	var unknown []string
+	var walk func(node map[interface{}]interface{}, pKey string)
+	walk = func(node map[interface{}]interface{}, pKey string) {
+		for k, v := range node {
+			cKey := fmt.Sprint(k)
+			if pKey != "" {
+				cKey = pKey + c.Delimiter + cKey
+			}
+
+			hasSubKeys := false
+			for declared := range schema {
+				if strings.HasPrefix(declared, cKey+c.Delimiter) || strings.HasPrefix(declared, cKey+"[") {
+					hasSubKeys = true
+					break
+				}
+			}
+			if !hasSubKeys {
+				if _, ok := schema[cKey]; !ok {
+					unknown = append(unknown, cKey)
+				}
+				continue
+			}
+			if vv, ok := v.(map[interface{}]interface{}); ok {
+				walk(vv, cKey)
+			}
+		}
+	}
+	walk(c.data(), "")
+	return unknown
+}
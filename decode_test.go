@@ -0,0 +1,157 @@
+package config
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type decodeBase struct {
+	Name string `config:"name"`
+}
+
+type decodeDB struct {
+	Host     string        `config:"host"`
+	Port     uint16        `config:"port"`
+	Timeout  time.Duration `config:"timeout"`
+	Password string        `config:"password" env:"TEST_DECODE_PASSWORD"`
+}
+
+type decodeConfig struct {
+	decodeBase
+	DB      decodeDB          `config:"db"`
+	Replica *decodeDB         `config:"replica"`
+	Hosts   []string          `config:"hosts"`
+	Pair    [2]int            `config:"pair"`
+	Labels  map[string]string `config:"labels"`
+	Extra   interface{}       `config:"extra"`
+	Ratio   float64
+	Skipped string `config:"-"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Setenv("TEST_DECODE_PASSWORD", "s3cret")
+	c, err := FromString(`name: app
+db: {host: localhost, port: 3306, timeout: 5s}
+replica: {host: replica, password: pw}
+hosts: [a, b]
+pair: [1, 2]
+labels: {env: prod}
+extra: {a: [1]}
+ratio: 1
+skipped: x
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got decodeConfig
+	if err := c.Unmarshal(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := decodeConfig{
+		decodeBase: decodeBase{Name: "app"},
+		DB:         decodeDB{Host: "localhost", Port: 3306, Timeout: 5 * time.Second, Password: "s3cret"},
+		Replica:    &decodeDB{Host: "replica", Password: "pw"},
+		Hosts:      []string{"a", "b"},
+		Pair:       [2]int{1, 2},
+		Labels:     map[string]string{"env": "prod"},
+		Extra:      map[string]interface{}{"a": []interface{}{1}},
+		Ratio:      1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal() = %+v, want %+v", got, want)
+	}
+
+	var db decodeDB
+	if err := c.UnmarshalKey("db", &db); err != nil || db.Port != 3306 {
+		t.Errorf("UnmarshalKey(db) = %+v, %v, want port 3306", db, err)
+	}
+	if err := c.UnmarshalKey("missing", &db); err == nil {
+		t.Error("UnmarshalKey() error = nil for a missing key, want an error")
+	}
+	if err := c.Unmarshal(got); err == nil {
+		t.Error("Unmarshal() error = nil for a non pointer, want an error")
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	c, err := FromString("db: {host: 1, port: 70000, timeout: x, user: root}\n", WithStrictKeys())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		DB decodeDB `config:"db"`
+	}
+	err = c.Unmarshal(&got)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Unmarshal() error = %v, want a *ValidationError", err)
+	}
+	// 所有字段的错误一起返回
+	if len(verr.Errors) != 4 {
+		t.Errorf("Unmarshal() errors = %v, want 4 errors", verr.Errors)
+	}
+	if !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Unmarshal() error = %v, want ErrUnknownKey for db.user", err)
+	}
+}
+
+func TestWithWeakDecoding(t *testing.T) {
+	const yaml = "port: \"8080\"\ndebug: \"true\"\nname: 42\n"
+	var got struct {
+		Port  int    `config:"port"`
+		Debug bool   `config:"debug"`
+		Name  string `config:"name"`
+	}
+	c, err := FromString(yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Unmarshal(&got); err == nil {
+		t.Error("Unmarshal() error = nil without WithWeakDecoding, want an error")
+	}
+
+	c, err = FromString(yaml, WithWeakDecoding(), WithCoercion(CoercionPolicy{StringToNumber: true, StringToBool: true, NumberToString: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Unmarshal(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Port != 8080 || !got.Debug || got.Name != "42" {
+		t.Errorf("Unmarshal() = %+v, want converted values", got)
+	}
+}
+
+func TestDecodeHook(t *testing.T) {
+	ipHook := func(value interface{}, target reflect.Type) (interface{}, error) {
+		s, ok := value.(string)
+		if !ok || target != reflect.TypeOf(net.IP{}) {
+			return value, nil
+		}
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, errors.New("invalid IP " + s)
+		}
+		return ip, nil
+	}
+	c, err := FromString("ips: [127.0.0.1, \"::1\"]\nbad: [x]\nports: [\"80\", 443]\n", WithDecodeHook(ipHook))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ips, err := GetSlice[net.IP](c, "ips")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ips) != 2 || !ips[0].Equal(net.IPv4(127, 0, 0, 1)) || !ips[1].Equal(net.IPv6loopback) {
+		t.Errorf("GetSlice[net.IP](ips) = %v", ips)
+	}
+	if _, err := GetSlice[net.IP](c, "bad"); err == nil {
+		t.Error("GetSlice[net.IP](bad) error = nil, want an error of the hook")
+	}
+	if ports, err := GetSlice[int](c, "ports"); err != nil || !reflect.DeepEqual(ports, []int{80, 443}) {
+		t.Errorf("GetSlice[int](ports) = %v, %v, want [80 443]", ports, err)
+	}
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SetDefault sets the default value for a given key.
+// Support multi-level key which concat with '.'.
+// Defaults have the lowest precedence, so the value is only used by getters
+// when the key is not set in the config file.
+func (c *Config) SetDefault(key string, value interface{}) {
+	value = normalizeValue(value)
+	_, err := c.update("default "+key, func() (func(), error) {
+		return c.editDefaults(func() error {
+			return c.setDefault(key, value)
+		})
+	})
+	if err != nil {
+		c.log().Printf("default of key `%s` is rejected: %s", key, err)
+	}
+}
+
+// editDefaults runs fn on a copy of the defaults, and returns the func
+// restoring the previous defaults.
+func (c *Config) editDefaults(fn func() error) (func(), error) {
+	old := c.defaults
+	if old != nil {
+		c.defaults = copyTree(old).(map[interface{}]interface{})
+	}
+	if err := fn(); err != nil {
+		c.defaults = old
+		return nil, err
+	}
+	return func() {
+		c.defaults = old
+	}, nil
+}
+
+func (c *Config) setDefault(key string, value interface{}) error {
+	keyArr, err := c.parseKey(key)
+	if err != nil {
+		return err
+	}
+	if c.defaults == nil {
+		c.defaults = make(map[interface{}]interface{})
+	}
+	_, err = setKeys(c.defaults, keyArr, value, c.Delimiter)
+	return err
+}
+
+// SetDefaultsFromStruct sets defaults from the `default` tags of a struct.
+// The key of a field is given by its `config` tag, or the lowercased field
+// name if the tag is absent. Fields of nested structs are prefixed with the
+// key of the struct field, fields of embedded structs are not.
+//
+//	type ServerConfig struct {
+//		Addr    string   `config:"addr" default:"127.0.0.1:8080"`
+//		Workers int      `config:"workers" default:"4"`
+//		Hosts   []string `config:"hosts" default:"[a, b]"`
+//	}
+func (c *Config) SetDefaultsFromStruct(v interface{}) error {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return errors.New("defaults should be set from a struct")
+	}
+
+	_, err := c.update("defaults "+t.String(), func() (func(), error) {
+		return c.editDefaults(func() error {
+			return c.setStructDefaults(t, "", make(map[reflect.Type]bool))
+		})
+	})
+	return err
+}
+
+// setStructDefaults sets the defaults of the fields of t, visiting holds the
+// struct types being set, so that the fields of self-referential types,
+// such as `Next *Node`, are not set forever.
+func (c *Config) setStructDefaults(t reflect.Type, prefix string, visiting map[reflect.Type]bool) error {
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			// 未导出字段
+			continue
+		}
+		name, ok := fieldKey(field)
+		if !ok {
+			continue
+		}
+
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		key := name
+		if prefix != "" {
+			key = prefix + c.Delimiter + name
+		}
+
+		def, hasDefault := field.Tag.Lookup("default")
+		if !hasDefault {
+			if ft.Kind() == reflect.Struct && !visiting[ft] {
+				if field.Anonymous && field.Tag.Get("config") == "" {
+					key = prefix
+				}
+				if err := c.setStructDefaults(ft, key, visiting); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		value, err := parseTagValue(ft, def)
+		if err != nil {
+			return errors.New("default value of `" + key + "` is invalid: " + err.Error())
+		}
+		if err := c.setDefault(key, value); err != nil {
+			return errors.New("default value of `" + key + "` can not be set: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// parseTagValue parses a value given in a struct tag for a field of type t.
+func parseTagValue(t reflect.Type, str string) (interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// 字符串类型直接使用，其它类型按yaml解析
+	if t.Kind() == reflect.String {
+		return str, nil
+	}
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(str), &value); err != nil {
+		return nil, err
+	}
+	return normalizeValue(value), nil
+}
+
+// fieldKey returns the config key of a struct field.
+func fieldKey(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("config")
+	if tag == "-" {
+		return "", false
+	}
+	if pos := strings.Index(tag, ","); pos != -1 {
+		tag = tag[:pos]
+	}
+	if tag == "" {
+		return strings.ToLower(field.Name), true
+	}
+	return tag, true
+}
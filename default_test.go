@@ -0,0 +1,65 @@
+package config
+
+import "testing"
+
+type defaultNode struct {
+	Name     string         `config:"name" default:"node"`
+	Next     *defaultNode   `config:"next"`
+	Children []*defaultNode `config:"children"`
+}
+
+type defaultServer struct {
+	Primary   defaultEndpoint `config:"primary"`
+	Secondary defaultEndpoint `config:"secondary"`
+	Node      *defaultNode    `config:"node"`
+}
+
+type defaultEndpoint struct {
+	Port int `config:"port" default:"80"`
+}
+
+func TestSetDefaultsFromStructCycle(t *testing.T) {
+	c, err := FromString("a: 1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetDefaultsFromStruct(&defaultServer{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key  string
+		want interface{}
+	}{
+		{"primary.port", 80},
+		{"secondary.port", 80},
+		{"node.name", "node"},
+	}
+	for _, tt := range tests {
+		if got, err := c.Get(tt.key); err != nil || got != tt.want {
+			t.Errorf("Get(%q) = %v, %v, want %v", tt.key, got, err, tt.want)
+		}
+	}
+	if c.Has("node.next") {
+		t.Error("Has(\"node.next\") = true, want false")
+	}
+}
+
+func TestSetDefaultQuotedKey(t *testing.T) {
+	c, err := FromString("old: {\"a.b\": 1}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetDefault(`labels."app.kubernetes.io/name"`, "web")
+	if got, err := c.GetString(`labels."app.kubernetes.io/name"`); err != nil || got != "web" {
+		t.Errorf("GetString(labels.\"app.kubernetes.io/name\") = %q, %v, want web", got, err)
+	}
+
+	c.DeprecateKey(`old."a.b"`, `new."a.b"`)
+	if got, err := c.GetInt(`new."a.b"`); err != nil || got != 1 {
+		t.Errorf("GetInt(new.\"a.b\") = %d, %v, want 1", got, err)
+	}
+	if c.Has("new.a") {
+		t.Error(`Has("new.a") = true, want the key mapped to new."a.b"`)
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+type keyMapping struct {
+	oldKey string
+	newKey string
+	alias  bool // 别名不输出警告
+}
+
+// DeprecateKey marks oldKey as renamed to newKey. Reads of newKey fall back
+// to the value of oldKey, and a warning is logged if oldKey is used.
+// The mapping is dropped and logged if the config with it fails the checks
+// of reloading, see WithSchema.
+// Support multi-level key which concat with '.'.
+func (c *Config) DeprecateKey(oldKey, newKey string) {
+	c.addMapping(keyMapping{oldKey, newKey, false})
+}
+
+// Alias makes reads of newKey fall back to the value of oldKey, like
+// DeprecateKey but without warnings, so that configs can be restructured
+// while old config files keep working.
+// Support multi-level key which concat with '.'.
+func (c *Config) Alias(newKey, oldKey string) {
+	c.addMapping(keyMapping{oldKey, newKey, true})
+}
+
+func (c *Config) addMapping(mapping keyMapping) {
+	_, err := c.parseKey(mapping.oldKey)
+	if err == nil {
+		_, err = c.parseKey(mapping.newKey)
+	}
+	if err != nil {
+		c.log().Printf("mapping of key `%s` to `%s` is rejected: %s", mapping.oldKey, mapping.newKey, err)
+		return
+	}
+	_, err = c.update("rename "+mapping.oldKey, func() (func(), error) {
+		old := c.deprecated
+		c.deprecated = append(c.deprecated[:len(c.deprecated):len(c.deprecated)], mapping)
+		return func() {
+			c.deprecated = old
+		}, nil
+	})
+	if err != nil {
+		c.log().Printf("mapping of key `%s` to `%s` is rejected: %s", mapping.oldKey, mapping.newKey, err)
+	}
+}
+
+// applyDeprecations copies the values of deprecated keys to their new keys
+// in a layer, unless the new key is already set in that layer.
+func (c *Config) applyDeprecations(data map[interface{}]interface{}) map[interface{}]interface{} {
+	if len(c.deprecated) == 0 || data == nil {
+		return data
+	}
+
+	var mapped map[interface{}]interface{}
+	for _, mapping := range c.deprecated {
+		// 支持带引号的key，addMapping已检查过格式
+		oldArr, _ := c.parseKey(mapping.oldKey)
+		newArr, _ := c.parseKey(mapping.newKey)
+		v, ok := lookupKeys(data, oldArr)
+		if !ok {
+			continue
+		}
+		if !mapping.alias {
+			c.warnDeprecated(mapping)
+		}
+		if _, ok := lookupKeys(data, newArr); ok {
+			continue
+		}
+
+		// 复制一份，不修改原layer
+		if mapped == nil {
+			mapped = make(map[interface{}]interface{}, len(data))
+			c.newMerger().merge(mapped, data)
+		}
+		if _, err := setKeys(mapped, newArr, v, c.Delimiter); err != nil {
+			c.log().Printf("key `%s` can not be mapped to `%s`: %s", mapping.oldKey, mapping.newKey, err)
+		}
+	}
+
+	if mapped == nil {
+		return data
+	}
+	return mapped
+}
+
+func (c *Config) warnDeprecated(mapping keyMapping) {
+	if c.deprecatedWarned == nil {
+		c.deprecatedWarned = make(map[string]bool)
+	}
+	if c.deprecatedWarned[mapping.oldKey] {
+		return
+	}
+	c.deprecatedWarned[mapping.oldKey] = true
+
+	msg := "key `" + mapping.oldKey + "` is deprecated, use `" + mapping.newKey + "` instead"
+	c.warn(Warning{Kind: WarningDeprecatedKey, Key: mapping.oldKey, Message: msg})
+	c.log().Printf("%s", msg)
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testLogger records the messages logged.
+type testLogger struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (l *testLogger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.msgs = append(l.msgs, fmt.Sprintf(format, v...))
+}
+
+func (l *testLogger) messages() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]string(nil), l.msgs...)
+}
+
+func TestDeprecateKey(t *testing.T) {
+	logger := &testLogger{}
+	c, err := FromString("db: {addr: localhost}\nport: 8080\n", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.DeprecateKey("db.addr", "db.host")
+	c.DeprecateKey("port", "server.port")
+	c.DeprecateKey("user", "db.user")
+
+	if got, err := c.GetString("db.host"); err != nil || got != "localhost" {
+		t.Errorf("GetString(db.host) = %q, %v, want localhost", got, err)
+	}
+	if got, err := c.GetInt("server.port"); err != nil || got != 8080 {
+		t.Errorf("GetInt(server.port) = %d, %v, want 8080", got, err)
+	}
+	if c.Has("db.user") {
+		t.Error("Has(db.user) = true for an unset deprecated key")
+	}
+	msgs := strings.Join(logger.messages(), "\n")
+	if !strings.Contains(msgs, "db.addr") || !strings.Contains(msgs, "port") {
+		t.Errorf("logged %q, want warnings of db.addr and port", msgs)
+	}
+	warned := make(map[string]bool)
+	for _, w := range c.Warnings() {
+		if w.Kind == WarningDeprecatedKey {
+			warned[w.Key] = true
+		}
+	}
+	if !warned["db.addr"] || !warned["port"] {
+		t.Errorf("Warnings() = %v, want warnings of db.addr and port", c.Warnings())
+	}
+
+	// 新key已设置时不使用旧key
+	c, err = FromString("db: {addr: old, host: new}\n", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.DeprecateKey("db.addr", "db.host")
+	if got, _ := c.GetString("db.host"); got != "new" {
+		t.Errorf("GetString(db.host) = %q, want new", got)
+	}
+}
+
+func TestAlias(t *testing.T) {
+	logger := &testLogger{}
+	c, err := FromString("listen: \":8080\"\n", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Alias("server.addr", "listen")
+	if got, err := c.GetString("server.addr"); err != nil || got != ":8080" {
+		t.Errorf("GetString(server.addr) = %q, %v, want :8080", got, err)
+	}
+	if msgs := logger.messages(); len(msgs) != 0 {
+		t.Errorf("Alias logged %q, want nothing", msgs)
+	}
+	for _, w := range c.Warnings() {
+		if w.Kind == WarningDeprecatedKey {
+			t.Errorf("Warnings() = %v, want no warning of an alias", c.Warnings())
+		}
+	}
+
+	c.Alias("[0]", "listen")
+	if msgs := logger.messages(); len(msgs) != 1 || !strings.Contains(msgs[0], "rejected") {
+		t.Errorf("Alias of an invalid key logged %q, want a rejection", msgs)
+	}
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+)
+
+// ChangeType is the kind of a Change.
+type ChangeType string
+
+// Kinds of changes reported by Diff.
+const (
+	ChangeAdded    ChangeType = "added"
+	ChangeRemoved  ChangeType = "removed"
+	ChangeModified ChangeType = "modified"
+)
+
+// Change is a leaf value that differs between two configs.
+type Change struct {
+	Key      string
+	Type     ChangeType
+	OldValue interface{}
+	NewValue interface{}
+}
+
+// Diff returns the leaf values added, removed and modified in other compared
+// with c, sorted by key. The secrets marked by MarkSecret of either config
+// are redacted.
+func (c *Config) Diff(other *Config) []Change {
+	return other.redactChanges(c.redactChanges(diffTrees(c.data(), other.data(), c.Delimiter)))
+}
+
+func diffTrees(oldData, newData map[interface{}]interface{}, delimiter string) []Change {
+	oldValues := leafValues(oldData, delimiter)
+	newValues := leafValues(newData, delimiter)
+
+	changes := make([]Change, 0)
+	for key, oldV := range oldValues {
+		newV, ok := newValues[key]
+		if !ok {
+			changes = append(changes, Change{key, ChangeRemoved, oldV, nil})
+		} else if !reflect.DeepEqual(oldV, newV) {
+			changes = append(changes, Change{key, ChangeModified, oldV, newV})
+		}
+	}
+	for key, newV := range newValues {
+		if _, ok := oldValues[key]; !ok {
+			changes = append(changes, Change{key, ChangeAdded, nil, newV})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+func leafValues(data map[interface{}]interface{}, delimiter string) map[string]interface{} {
+	values := make(map[string]interface{})
+	walkLeaves(data, "", delimiter, func(key string, v interface{}) error {
+		values[key] = v
+		return nil
+	})
+	return values
+}
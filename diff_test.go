@@ -0,0 +1,32 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	oldC, err := FromString("db: {host: localhost, port: 3306, password: a}\nhosts: [a, b]\nname: app\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newC, err := FromString("db: {host: db, port: 3306, password: b}\nhosts: [a]\nlevel: debug\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newC.MarkSecret("db.password")
+
+	want := []Change{
+		{Key: "db.host", Type: ChangeModified, OldValue: "localhost", NewValue: "db"},
+		{Key: "db.password", Type: ChangeModified, OldValue: redactedValue, NewValue: redactedValue},
+		{Key: "hosts[1]", Type: ChangeRemoved, OldValue: "b"},
+		{Key: "level", Type: ChangeAdded, NewValue: "debug"},
+		{Key: "name", Type: ChangeRemoved, OldValue: "app"},
+	}
+	if got := oldC.Diff(newC); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+	if got := oldC.Diff(oldC); len(got) != 0 {
+		t.Errorf("Diff() of the same config = %+v, want no change", got)
+	}
+}
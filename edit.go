@@ -0,0 +1,399 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// edit is a change made at runtime, edits are replayed in order on the
+// merged layers every time the config is rebuilt.
+type edit func(data map[interface{}]interface{}, sources map[string]SourceInfo)
+
+// Delete removes a given key, elements of slices can be removed by index.
+// Support multi-level key which concat with '.'.
+func (c *Config) Delete(key string) error {
+	return c.deleteKey(key, false)
+}
+
+// DeleteAndPrune removes a given key like Delete, then removes the parent
+// maps left empty.
+func (c *Config) DeleteAndPrune(key string) error {
+	return c.deleteKey(key, true)
+}
+
+func (c *Config) deleteKey(key string, prune bool) error {
+	if err := c.loadIncludes(key); err != nil {
+		return err
+	}
+
+	keyArr, err := c.parseKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = c.update("delete "+key, func() (func(), error) {
+		if _, ok := lookupKeys(c.current().data, keyArr); !ok {
+			return nil, notFoundError(key)
+		}
+		return c.addEdits(func(data map[interface{}]interface{}, sources map[string]SourceInfo) {
+			deleteKeys(data, keyArr, prune)
+		}), nil
+	})
+	return err
+}
+
+// addEdits appends edits to the config, and returns the func removing them.
+func (c *Config) addEdits(edits ...edit) func() {
+	old := c.edits
+	c.edits = append(c.edits[:len(c.edits):len(c.edits)], edits...)
+	return func() {
+		c.edits = old
+	}
+}
+
+// deleteKeys removes the value at the parsed key from node, and returns the
+// node after deletion. Only node itself is changed, the maps and slices
+// along the key are copied, since they may be shared with the snapshots
+// being read.
+func deleteKeys(node interface{}, keyArr []interface{}, prune bool) (interface{}, bool) {
+	switch key := keyArr[0].(type) {
+	case string:
+		tMap, ok := node.(map[interface{}]interface{})
+		if !ok {
+			return node, false
+		}
+		child, ok := tMap[key]
+		if !ok {
+			return node, false
+		}
+		if len(keyArr) == 1 {
+			delete(tMap, key)
+			return tMap, true
+		}
+		if child, ok = deleteKeys(cloneNode(child), keyArr[1:], prune); !ok {
+			return node, false
+		}
+		if m, isMap := child.(map[interface{}]interface{}); prune && isMap && len(m) == 0 {
+			delete(tMap, key)
+		} else {
+			tMap[key] = child
+		}
+		return tMap, true
+
+	case int:
+		tSlice, ok := node.([]interface{})
+		if !ok {
+			return node, false
+		}
+		index, ok := sliceIndex(key, len(tSlice))
+		if !ok {
+			return node, false
+		}
+		if len(keyArr) == 1 {
+			result := make([]interface{}, 0, len(tSlice)-1)
+			result = append(result, tSlice[:index]...)
+			return append(result, tSlice[index+1:]...), true
+		}
+		child, ok := deleteKeys(cloneNode(tSlice[index]), keyArr[1:], prune)
+		if !ok {
+			return node, false
+		}
+		tSlice[index] = child
+		return tSlice, true
+	}
+	return node, false
+}
+
+// setEdit returns an edit setting the value of a parsed key, the value is
+// copied so that later changes of the caller don't affect the config.
+func (c *Config) setEdit(keyArr []interface{}, value interface{}, source SourceInfo) edit {
+	value = copyTree(value)
+	return func(data map[interface{}]interface{}, sources map[string]SourceInfo) {
+		key, err := setKeys(data, keyArr, value, c.Delimiter)
+		if err != nil {
+			return
+		}
+		m := &merger{delimiter: c.Delimiter, source: source, sources: sources}
+		m.record(key)
+	}
+}
+
+// copyData returns a deep copy of the effective config.
+func (c *Config) copyData() map[interface{}]interface{} {
+	cur := c.current().data
+	data := make(map[interface{}]interface{}, len(cur))
+	c.newMerger().merge(data, cur)
+	return data
+}
+
+// setKeys sets the value at the parsed key, missing maps are created and
+// an index equal to the length of a slice appends to it. It returns the
+// key with resolved indexes. Only data itself is changed, the maps and
+// slices along the key are copied like deleteKeys.
+func setKeys(data map[interface{}]interface{}, keyArr []interface{}, value interface{}, delimiter string) (string, error) {
+	var key string
+	_, err := setIn(data, keyArr, value, "", delimiter, &key)
+	return key, err
+}
+
+func setIn(node interface{}, keyArr []interface{}, value interface{}, pKey, delimiter string, key *string) (interface{}, error) {
+	switch k := keyArr[0].(type) {
+	case string:
+		cKey := joinKey(pKey, k, delimiter)
+		tMap, ok := node.(map[interface{}]interface{})
+		if !ok {
+			tMap = make(map[interface{}]interface{})
+		}
+		if len(keyArr) == 1 {
+			tMap[k] = value
+			*key = cKey
+			return tMap, nil
+		}
+		child, err := setIn(cloneNode(tMap[k]), keyArr[1:], value, cKey, delimiter, key)
+		if err != nil {
+			return node, err
+		}
+		tMap[k] = child
+		return tMap, nil
+
+	case int:
+		tSlice, ok := node.([]interface{})
+		if !ok && node != nil {
+			return node, nodeError(pKey, ErrNotASlice, node)
+		}
+		index := k
+		if k != len(tSlice) {
+			if index, ok = sliceIndex(k, len(tSlice)); !ok {
+				return node, indexError(pKey, k, len(tSlice))
+			}
+		}
+		cKey := fmt.Sprintf("%s[%d]", pKey, index)
+
+		var child interface{}
+		if index < len(tSlice) {
+			child = tSlice[index]
+		}
+		if len(keyArr) == 1 {
+			child = value
+			*key = cKey
+		} else {
+			var err error
+			if child, err = setIn(cloneNode(child), keyArr[1:], value, cKey, delimiter, key); err != nil {
+				return node, err
+			}
+		}
+
+		result := make([]interface{}, len(tSlice), len(tSlice)+1)
+		copy(result, tSlice)
+		if index == len(tSlice) {
+			return append(result, child), nil
+		}
+		result[index] = child
+		return result, nil
+	}
+	return node, ErrKeyFormat
+}
+
+// SetWithSource sets the value of a given key at the highest precedence,
+// source is reported by Source and Explain. It is used by the packages
+// binding other sources, such as command line flags.
+func (c *Config) SetWithSource(key string, value interface{}, source SourceInfo) error {
+	if err := c.loadIncludes(key); err != nil {
+		return err
+	}
+
+	keyArr, err := c.parseKey(key)
+	if err != nil {
+		return err
+	}
+	value = normalizeValue(value)
+	_, err = c.update("set "+key, func() (func(), error) {
+		if _, err := setKeys(c.copyData(), keyArr, value, c.Delimiter); err != nil {
+			return nil, err
+		}
+		return c.addEdits(c.setEdit(keyArr, value, source)), nil
+	})
+	return err
+}
+
+// SetFromStruct sets the fields of a struct under prefix at the highest
+// precedence, the keys are given by the `config` tags as in Unmarshal, so
+// that a section can be set with the type it is read into. Keys under prefix
+// that are not set by the struct are kept, nil fields are not set.
+// An empty prefix sets the fields as top level keys.
+func (c *Config) SetFromStruct(prefix string, v interface{}) error {
+	if err := c.loadIncludes(prefix); err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return errors.New("config should be set from a struct")
+	}
+
+	var prefixArr []interface{}
+	if prefix != "" {
+		var err error
+		if prefixArr, err = c.parseKey(prefix); err != nil {
+			return err
+		}
+	}
+	// time.Time等实现了TextMarshaler的struct转换为字符串
+	fields, ok := normalizeValue(rv.Interface()).(map[interface{}]interface{})
+	if !ok {
+		return errors.New("config should be set from a struct of fields, got `" + rv.Type().String() + "`")
+	}
+
+	_, err := c.update("set "+rv.Type().String(), func() (func(), error) {
+		return c.setFields(prefixArr, fields, SourceInfo{LayerOverride, rv.Type().String()})
+	})
+	return err
+}
+
+// setFields adds the edits setting the fields of a struct under prefixArr,
+// and returns the func undoing them.
+func (c *Config) setFields(prefixArr []interface{}, fields map[interface{}]interface{}, source SourceInfo) (func(), error) {
+	// 在副本上依次检查
+	data := c.copyData()
+	var edits []edit
+	var setMap func(keyArr []interface{}, m map[interface{}]interface{}) error
+	setMap = func(keyArr []interface{}, m map[interface{}]interface{}) error {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k.(string))
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			cKeyArr := append(append(make([]interface{}, 0, len(keyArr)+1), keyArr...), k)
+			// 非空的struct和map逐个设置子key
+			if child, ok := m[k].(map[interface{}]interface{}); ok && len(child) > 0 && stringKeys(child) {
+				if err := setMap(cKeyArr, child); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := setKeys(data, cKeyArr, m[k], c.Delimiter); err != nil {
+				return err
+			}
+			edits = append(edits, c.setEdit(cKeyArr, m[k], source))
+		}
+		return nil
+	}
+	if err := setMap(prefixArr, fields); err != nil {
+		return nil, err
+	}
+	return c.addEdits(edits...), nil
+}
+
+// stringKeys returns whether all keys of a map are strings.
+func stringKeys(m map[interface{}]interface{}) bool {
+	for k := range m {
+		if _, ok := k.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ApplyOverrides sets keys from `key=value` strings, such as `server.port=9090`
+// or `tags[0]=prod`, at the highest precedence. Values are converted to
+// int, float64 or bool if possible, quoted values are always strings.
+// No override is applied if any of them is invalid.
+func (c *Config) ApplyOverrides(pairs []string) error {
+	if err := c.loadIncludes(""); err != nil {
+		return err
+	}
+
+	_, err := c.update("override", func() (func(), error) {
+		return c.applyOverrides(pairs)
+	})
+	return err
+}
+
+// applyOverrides adds the edits setting the overrides, and returns the func
+// undoing them.
+func (c *Config) applyOverrides(pairs []string) (func(), error) {
+	// 在副本上依次检查
+	data := c.copyData()
+	edits := make([]edit, 0, len(pairs))
+	for _, pair := range pairs {
+		pos := strings.IndexByte(pair, '=')
+		if pos <= 0 {
+			return nil, errors.New("invalid override `" + pair + "`, should be key=value")
+		}
+		keyArr, err := c.parseKey(strings.TrimSpace(pair[:pos]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid override `%s`: %w", pair, err)
+		}
+		value := parseScalar(pair[pos+1:])
+		if _, err := setKeys(data, keyArr, value, c.Delimiter); err != nil {
+			return nil, fmt.Errorf("invalid override `%s`: %w", pair, err)
+		}
+		edits = append(edits, c.setEdit(keyArr, value, SourceInfo{LayerOverride, pair}))
+	}
+	return c.addEdits(edits...), nil
+}
+
+// parseScalar infers the type of a value given as string. Only decimal
+// numbers are converted, values such as `nan`, `inf` or `0x1p4` are kept as
+// strings.
+func parseScalar(str string) interface{} {
+	if len(str) >= 2 && (str[0] == '"' || str[0] == '\'') && str[len(str)-1] == str[0] {
+		return str[1 : len(str)-1]
+	}
+	if i, err := strconv.Atoi(str); err == nil {
+		return i
+	}
+	if decimalNumber(str) {
+		if f, err := strconv.ParseFloat(str, 64); err == nil {
+			return f
+		}
+	}
+	switch str {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return str
+}
+
+// decimalNumber returns whether s is a decimal number with an optional sign,
+// fraction and exponent, such as `-1.5e3`.
+func decimalNumber(s string) bool {
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	digits := 0
+	for ; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+		digits++
+	}
+	if i < len(s) && s[i] == '.' {
+		for i++; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+			digits++
+		}
+	}
+	if digits == 0 {
+		return false
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		start := i
+		for ; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+		}
+		if i == start {
+			return false
+		}
+	}
+	return i == len(s)
+}
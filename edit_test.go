@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type textStruct struct {
+	Name string
+}
+
+func (s textStruct) MarshalText() ([]byte, error) {
+	return []byte(s.Name), nil
+}
+
+func TestSetFromStructNotFields(t *testing.T) {
+	c, err := FromString("a: 1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{"time.Time", time.Now()},
+		{"pointer to time.Time", &time.Time{}},
+		{"TextMarshaler", textStruct{Name: "x"}},
+	}
+	for _, tt := range tests {
+		if err := c.SetFromStruct("t", tt.v); err == nil {
+			t.Errorf("SetFromStruct(%s) error = nil, want an error", tt.name)
+		}
+	}
+	if c.Has("t") {
+		t.Error("Has(\"t\") = true after failed SetFromStruct, want false")
+	}
+}
+
+func TestParseScalar(t *testing.T) {
+	tests := []struct {
+		str  string
+		want interface{}
+	}{
+		{"8080", 8080},
+		{"-3", -3},
+		{"1.5", 1.5},
+		{"-.5", -0.5},
+		{"2.", 2.0},
+		{"1e3", 1000.0},
+		{"1.5E-2", 0.015},
+		{"true", true},
+		{"false", false},
+		{`"8080"`, "8080"},
+		{"'true'", "true"},
+		{"nan", "nan"},
+		{"NaN", "NaN"},
+		{"inf", "inf"},
+		{"-Infinity", "-Infinity"},
+		{"0x1p4", "0x1p4"},
+		{"0x10", "0x10"},
+		{"1_000", "1_000"},
+		{"1e", "1e"},
+		{".", "."},
+		{"1e999", "1e999"},
+		{"localhost", "localhost"},
+	}
+	for _, tt := range tests {
+		if got := parseScalar(tt.str); got != tt.want {
+			t.Errorf("parseScalar(%q) = %#v, want %#v", tt.str, got, tt.want)
+		}
+	}
+}
+
+func TestTreeFromKeysScalars(t *testing.T) {
+	tree := TreeFromKeys(map[string]string{"a_port": "8080", "a_ratio": "0.5", "a_name": "Infinity"}, "_")
+	a, _ := tree["a"].(map[interface{}]interface{})
+	if a["port"] != 8080 || a["ratio"] != 0.5 || a["name"] != "Infinity" {
+		t.Errorf("TreeFromKeys() = %#v, want port 8080, ratio 0.5 and name \"Infinity\"", tree)
+	}
+}
+
+func TestEditValidated(t *testing.T) {
+	c, err := FromString("server: {port: 8080}\nname: app\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.RegisterValidator("server.port", func(v interface{}) error {
+		if port, ok := v.(int); !ok || port <= 0 {
+			return errors.New("should be a positive number")
+		}
+		return nil
+	})
+	var changes []Change
+	c.OnChange(func(cs []Change) {
+		changes = append(changes, cs...)
+	})
+
+	edits := []struct {
+		name string
+		fn   func() error
+	}{
+		{"SetWithSource", func() error { return c.SetWithSource("server.port", -1, SourceInfo{}) }},
+		{"ApplyOverrides", func() error { return c.ApplyOverrides([]string{"server.port=0"}) }},
+		{"ApplyMergePatch", func() error { return c.ApplyMergePatch([]byte(`{"server": {"port": "x"}}`)) }},
+		{"ApplyJSONPatch", func() error {
+			return c.ApplyJSONPatch([]byte(`[{"op": "remove", "path": "/server/port"}, {"op": "add", "path": "/server/port", "value": -2}]`))
+		}},
+	}
+	for _, tt := range edits {
+		if err := tt.fn(); err == nil {
+			t.Errorf("%s() error = nil, want the validation error", tt.name)
+		}
+		if port, err := c.GetInt("server.port"); err != nil || port != 8080 {
+			t.Errorf("GetInt(server.port) after %s = %d, %v, want 8080", tt.name, port, err)
+		}
+	}
+	if len(changes) != 0 {
+		t.Errorf("changes = %v, want none for the rejected edits", changes)
+	}
+
+	if err := c.SetWithSource("server.port", 9090, SourceInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Key != "server.port" {
+		t.Errorf("changes = %v, want the change of server.port", changes)
+	}
+	c.SetDefault("server.port", "x")
+	if port, err := c.GetInt("server.port"); err != nil || port != 9090 {
+		t.Errorf("GetInt(server.port) after SetDefault = %d, %v, want 9090", port, err)
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"os"
+)
+
+// WithEnvOverride overrides the keys of the config by environment variables
+// named after them with a prefix, such as APP_SERVER_PORT for `server.port`
+// and APP_SERVERS_0_HOST for `servers[0].host` with the prefix APP. The
+// variables are named as by ToEnv. Values are
+// converted to int, float64 or bool if possible, quoted values are always
+// strings. Only the keys existing in the config can be overridden, the
+// environment variables take precedence over the config files and remote
+// sources, but not over command line flags and the changes made at runtime.
+func WithEnvOverride(prefix string) Option {
+	return func(c *Config) {
+		c.envPrefix = prefix
+	}
+}
+
+// applyEnv sets the keys overridden by environment variables.
+func (c *Config) applyEnv(data map[interface{}]interface{}, sources map[string]SourceInfo) {
+	if c.envPrefix == "" {
+		return
+	}
+
+	type override struct {
+		keyArr []interface{}
+		name   string
+		value  string
+	}
+	var overrides []override
+	walkLeaves(data, "", c.Delimiter, func(key string, v interface{}) error {
+		keyArr, err := c.parseKey(key)
+		if err != nil {
+			return nil
+		}
+		name := envName(c.envPrefix) + "_" + envName(key)
+		if value, ok := os.LookupEnv(name); ok {
+			overrides = append(overrides, override{keyArr, name, value})
+		}
+		return nil
+	})
+
+	for _, o := range overrides {
+		m := &merger{delimiter: c.Delimiter, source: SourceInfo{LayerEnv, o.name}, sources: sources}
+		if key, err := setKeys(data, o.keyArr, parseScalar(o.value), c.Delimiter); err == nil {
+			m.record(key)
+		}
+	}
+}
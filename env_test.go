@@ -0,0 +1,62 @@
+package config
+
+import (
+	"context"
+	"flag"
+	"testing"
+)
+
+func TestWithEnvOverride(t *testing.T) {
+	t.Setenv("APP_SERVER_PORT", "9090")
+	t.Setenv("APP_SERVER_DEBUG", "true")
+	t.Setenv("APP_SERVERS_1_HOST", "b.internal")
+	t.Setenv("APP_NAME", `"42"`)
+	t.Setenv("APP_MISSING", "x")
+
+	c, err := FromString("server: {port: 8080, debug: false}\nservers: [{host: a}, {host: b}]\nname: app\n", WithEnvOverride("APP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]interface{}{
+		"server.port":     9090,
+		"server.debug":    true,
+		"servers[0].host": "a",
+		"servers[1].host": "b.internal",
+		"name":            "42",
+	}
+	for key, want := range tests {
+		if got, err := c.Get(key); err != nil || got != want {
+			t.Errorf("Get(%q) = %#v, %v, want %#v", key, got, err, want)
+		}
+	}
+	if c.Has("missing") {
+		t.Error("Has(missing) = true, want only the existing keys overridden")
+	}
+	if got, want := c.Source("server.port"), (SourceInfo{LayerEnv, "APP_SERVER_PORT"}); got != want {
+		t.Errorf("Source(server.port) = %v, want %v", got, want)
+	}
+
+	// 环境变量优先于配置源，但不优先于命令行参数
+	p := &flakyProvider{}
+	if err := c.AddRemote(context.Background(), "remote", p); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("APP_PORT", "7070")
+	if _, err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetInt("port"); err != nil || got != 7070 {
+		t.Errorf("GetInt(port) = %d, %v, want 7070 from the environment", got, err)
+	}
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.Int("port", 0, "")
+	if err := fs.Parse([]string{"-port=6060"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.BindFlagSet(fs, map[string]string{"port": "port"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetInt("port"); err != nil || got != 6060 {
+		t.Errorf("GetInt(port) = %d, %v, want 6060 from the flag", got, err)
+	}
+}
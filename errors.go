@@ -0,0 +1,120 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors reported by getters, wrapped in a *KeyError carrying the key.
+// Use errors.Is to check the kind of an error, and errors.As to get the
+// *KeyError.
+var (
+	ErrEmptyKey        = errors.New("key should not be empty")
+	ErrKeyFormat       = errors.New("wrong key format")
+	ErrKeyNotFound     = errors.New("key not found")
+	ErrTypeMismatch    = errors.New("type mismatch")
+	ErrNotAMap         = errors.New("not a map")
+	ErrNotASlice       = errors.New("not a slice")
+	ErrNotAMapOrSlice  = errors.New("not a map or slice")
+	ErrUnknownKey      = errors.New("unknown key")
+	ErrIndexOutOfRange = errors.New("index out of range")
+
+	// ErrNullValue is reported for a key set to null, it wraps
+	// ErrKeyNotFound as getters take null values as not set.
+	ErrNullValue = fmt.Errorf("%w: value is null", ErrKeyNotFound)
+)
+
+// KeyError is the error of a given key.
+type KeyError struct {
+	Key      string
+	Err      error  // one of the Err* sentinel errors
+	Expected string // the expected type, for ErrTypeMismatch
+	Actual   string // the actual type of the value
+	msg      string
+}
+
+func (e *KeyError) Error() string {
+	return e.msg
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+func notFoundError(key string) error {
+	return &KeyError{Key: key, Err: ErrKeyNotFound, msg: "key `" + key + "` is not exists"}
+}
+
+func nullError(key string) error {
+	return &KeyError{Key: key, Err: ErrNullValue, msg: "key `" + key + "` is null"}
+}
+
+func requiredError(key string) error {
+	return &KeyError{Key: key, Err: ErrKeyNotFound, msg: "key `" + key + "` is required"}
+}
+
+func unknownKeyError(key string) error {
+	return &KeyError{Key: key, Err: ErrUnknownKey, msg: "key `" + key + "` is unknown"}
+}
+
+func indexError(key string, index, n int) error {
+	return &KeyError{
+		Key: key,
+		Err: ErrIndexOutOfRange,
+		msg: fmt.Sprintf("index %d out of range for key `%s` (len %d)", index, key, n),
+	}
+}
+
+// typeError reports the value of a key is not of the expected type.
+func typeError(key, expected string, actual interface{}) error {
+	return &KeyError{
+		Key:      key,
+		Err:      ErrTypeMismatch,
+		Expected: expected,
+		Actual:   typeName(actual),
+		msg:      "value of `" + key + "` is not " + expected,
+	}
+}
+
+// elemTypeError reports a value in the list of a key is not of the expected type.
+func elemTypeError(key, expected string, actual interface{}) error {
+	return &KeyError{
+		Key:      key,
+		Err:      ErrTypeMismatch,
+		Expected: expected,
+		Actual:   typeName(actual),
+		msg:      "some value in key `" + key + "` is not " + expected,
+	}
+}
+
+// nodeError reports a key in the middle of a path can not contain sub keys.
+func nodeError(key string, err error, actual interface{}) error {
+	return &KeyError{
+		Key:    key,
+		Err:    err,
+		Actual: typeName(actual),
+		msg:    "key `" + key + "` is " + err.Error(),
+	}
+}
+
+// typeName returns the name of the type of a config value.
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case int:
+		return "int"
+	case float64:
+		return "float64"
+	case bool:
+		return "boolean"
+	case map[interface{}]interface{}:
+		return "map"
+	case []interface{}:
+		return "list"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,75 @@
+// Package etcdsource loads config from etcd v3, either a yaml document
+// stored in a single key or a tree of keys under a prefix.
+package etcdsource
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/go-apibox/config"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Source is a config.RemoteWatcher backed by etcd.
+type Source struct {
+	client *clientv3.Client
+	key    string
+	prefix bool
+}
+
+// New create a source reading the yaml document stored in key.
+func New(client *clientv3.Client, key string) *Source {
+	return &Source{client: client, key: key}
+}
+
+// NewPrefix create a source reading the keys under prefix, the rest of the
+// keys are split into config levels by slashes, such as `/app/db/port`
+// with prefix `/app/` for `db.port`.
+func NewPrefix(client *clientv3.Client, prefix string) *Source {
+	return &Source{client: client, key: prefix, prefix: true}
+}
+
+// Fetch implements config.RemoteProvider.
+func (s *Source) Fetch(ctx context.Context) (map[interface{}]interface{}, error) {
+	if !s.prefix {
+		resp, err := s.client.Get(ctx, s.key)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Kvs) == 0 {
+			return nil, errors.New("etcd key `" + s.key + "` not found")
+		}
+		return config.ParseYAML(resp.Kvs[0].Value, s.key)
+	}
+
+	resp, err := s.client.Get(ctx, s.key, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	kvs := make(map[string]string, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		kvs[strings.TrimPrefix(string(kv.Key), s.key)] = string(kv.Value)
+	}
+	return config.TreeFromKeys(kvs, "/"), nil
+}
+
+// Watch implements config.RemoteWatcher, the whole document or prefix is
+// fetched again on every change.
+func (s *Source) Watch(ctx context.Context, update func(map[interface{}]interface{})) error {
+	var opts []clientv3.OpOption
+	if s.prefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+	for resp := range s.client.Watch(ctx, s.key, opts...) {
+		if err := resp.Err(); err != nil {
+			return err
+		}
+		data, err := s.Fetch(ctx)
+		if err != nil {
+			return err
+		}
+		update(data)
+	}
+	return ctx.Err()
+}
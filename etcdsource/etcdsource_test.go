@@ -0,0 +1,158 @@
+package etcdsource
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// fakeKV is an in-memory clientv3.KV and clientv3.Watcher, only Get and
+// Watch are implemented.
+type fakeKV struct {
+	clientv3.KV
+	clientv3.Watcher
+
+	mu       sync.Mutex
+	values   map[string]string
+	watchers []chan clientv3.WatchResponse
+}
+
+func newFakeClient(values map[string]string) (*clientv3.Client, *fakeKV) {
+	kv := &fakeKV{values: values}
+	return &clientv3.Client{KV: kv, Watcher: kv}, kv
+}
+
+func (kv *fakeKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	prefix := len(clientv3.OpGet(key, opts...).RangeBytes()) > 0
+	keys := make([]string, 0, len(kv.values))
+	for k := range kv.values {
+		if k == key || (prefix && strings.HasPrefix(k, key)) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	resp := &clientv3.GetResponse{}
+	for _, k := range keys {
+		resp.Kvs = append(resp.Kvs, &mvccpb.KeyValue{Key: []byte(k), Value: []byte(kv.values[k])})
+	}
+	return resp, nil
+}
+
+func (kv *fakeKV) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	ch := make(chan clientv3.WatchResponse, 1)
+	kv.watchers = append(kv.watchers, ch)
+	go func() {
+		<-ctx.Done()
+		kv.mu.Lock()
+		defer kv.mu.Unlock()
+		close(ch)
+	}()
+	return ch
+}
+
+func (kv *fakeKV) put(key, value string) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	kv.values[key] = value
+	for _, ch := range kv.watchers {
+		ch <- clientv3.WatchResponse{}
+	}
+}
+
+func TestFetch(t *testing.T) {
+	client, _ := newFakeClient(map[string]string{
+		"/app/config":  "db: {host: localhost, port: 3306}\n",
+		"/app/db/host": "db1",
+		"/app/db/port": "3307",
+		"/other/key":   "x",
+	})
+	tests := []struct {
+		name    string
+		source  *Source
+		want    map[interface{}]interface{}
+		wantErr bool
+	}{
+		{
+			name:   "document",
+			source: New(client, "/app/config"),
+			want: map[interface{}]interface{}{
+				"db": map[interface{}]interface{}{"host": "localhost", "port": 3306},
+			},
+		},
+		{
+			name:   "prefix",
+			source: NewPrefix(client, "/app/db/"),
+			want:   map[interface{}]interface{}{"host": "db1", "port": 3307},
+		},
+		{
+			name:    "missing key",
+			source:  New(client, "/app/missing"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.source.Fetch(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Fetch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	client, kv := newFakeClient(map[string]string{"/app/port": "8080"})
+	s := NewPrefix(client, "/app/")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates := make(chan map[interface{}]interface{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Watch(ctx, func(data map[interface{}]interface{}) {
+			updates <- data
+		})
+	}()
+	// 等待Watch开始
+	for {
+		kv.mu.Lock()
+		n := len(kv.watchers)
+		kv.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	kv.put("/app/port", "9090")
+	select {
+	case data := <-updates:
+		if data["port"] != 9090 {
+			t.Errorf("update = %v, want port 9090", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update after the key changed")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Watch() error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Explain returns a human readable trace of how the value of a given key is
+// resolved: the layers having the key, the value that wins, and the type
+// conversions the getters apply to it.
+func (c *Config) Explain(key string) string {
+	if err := c.loadIncludes(key); err != nil {
+		c.log().Printf("%s", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "key `%s`:\n", key)
+
+	keyArr, err := c.parseKey(key)
+	if err != nil {
+		fmt.Fprintf(&buf, "  %s\n", err)
+		return buf.String()
+	}
+
+	// 按优先级从低到高列出含有该key的layer
+	var found []SourceInfo
+	var last interface{}
+	for _, l := range c.layers() {
+		data := make(map[interface{}]interface{})
+		m := &merger{arrayMerge: l.arrayMerge, delimiter: c.Delimiter, directives: l.directives}
+		m.merge(data, c.layerData(l))
+		if v, ok := lookupKeys(data, keyArr); ok {
+			fmt.Fprintf(&buf, "  %s: %s\n", l.source, formatValue(c.redact(v, keyArr)))
+			found = append(found, l.source)
+			last = v
+		}
+	}
+
+	snap := c.current()
+	v, ok := lookupKeys(snap.data, keyArr)
+	if !ok {
+		if len(found) == 0 {
+			buf.WriteString("  not set in any layer\n")
+		} else {
+			buf.WriteString("  => removed by a merge policy\n")
+		}
+		return buf.String()
+	}
+
+	shown := v
+	if snap.raw != nil {
+		rawV, _ := lookupKeys(snap.raw, keyArr)
+		shown = redactResolved(v, rawV)
+	}
+	shown = c.redact(shown, keyArr)
+	switch {
+	case len(found) == 0:
+		fmt.Fprintf(&buf, "  => %s, from %s\n", formatValue(shown), c.source(key))
+	case reflect.DeepEqual(v, last):
+		fmt.Fprintf(&buf, "  => %s, from %s (highest precedence)\n", formatValue(shown), found[len(found)-1])
+	case c.source(key).Layer == LayerEnv:
+		fmt.Fprintf(&buf, "  => %s, from %s\n", formatValue(shown), c.source(key))
+	default:
+		fmt.Fprintf(&buf, "  => %s, merged from the layers above\n", formatValue(shown))
+	}
+	fmt.Fprintf(&buf, "  type: %s\n", c.describeType(v))
+	return buf.String()
+}
+
+func formatValue(v interface{}) string {
+	if str, ok := v.(string); ok {
+		return strconv.Quote(str)
+	}
+	return fmt.Sprint(stringKeyed(v))
+}
+
+// describeType describes the type of a value and the conversions getters
+// of other types apply to it under the coercion policy of the config.
+func (c *Config) describeType(v interface{}) string {
+	var desc, conv string
+	switch vv := v.(type) {
+	case nil:
+		return "null, getters return ErrNullValue"
+	case string:
+		desc = "string"
+		if _, ok := c.stringToInt(vv); ok {
+			conv = "GetInt and GetFloat convert it to a number"
+		} else if _, ok := c.stringToFloat(vv); ok {
+			conv = "GetFloat converts it to a number"
+		} else if _, ok := c.stringToBool(vv); ok {
+			conv = "GetBool converts it to a boolean"
+		}
+	case int:
+		desc, conv = "int", "GetFloat converts it to float64"
+		if c.coercion.NumberToString {
+			conv += ", GetString converts it to a string"
+		}
+	case float64:
+		desc = "float64"
+		if c.coercion.NumberToString {
+			conv = "GetString converts it to a string"
+		}
+	case bool:
+		return "bool"
+	case map[interface{}]interface{}:
+		return "map"
+	case []interface{}:
+		return "list"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+	if conv == "" {
+		return desc
+	}
+	return desc + ", " + conv
+}
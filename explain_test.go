@@ -0,0 +1,104 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExplainType(t *testing.T) {
+	yaml := "port: \"8080\"\nratio: \"0.5\"\ndebug: \"yes\"\nname: ~\ncount: 3\n"
+	tests := []struct {
+		name string
+		opts []Option
+		key  string
+		want string
+	}{
+		{name: "number", key: "port", want: "type: string, GetInt and GetFloat convert it to a number\n"},
+		{name: "float", key: "ratio", want: "type: string, GetFloat converts it to a number\n"},
+		{name: "extended bool", key: "debug", want: "type: string\n"},
+		{name: "null", key: "name", want: "type: null, getters return ErrNullValue\n"},
+		{name: "int", key: "count", want: "type: int, GetFloat converts it to float64\n"},
+		{name: "strict", opts: []Option{WithStrictTypes()}, key: "port", want: "type: string\n"},
+		{
+			name: "coercion",
+			opts: []Option{WithCoercion(CoercionPolicy{ExtendedBool: true, NumberToString: true})},
+			key:  "debug",
+			want: "type: string, GetBool converts it to a boolean\n",
+		},
+		{
+			name: "number to string",
+			opts: []Option{WithCoercion(CoercionPolicy{NumberToString: true})},
+			key:  "count",
+			want: "type: int, GetFloat converts it to float64, GetString converts it to a string\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := FromString(yaml, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.Explain(tt.key); !strings.HasSuffix(got, tt.want) {
+				t.Errorf("Explain(%q) = %q, want the suffix %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExplain(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(file, []byte("server: {port: 8080}\ndb: {password: s3cret}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := FromFile(file, WithDefaults([]byte("server: {port: 80, host: localhost}\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.MarkSecret("db.password")
+
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{
+			key: "server.port",
+			want: []string{
+				"key `server.port`:\n",
+				"  defaults: 80\n",
+				"  file " + file + ": 8080\n",
+				"  => 8080, from file " + file + " (highest precedence)\n",
+				"  type: int",
+			},
+		},
+		{
+			key:  "server.host",
+			want: []string{"  defaults: \"localhost\"\n", "  => \"localhost\", from defaults (highest precedence)\n"},
+		},
+		{
+			key:  "server",
+			want: []string{"  => map[host:localhost port:8080], merged from the layers above\n", "  type: map"},
+		},
+		{
+			key:  "db.password",
+			want: []string{"  => \"***\""},
+		},
+		{
+			key:  "server.user",
+			want: []string{"  not set in any layer\n"},
+		},
+	}
+	for _, tt := range tests {
+		got := c.Explain(tt.key)
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("Explain(%q) = %q, want it to contain %q", tt.key, got, want)
+			}
+		}
+		if strings.Contains(got, "s3cret") {
+			t.Errorf("Explain(%q) = %q, want the secret redacted", tt.key, got)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ToYAML returns the effective config encoded as yaml, the secrets marked
+// by MarkSecret and the decrypted or resolved values are redacted.
+func (c *Config) ToYAML() ([]byte, error) {
+	return yaml.Marshal(c.exported(c.snap()))
+}
+
+// Checksum returns a hash of the effective config, it changes only if the
+// config changes, so that it can be reported as the version of the config,
+// such as in health checks, or used as an ETag.
+func (c *Config) Checksum() string {
+	snap := c.snap()
+	snap.sumOnce.Do(func() {
+		snap.sum = checksum(snap.data)
+	})
+	return snap.sum
+}
+
+// ChecksumOf returns a hash of the value of a given key like Checksum, so
+// that a component can detect the changes of its own section.
+// Support multi-level key which concat with '.'.
+func (c *Config) ChecksumOf(key string) (string, error) {
+	v, err := c.Get(key)
+	if err != nil {
+		return "", err
+	}
+	return checksum(v), nil
+}
+
+// ToJSON returns the effective config encoded as json, the secrets marked
+// by MarkSecret and the decrypted or resolved values are redacted.
+func (c *Config) ToJSON() ([]byte, error) {
+	return json.Marshal(stringKeyed(c.exported(c.snap())))
+}
+
+// ToEnv returns the effective config as environment variables, such as
+// `PREFIX_SERVER_PORT=8080`. Elements of slices are suffixed by their index,
+// null and empty values are exported as empty strings. The values are not
+// quoted, so that they can be used as the environment of a child process.
+// The secrets marked by MarkSecret and the decrypted or resolved values
+// are redacted.
+func (c *Config) ToEnv(prefix string) []string {
+	envs := make([]string, 0)
+	walkLeaves(c.exported(c.snap()), "", c.Delimiter, func(key string, v interface{}) error {
+		name := envName(key)
+		if prefix != "" {
+			name = envName(prefix) + "_" + name
+		}
+
+		var value string
+		switch vv := v.(type) {
+		case nil, map[interface{}]interface{}, []interface{}:
+		default:
+			value = fmt.Sprint(vv)
+		}
+		envs = append(envs, name+"="+value)
+		return nil
+	})
+	return envs
+}
+
+// envName converts a key path to an environment variable name.
+func envName(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == ']':
+			return -1
+		default:
+			return '_'
+		}
+	}, key)
+}
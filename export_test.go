@@ -0,0 +1,105 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestToEnv(t *testing.T) {
+	c, err := FromString("db: {host: localhost, password: secret}\nhosts: [a, b]\nempty: ~\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MarkSecret("db.password"); err != nil {
+		t.Fatal(err)
+	}
+
+	envs := c.ToEnv("app")
+	sort.Strings(envs)
+	want := []string{"APP_DB_HOST=localhost", "APP_DB_PASSWORD=***", "APP_EMPTY=", "APP_HOSTS_0=a", "APP_HOSTS_1=b"}
+	if !reflect.DeepEqual(envs, want) {
+		t.Errorf("ToEnv() = %v, want %v", envs, want)
+	}
+}
+
+func TestToYAMLAndJSON(t *testing.T) {
+	c, err := FromString("db: {host: localhost, password: secret}\nhosts: [a, b]\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MarkSecret("db.password"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"db":    map[string]interface{}{"host": "localhost", "password": "***"},
+		"hosts": []interface{}{"a", "b"},
+	}
+
+	b, err := c.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ToJSON() = %s, want %v", b, want)
+	}
+
+	b, err = c.ToYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[interface{}]interface{}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if got := stringKeyed(raw); !reflect.DeepEqual(got, want) {
+		t.Errorf("ToYAML() = %s, want %v", b, want)
+	}
+	if strings.Contains(string(b), "secret") {
+		t.Errorf("ToYAML() = %s, want the secret redacted", b)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	c, err := FromString("db: {host: localhost}\nname: app\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := c.Checksum()
+	db, err := c.ChecksumOf("db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum == "" || sum == db {
+		t.Errorf("Checksum() = %q, ChecksumOf(db) = %q, want different hashes", sum, db)
+	}
+	same, err := FromString("name: app\ndb: {host: localhost}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := same.Checksum(); got != sum {
+		t.Errorf("Checksum() of the same config = %q, want %q", got, sum)
+	}
+
+	// 只有修改的部分的hash改变
+	if err := c.SetWithSource("name", "api", SourceInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Checksum(); got == sum {
+		t.Error("Checksum() is not changed after SetWithSource")
+	}
+	if got, _ := c.ChecksumOf("db"); got != db {
+		t.Errorf("ChecksumOf(db) = %q after changing name, want %q", got, db)
+	}
+	if _, err := c.ChecksumOf("missing"); err == nil {
+		t.Error("ChecksumOf() error = nil for a missing key, want an error")
+	}
+}
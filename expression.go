@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+)
+
+// WithExpressions evaluates the values of the form `=(...)` after the
+// config is merged, the result replaces the value:
+//
+//	base:
+//	  timeout: 30
+//	  name: api
+//	retry_timeout: =(base.timeout * 2)
+//	queue: =(base.name + "-queue")
+//
+// Expressions are in the syntax of github.com/expr-lang/expr, they read the
+// other keys of the config by their paths and can use the results of other
+// expressions.
+func WithExpressions() Option {
+	return func(c *Config) {
+		c.expressions = true
+		if c.programs == nil {
+			c.programs = make(map[string]*vm.Program)
+		}
+	}
+}
+
+// expression returns the expression of a value of the form `=(...)`.
+func expression(v interface{}) (string, bool) {
+	s, ok := v.(string)
+	if !ok || !strings.HasPrefix(s, "=(") || !strings.HasSuffix(s, ")") {
+		return "", false
+	}
+	return s[2 : len(s)-1], true
+}
+
+type pendingExpr struct {
+	keyArr []interface{}
+	key    string
+	value  string
+}
+
+// evalExpressions returns a copy of a merged config tree with the
+// expressions replaced by their results, the first error is recorded.
+func (c *Config) evalExpressions(data map[interface{}]interface{}) map[interface{}]interface{} {
+	if !c.expressions {
+		return data
+	}
+
+	var pending []pendingExpr
+	collectExpressions(data, nil, "", c.Delimiter, &pending)
+	if len(pending) == 0 {
+		return data
+	}
+	// 运行时修改的值可能与合并后的配置共享，在副本上替换
+	data = copyTree(data).(map[interface{}]interface{})
+
+	// 表达式可以引用其他表达式的结果，每轮计算能够计算的表达式，直到没有进展
+	for len(pending) > 0 {
+		env := c.exprEnv(data, pending)
+		var rest []pendingExpr
+		var firstErr error
+		for _, p := range pending {
+			out, err := c.runExpression(p.value, env)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("expression of `%s` failed: %w", p.key, err)
+				}
+				rest = append(rest, p)
+				continue
+			}
+			setLeaf(data, p.keyArr, normalizeValue(out))
+		}
+		if len(rest) == len(pending) {
+			c.recordEvalErr(firstErr)
+			break
+		}
+		pending = rest
+	}
+	return data
+}
+
+// runExpression compiles an expression once and runs it.
+func (c *Config) runExpression(value string, env map[string]interface{}) (interface{}, error) {
+	program, ok := c.programs[value]
+	if !ok {
+		code, _ := expression(value)
+		var err error
+		if program, err = expr.Compile(code); err != nil {
+			return nil, err
+		}
+		c.programs[value] = program
+	}
+	return expr.Run(program, env)
+}
+
+// exprEnv returns the config tree for evaluating expressions, the values of
+// the pending expressions are left out so that using them fails.
+func (c *Config) exprEnv(data map[interface{}]interface{}, pending []pendingExpr) map[string]interface{} {
+	tree := copyTree(data).(map[interface{}]interface{})
+	for _, p := range pending {
+		setLeaf(tree, p.keyArr, nil)
+	}
+	env, _ := stringKeyed(tree).(map[string]interface{})
+	return env
+}
+
+func collectExpressions(node interface{}, keyArr []interface{}, key, delimiter string, pending *[]pendingExpr) {
+	switch vv := node.(type) {
+	case map[interface{}]interface{}:
+		for k, item := range vv {
+			cKeyArr := append(keyArr[:len(keyArr):len(keyArr)], k)
+			collectExpressions(item, cKeyArr, joinKey(key, fmt.Sprint(k), delimiter), delimiter, pending)
+		}
+	case []interface{}:
+		for i, item := range vv {
+			cKeyArr := append(keyArr[:len(keyArr):len(keyArr)], i)
+			collectExpressions(item, cKeyArr, key+"["+strconv.Itoa(i)+"]", delimiter, pending)
+		}
+	default:
+		if _, ok := expression(node); ok {
+			*pending = append(*pending, pendingExpr{keyArr, key, node.(string)})
+		}
+	}
+}
+
+// setLeaf replaces the value at a path of map keys and slice indexes, the
+// path must exist.
+func setLeaf(node interface{}, keyArr []interface{}, v interface{}) {
+	last := len(keyArr) - 1
+	for i, k := range keyArr {
+		switch vv := node.(type) {
+		case map[interface{}]interface{}:
+			if i == last {
+				vv[k] = v
+				return
+			}
+			node = vv[k]
+		case []interface{}:
+			index := k.(int)
+			if i == last {
+				vv[index] = v
+				return
+			}
+			node = vv[index]
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package config
+
+import "testing"
+
+func TestWithExpressions(t *testing.T) {
+	const yaml = `base:
+  timeout: 30
+  name: api
+retry_timeout: =(base.timeout * 2)
+max_timeout: =(retry_timeout + 10)
+queue: =(base.name + "-queue")
+hosts: ["=(base.name + '.internal')"]
+plain: "=(not an expression"
+`
+	c, err := FromString(yaml, WithExpressions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]interface{}{
+		"retry_timeout": 60,
+		"max_timeout":   70, // 引用其他表达式的结果
+		"queue":         "api-queue",
+		"hosts[0]":      "api.internal",
+		"plain":         "=(not an expression",
+	}
+	for key, want := range tests {
+		if got, err := c.Get(key); err != nil || got != want {
+			t.Errorf("Get(%q) = %#v, %v, want %#v", key, got, err, want)
+		}
+	}
+
+	// 修改被引用的值后重新计算
+	if err := c.SetWithSource("base.timeout", 10, SourceInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetInt("max_timeout"); err != nil || got != 30 {
+		t.Errorf("GetInt(max_timeout) after SetWithSource = %d, %v, want 30", got, err)
+	}
+
+	c, err = FromString(yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.GetString("queue"); got != `=(base.name + "-queue")` {
+		t.Errorf("GetString(queue) without WithExpressions = %q, want the expression", got)
+	}
+}
+
+func TestWithExpressionsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+	}{
+		{name: "syntax", yaml: "a: =(1 +)\n"},
+		{name: "cycle", yaml: "a: =(b + 1)\nb: =(a + 1)\n"},
+		{name: "type", yaml: "a: x\nb: =(a * 2)\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FromString(tt.yaml, WithExpressions()); err == nil {
+				t.Error("FromString() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+)
+
+// BindFlagSet sets config keys from the flags of fs, mapping maps flag names
+// to config keys. Only the flags set on the command line are applied, so it
+// should be called after fs.Parse. Flags override the config files.
+func (c *Config) BindFlagSet(fs *flag.FlagSet, mapping map[string]string) error {
+	if err := c.loadIncludes(""); err != nil {
+		return err
+	}
+
+	_, err := c.update("flags", func() (func(), error) {
+		return c.bindFlags(fs, mapping)
+	})
+	return err
+}
+
+// bindFlags adds the edits setting the flags, and returns the func undoing
+// them.
+func (c *Config) bindFlags(fs *flag.FlagSet, mapping map[string]string) (func(), error) {
+	data := c.copyData()
+	edits := make([]edit, 0, len(mapping))
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		key, ok := mapping[f.Name]
+		if !ok || err != nil {
+			return
+		}
+
+		var keyArr []interface{}
+		if keyArr, err = c.parseKey(key); err != nil {
+			err = fmt.Errorf("invalid key of flag `%s`: %w", f.Name, err)
+			return
+		}
+
+		var value interface{}
+		if getter, ok := f.Value.(flag.Getter); ok {
+			value = normalizeValue(getter.Get())
+		} else {
+			value = parseScalar(f.Value.String())
+		}
+		if _, err = setKeys(data, keyArr, value, c.Delimiter); err != nil {
+			err = fmt.Errorf("can not set `%s` by flag `%s`: %w", key, f.Name, err)
+			return
+		}
+		edits = append(edits, c.setEdit(keyArr, value, SourceInfo{LayerFlag, "-" + f.Name}))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.addEdits(edits...), nil
+}
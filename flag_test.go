@@ -0,0 +1,59 @@
+package config
+
+import (
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestBindFlagSet(t *testing.T) {
+	c, err := FromString("server: {port: 8080, host: localhost}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.Int("port", 0, "")
+	fs.String("host", "flag-default", "")
+	fs.Bool("debug", false, "")
+	fs.Duration("timeout", 0, "")
+	if err := fs.Parse([]string{"-port=9090", "-debug", "-timeout=5s"}); err != nil {
+		t.Fatal(err)
+	}
+	mapping := map[string]string{
+		"port":    "server.port",
+		"host":    "server.host",
+		"debug":   "debug",
+		"timeout": "server.timeout",
+	}
+	if err := c.BindFlagSet(fs, mapping); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetInt("server.port"); err != nil || got != 9090 {
+		t.Errorf("GetInt(server.port) = %d, %v, want 9090", got, err)
+	}
+	// 未设置的flag不覆盖配置
+	if got, err := c.GetString("server.host"); err != nil || got != "localhost" {
+		t.Errorf("GetString(server.host) = %q, %v, want localhost", got, err)
+	}
+	if got, err := c.GetBool("debug"); err != nil || !got {
+		t.Errorf("GetBool(debug) = %v, %v, want true", got, err)
+	}
+	if got, err := c.GetDuration("server.timeout"); err != nil || got != 5*time.Second {
+		t.Errorf("GetDuration(server.timeout) = %v, %v, want 5s", got, err)
+	}
+	if got, want := c.Source("server.port"), (SourceInfo{LayerFlag, "-port"}); got != want {
+		t.Errorf("Source(server.port) = %v, want %v", got, want)
+	}
+
+	// 重新加载后保留flag的值
+	if _, err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetInt("server.port"); err != nil || got != 9090 {
+		t.Errorf("GetInt(server.port) after Reload = %d, %v, want 9090", got, err)
+	}
+
+	if err := c.BindFlagSet(fs, map[string]string{"port": "[0]"}); err == nil {
+		t.Error("BindFlagSet() error = nil for an invalid key, want an error")
+	}
+}
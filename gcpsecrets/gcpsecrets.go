@@ -0,0 +1,43 @@
+// Package gcpsecrets resolves secret references from GCP Secret Manager.
+//
+//	c, err := config.FromFile("config.yaml",
+//		config.WithResolver("gcpsm", gcpsecrets.NewResolver(client, "my-project", time.Minute)))
+//
+// Values such as `gcpsm://db#password` are then resolved to the field
+// `password` of the latest version of the JSON secret `db`. Full resource
+// names such as `gcpsm://projects/p/secrets/db/versions/3` are also
+// supported.
+package gcpsecrets
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/go-apibox/config"
+	"github.com/googleapis/gax-go/v2"
+)
+
+// Client is the part of *secretmanager.Client used by the resolver.
+type Client interface {
+	AccessSecretVersion(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest,
+		opts ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error)
+}
+
+// NewResolver create a resolver fetching secrets of project, secrets are
+// cached for ttl.
+func NewResolver(client Client, project string, ttl time.Duration) config.Resolver {
+	return config.NewSecretResolver(func(name string) (string, error) {
+		if !strings.HasPrefix(name, "projects/") {
+			name = "projects/" + project + "/secrets/" + name + "/versions/latest"
+		}
+		resp, err := client.AccessSecretVersion(context.Background(), &secretmanagerpb.AccessSecretVersionRequest{
+			Name: name,
+		})
+		if err != nil {
+			return "", err
+		}
+		return string(resp.GetPayload().GetData()), nil
+	}, ttl)
+}
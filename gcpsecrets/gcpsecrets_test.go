@@ -0,0 +1,60 @@
+package gcpsecrets
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/go-apibox/config"
+	"github.com/googleapis/gax-go/v2"
+)
+
+var _ Client = (*secretmanager.Client)(nil)
+
+// fakeClient serves secret versions from memory by resource name.
+type fakeClient struct {
+	versions map[string]string
+}
+
+func (f *fakeClient) AccessSecretVersion(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest,
+	opts ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error) {
+	data, ok := f.versions[req.GetName()]
+	if !ok {
+		return nil, errors.New("secret version `" + req.GetName() + "` not found")
+	}
+	return &secretmanagerpb.AccessSecretVersionResponse{
+		Name:    req.GetName(),
+		Payload: &secretmanagerpb.SecretPayload{Data: []byte(data)},
+	}, nil
+}
+
+func TestResolver(t *testing.T) {
+	client := &fakeClient{versions: map[string]string{
+		"projects/app/secrets/db/versions/latest":    `{"password": "hunter2"}`,
+		"projects/app/secrets/token/versions/latest": "s3cr3t",
+		"projects/other/secrets/db/versions/3":       `{"password": "v3"}`,
+	}}
+	yaml := "db: {password: \"gcpsm://db#password\"}\ntoken: gcpsm://token\n" +
+		"old: \"gcpsm://projects/other/secrets/db/versions/3#password\"\n"
+	c, err := config.FromString(yaml, config.WithResolver("gcpsm", NewResolver(client, "app", time.Minute)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"db.password": "hunter2",
+		"token":       "s3cr3t",
+		"old":         "v3",
+	}
+	for key, want := range tests {
+		if got, err := c.GetString(key); err != nil || got != want {
+			t.Errorf("GetString(%q) = %q, %v, want %q", key, got, err, want)
+		}
+	}
+
+	if _, err := config.FromString("v: gcpsm://missing\n", config.WithResolver("gcpsm", NewResolver(client, "app", time.Minute))); err == nil {
+		t.Error("FromString() error = nil for a missing secret, want an error")
+	}
+}
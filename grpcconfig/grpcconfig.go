@@ -0,0 +1,187 @@
+// Package grpcconfig serves a config over gRPC, so that a config server can
+// push it to many consumers, and provides the source reading it.
+//
+// The service is defined in config.proto, it only uses the well-known types
+// so that no generated code is needed.
+package grpcconfig
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"github.com/go-apibox/config"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const serviceName = "apibox.config.v1.ConfigService"
+
+// Server serves a config.
+type Server struct {
+	c *config.Config
+
+	mu          sync.Mutex
+	subscribers map[chan struct{}]bool
+}
+
+// NewServer create a server serving c, watchers are notified every time c
+// changes.
+func NewServer(c *config.Config) *Server {
+	s := &Server{c: c, subscribers: make(map[chan struct{}]bool)}
+	c.OnChange(func(changes []config.Change) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for ch := range s.subscribers {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	})
+	return s
+}
+
+// Register registers the service on a grpc server.
+func (s *Server) Register(gs *grpc.Server) {
+	gs.RegisterService(&serviceDesc, s)
+}
+
+func (s *Server) get() (*structpb.Struct, error) {
+	return structpb.NewStruct(s.c.AllSettings())
+}
+
+func (s *Server) watch(stream grpc.ServerStream) error {
+	ch := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.subscribers[ch] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, ch)
+		s.mu.Unlock()
+	}()
+
+	for {
+		msg, err := s.get()
+		if err != nil {
+			return err
+		}
+		if err := stream.SendMsg(msg); err != nil {
+			return err
+		}
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-ch:
+		}
+	}
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Get",
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := new(emptypb.Empty)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return srv.(*Server).get()
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/Get"}
+			return interceptor(ctx, in, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.(*Server).get()
+			})
+		},
+	}},
+	Streams: []grpc.StreamDesc{{
+		StreamName: "Watch",
+		Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			in := new(emptypb.Empty)
+			if err := stream.RecvMsg(in); err != nil {
+				return err
+			}
+			return srv.(*Server).watch(stream)
+		},
+		ServerStreams: true,
+	}},
+	Metadata: "config.proto",
+}
+
+// Source is a config.RemoteWatcher reading the config of a config server.
+type Source struct {
+	conn grpc.ClientConnInterface
+}
+
+// NewSource create a source reading the config served on conn.
+func NewSource(conn grpc.ClientConnInterface) *Source {
+	return &Source{conn: conn}
+}
+
+// Fetch implements config.RemoteProvider.
+func (s *Source) Fetch(ctx context.Context) (map[interface{}]interface{}, error) {
+	out := new(structpb.Struct)
+	if err := s.conn.Invoke(ctx, "/"+serviceName+"/Get", new(emptypb.Empty), out); err != nil {
+		return nil, err
+	}
+	return fromStruct(out), nil
+}
+
+// Watch implements config.RemoteWatcher with the streaming of the server.
+func (s *Source) Watch(ctx context.Context, update func(map[interface{}]interface{})) error {
+	desc := &serviceDesc.Streams[0]
+	stream, err := s.conn.NewStream(ctx, desc, "/"+serviceName+"/Watch")
+	if err != nil {
+		return err
+	}
+	if err := stream.SendMsg(new(emptypb.Empty)); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+
+	// 第一条消息为当前配置，Fetch之后的修改也包含在内
+	for {
+		msg := new(structpb.Struct)
+		if err := stream.RecvMsg(msg); err != nil {
+			return err
+		}
+		update(fromStruct(msg))
+	}
+}
+
+// fromStruct converts a protobuf struct to a config tree.
+func fromStruct(s *structpb.Struct) map[interface{}]interface{} {
+	return convert(s.AsMap()).(map[interface{}]interface{})
+}
+
+// convert converts the values of a protobuf struct to config values,
+// integral numbers are converted to int.
+func convert(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		m := make(map[interface{}]interface{}, len(vv))
+		for k, item := range vv {
+			m[k] = convert(item)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(vv))
+		for i, item := range vv {
+			s[i] = convert(item)
+		}
+		return s
+	case float64:
+		if vv == math.Trunc(vv) && math.Abs(vv) < 1<<53 {
+			return int(vv)
+		}
+		return vv
+	default:
+		return v
+	}
+}
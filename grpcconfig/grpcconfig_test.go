@@ -0,0 +1,127 @@
+package grpcconfig
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-apibox/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// serve serves c on an in-memory listener, and returns a connection to it.
+func serve(t *testing.T, c *config.Config) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	NewServer(c).Register(gs)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestFetch(t *testing.T) {
+	c, err := config.FromString("server: {host: localhost, port: 8080, ratio: 0.5}\ntags: [a, b]\ndebug: true\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewSource(serve(t, c)).Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[interface{}]interface{}{
+		"server": map[interface{}]interface{}{"host": "localhost", "port": 8080, "ratio": 0.5},
+		"tags":   []interface{}{"a", "b"},
+		"debug":  true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Fetch() = %v, want %v", got, want)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	c, err := config.FromString("port: 8080\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSource(serve(t, c))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates := make(chan map[interface{}]interface{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Watch(ctx, func(data map[interface{}]interface{}) {
+			updates <- data
+		})
+	}()
+	// 第一条消息为当前配置
+	for _, port := range []int{8080, 9090} {
+		select {
+		case data := <-updates:
+			if data["port"] != port {
+				t.Errorf("update = %v, want port %d", data, port)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no update of port %d", port)
+		}
+		if port == 8080 {
+			if err := c.SetWithSource("port", 9090, config.SourceInfo{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	cancel()
+	if err := <-done; err == nil {
+		t.Error("Watch() error = nil after ctx is done, want an error")
+	}
+}
+
+func TestSourceOfServer(t *testing.T) {
+	server, err := config.FromString("db: {host: db1}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := serve(t, server)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c, err := config.FromString("name: app\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddRemote(ctx, "config server", NewSource(conn)); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetString("db.host"); err != nil || got != "db1" {
+		t.Errorf("GetString(db.host) = %q, %v, want db1", got, err)
+	}
+
+	if err := server.SetWithSource("db.host", "db2", config.SourceInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if got, _ := c.GetString("db.host"); got == "db2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("config is not updated by the config server")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Handler returns a http handler serving the effective config and the
+// source of each key, it is suitable for mounting at `/debug/config`. The
+// secrets marked by MarkSecret and the decrypted or resolved values are
+// redacted. The response is JSON, or yaml if the `format` query parameter
+// is `yaml` or the Accept header asks for it.
+func (c *Config) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		snap := c.snap()
+		sources := make(map[string]string)
+		walkLeaves(snap.data, "", c.Delimiter, func(key string, v interface{}) error {
+			sources[key] = c.Source(key).String()
+			return nil
+		})
+		body := map[string]interface{}{
+			"config":  stringKeyed(c.exported(snap)),
+			"sources": sources,
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" && strings.Contains(r.Header.Get("Accept"), "yaml") {
+			format = "yaml"
+		}
+		if format == "yaml" {
+			b, err := yaml.Marshal(body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+			w.Write(b)
+			return
+		}
+
+		b, err := json.MarshalIndent(body, "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write(b)
+	})
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestHandler(t *testing.T) {
+	c, err := FromString("server: {port: 8080}\ndb: {password: s3cret}\n", WithDefaults([]byte("server: {host: localhost}\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.MarkSecret("db.password")
+
+	type body struct {
+		Config  map[string]interface{} `json:"config" yaml:"config"`
+		Sources map[string]string      `json:"sources" yaml:"sources"`
+	}
+	check := func(name string, got body) {
+		t.Helper()
+		server, _ := got.Config["server"].(map[string]interface{})
+		if server == nil || server["host"] != "localhost" {
+			t.Errorf("%s config = %v, want server.host localhost", name, got.Config)
+		}
+		if db, _ := got.Config["db"].(map[string]interface{}); db == nil || db["password"] != redactedValue {
+			t.Errorf("%s config = %v, want db.password redacted", name, got.Config)
+		}
+		if got.Sources["server.host"] != LayerDefaults || got.Sources["server.port"] != c.Source("server.port").String() {
+			t.Errorf("%s sources = %v", name, got.Sources)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/config", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Content-Type = %q, want JSON", ct)
+	}
+	var got body
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	check("JSON", got)
+
+	for _, req := range []string{"query", "accept"} {
+		r := httptest.NewRequest("GET", "/debug/config?format=yaml", nil)
+		if req == "accept" {
+			r = httptest.NewRequest("GET", "/debug/config", nil)
+			r.Header.Set("Accept", "application/yaml")
+		}
+		rec := httptest.NewRecorder()
+		c.Handler().ServeHTTP(rec, r)
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/yaml") {
+			t.Errorf("Content-Type by %s = %q, want yaml", req, ct)
+		}
+		var raw map[string]interface{}
+		if err := yaml.Unmarshal(rec.Body.Bytes(), &raw); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(stringKeyed(normalizeValue(raw)))
+		var got body
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatal(err)
+		}
+		check("yaml by "+req, got)
+	}
+}
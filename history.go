@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Revision describes an effective config kept in the history.
+type Revision struct {
+	Version int       // the version of the config, increased by every update
+	Time    time.Time // when the config was made effective
+	Source  string    // what made the config, such as `load`, `reload` or `remote etcd`
+}
+
+// revision is a Revision with the state of the sources to roll back to.
+type revision struct {
+	Revision
+	fileLayers []*layer
+	profile    string
+	remoteData map[*layer]map[interface{}]interface{}
+}
+
+// WithHistory keeps the last n effective configs made by loading the config
+// and by updates of sources, such as Reload and remote sources being
+// watched, so that the config can be rolled back by RollbackTo.
+func WithHistory(n int) Option {
+	return func(c *Config) {
+		c.historySize = n
+	}
+}
+
+// recordRevision adds the current config to the history, c.mu must be locked.
+func (c *Config) recordRevision(source string) {
+	if c.historySize <= 0 {
+		return
+	}
+
+	c.version++
+	rev := &revision{
+		Revision:   Revision{Version: c.version, Time: time.Now(), Source: source},
+		fileLayers: c.fileLayers,
+		profile:    c.profile,
+		remoteData: make(map[*layer]map[interface{}]interface{}, len(c.remoteLayers)),
+	}
+	for _, l := range c.remoteLayers {
+		rev.remoteData[l] = l.data
+	}
+	c.history = append(c.history, rev)
+	if len(c.history) > c.historySize {
+		c.history = append(c.history[:0:0], c.history[len(c.history)-c.historySize:]...)
+	}
+}
+
+// History returns the revisions kept by WithHistory, from the oldest to the
+// current one.
+func (c *Config) History() []Revision {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	revisions := make([]Revision, 0, len(c.history))
+	for _, rev := range c.history {
+		revisions = append(revisions, rev.Revision)
+	}
+	return revisions
+}
+
+// RollbackTo makes the config of a version in the history effective again,
+// such as to revert a bad push of a remote source. The files and the data
+// of the remote sources of the version are used, the runtime changes, such
+// as by SetWithSource, are kept. The rollback is a new version in the
+// history, the config is updated again by the next change of a source.
+func (c *Config) RollbackTo(version int) error {
+	_, err := c.update(fmt.Sprintf("rollback to %d", version), func() (func(), error) {
+		var rev *revision
+		for _, r := range c.history {
+			if r.Version == version {
+				rev = r
+			}
+		}
+		if rev == nil {
+			return nil, fmt.Errorf("version %d is not in the history", version)
+		}
+
+		oldLayers, oldProfile, oldPending := c.fileLayers, c.profile, atomic.LoadInt32(&c.lazyPending)
+		oldData := make(map[*layer]map[interface{}]interface{}, len(rev.remoteData))
+		c.fileLayers, c.profile = rev.fileLayers, rev.profile
+		// 版本中的include文件可能尚未加载
+		var pending int32
+		for _, l := range c.fileLayers {
+			if l.pending || l.loadErr != nil {
+				pending++
+			}
+		}
+		atomic.StoreInt32(&c.lazyPending, pending)
+		for l, data := range rev.remoteData {
+			oldData[l], l.data = l.data, data
+		}
+		return func() {
+			c.fileLayers, c.profile = oldLayers, oldProfile
+			atomic.StoreInt32(&c.lazyPending, oldPending)
+			for l, data := range oldData {
+				l.data = data
+			}
+		}, nil
+	})
+	return err
+}
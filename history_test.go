@@ -0,0 +1,73 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(port string) {
+		t.Helper()
+		if err := ioutil.WriteFile(file, []byte("port: "+port+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("8080")
+	c, err := FromFile(file, WithHistory(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, port := range []string{"8081", "8082", "8083"} {
+		write(port)
+		if _, err := c.Reload(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 只保留最近的3个版本
+	history := c.History()
+	if len(history) != 3 {
+		t.Fatalf("History() = %v, want 3 revisions", history)
+	}
+	for i, rev := range history {
+		if rev.Version != i+2 || rev.Source != "reload" || rev.Time.IsZero() {
+			t.Errorf("History()[%d] = %+v, want version %d of reload", i, rev, i+2)
+		}
+	}
+
+	// 运行时的修改也记录一个版本，版本2被丢弃
+	if err := c.SetWithSource("name", "app", SourceInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RollbackTo(3); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetInt("port"); err != nil || got != 8082 {
+		t.Errorf("GetInt(port) after RollbackTo = %d, %v, want 8082", got, err)
+	}
+	// 运行时的修改保留
+	if got, err := c.GetString("name"); err != nil || got != "app" {
+		t.Errorf("GetString(name) after RollbackTo = %q, %v, want app", got, err)
+	}
+	history = c.History()
+	if last := history[len(history)-1]; last.Source != "rollback to 3" {
+		t.Errorf("last revision = %+v, want the rollback", last)
+	}
+
+	if err := c.RollbackTo(2); err == nil {
+		t.Error("RollbackTo() error = nil for a version not kept, want an error")
+	}
+	if got, _ := c.GetInt("port"); got != 8082 {
+		t.Errorf("GetInt(port) after a failed RollbackTo = %d, want 8082", got)
+	}
+
+	c, err = FromString("a: 1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history := c.History(); len(history) != 0 {
+		t.Errorf("History() without WithHistory = %v, want none", history)
+	}
+}
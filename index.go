@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// lookupIndex returns the value of a key in the flattened index of the
+// snapshot, the index is built on the first lookup. Keys are indexed in the
+// form returned by AllKeys, including the keys of maps and slices.
+func (s *snapshot) lookupIndex(key, delimiter string) (interface{}, bool) {
+	s.indexOnce.Do(func() {
+		s.index = make(map[string]interface{})
+		s.indexDelim = delimiter
+		indexNode(s.index, s.data, "", delimiter)
+	})
+	// 分隔符改变后索引不可用
+	if s.indexDelim != delimiter {
+		return nil, false
+	}
+	v, ok := s.index[key]
+	return v, ok
+}
+
+func indexNode(index map[string]interface{}, node interface{}, pKey, delimiter string) {
+	switch vv := node.(type) {
+	case map[interface{}]interface{}:
+		for k, v := range vv {
+			// 与Get一致，只有string类型的key可以读取，
+			// 解析会有歧义的key不建索引，由Get按路径查找
+			key, ok := k.(string)
+			if !ok || !indexable(key) {
+				continue
+			}
+			cKey := joinKey(pKey, key, delimiter)
+			index[cKey] = v
+			indexNode(index, v, cKey, delimiter)
+		}
+	case []interface{}:
+		for i, v := range vv {
+			cKey := fmt.Sprintf("%s[%d]", pKey, i)
+			index[cKey] = v
+			indexNode(index, v, cKey, delimiter)
+		}
+	}
+}
+
+// indexable returns whether a map key is read by Get exactly as joinKey
+// writes it.
+func indexable(key string) bool {
+	return key != "" &&
+		!strings.HasPrefix(key, "[") &&
+		!strings.HasSuffix(key, "]") &&
+		!strings.Contains(key, `"`)
+}
+
+// canonicalKey returns whether a key is in the form of the keys in the
+// index, so that a key missing from the index doesn't exist. It doesn't
+// allocate, unlike parsing the key.
+func canonicalKey(key, delimiter string) bool {
+	if key == "" || delimiter == "" {
+		return false
+	}
+	for {
+		seg, last := key, true
+		if pos := strings.Index(key, delimiter); pos != -1 {
+			seg, key, last = key[:pos], key[pos+len(delimiter):], false
+		}
+		// 去掉末尾的数组索引
+		for strings.HasSuffix(seg, "]") {
+			start := strings.LastIndexByte(seg, '[')
+			if start == -1 || !canonicalIndex(seg[start+1:len(seg)-1]) {
+				break
+			}
+			seg = seg[:start]
+		}
+		if !indexable(seg) {
+			return false
+		}
+		if last {
+			return true
+		}
+	}
+}
+
+// canonicalIndex returns whether a slice index is written as in the index.
+func canonicalIndex(s string) bool {
+	if s == "" || (s[0] == '0' && len(s) > 1) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupFast looks up a key in the index without allocating. known is false
+// if the index can't tell whether the key exists, found is false for keys
+// set to null too.
+func (c *Config) lookupFast(key string) (v interface{}, found, known bool) {
+	if err := c.loadIncludes(key); err != nil {
+		return nil, false, false
+	}
+	snap := c.current()
+	v, ok := snap.lookupIndex(key, c.Delimiter)
+	switch {
+	case ok:
+		return v, v != nil, true
+	case canonicalKey(key, c.Delimiter):
+		return nil, false, true
+	default:
+		return nil, false, false
+	}
+}
+
+// missing returns whether a key is known not to exist without building an
+// error, the access hooks are called as by Get.
+func (c *Config) missing(key string) bool {
+	if _, found, known := c.lookupFast(key); !known || found {
+		return false
+	}
+	for _, fn := range c.accessHooks {
+		fn(key, false)
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"strconv"
+	"testing"
+)
+
+// benchConfig returns a config of 100 services with a few nested keys each.
+func benchConfig(b *testing.B) *Config {
+	services := make(map[string]interface{}, 100)
+	for i := 0; i < 100; i++ {
+		services["svc"+strconv.Itoa(i)] = map[string]interface{}{
+			"db": map[string]interface{}{
+				"host":  "localhost",
+				"port":  3306,
+				"hosts": []interface{}{"a", "b", "c"},
+			},
+			"timeout": "1s",
+		}
+	}
+	c, err := FromMap(map[string]interface{}{"services": services})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return c
+}
+
+func BenchmarkGet(b *testing.B) {
+	c := benchConfig(b)
+	keys := []string{"services.svc42.db.host", "services.svc42.db.hosts[1]", "services.svc7.timeout"}
+
+	b.Run("index", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := c.Get(keys[i%len(keys)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	// 不使用索引，每次解析key并按路径查找
+	b.Run("walk", func(b *testing.B) {
+		b.ReportAllocs()
+		data := c.current().data
+		for i := 0; i < b.N; i++ {
+			keyArr, err := c.parseKey(keys[i%len(keys)])
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := c.getKeys(data, keyArr); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestGetIndex(t *testing.T) {
+	c, err := FromString("a: {b: [1, {c: x}]}\n\"d.e\": 2\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key     string
+		want    interface{}
+		wantErr bool
+	}{
+		{key: "a.b[0]", want: 1},
+		{key: "a.b[1].c", want: "x"},
+		{key: `"d.e"`, want: 2},
+		{key: "a.b[2]", wantErr: true},
+		{key: "a.x", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := c.Get(tt.key)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && got != tt.want) {
+			t.Errorf("Get(%q) = %v, %v, want %v", tt.key, got, err, tt.want)
+		}
+	}
+
+	// 修改后索引重建
+	if err := c.SetWithSource("a.b[0]", 3, SourceInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.Get("a.b[0]"); err != nil || got != 3 {
+		t.Errorf("Get(%q) after SetWithSource = %v, %v, want 3", "a.b[0]", got, err)
+	}
+}
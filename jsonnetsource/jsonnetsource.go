@@ -0,0 +1,89 @@
+// Package jsonnetsource loads config from a Jsonnet file, the file is
+// evaluated to JSON with the import paths and external variables given.
+package jsonnetsource
+
+import (
+	"context"
+
+	"github.com/go-apibox/config"
+	"github.com/google/go-jsonnet"
+)
+
+// Source is a config.Source evaluating a Jsonnet file.
+type Source struct {
+	file        string
+	importPaths []string
+	extVars     map[string]string
+	extCodes    map[string]string
+}
+
+// Option configures a Source.
+type Option func(*Source)
+
+// WithImportPaths adds the paths searched for the imported files after the
+// directory of the importing file, like the `-J` flag of jsonnet.
+func WithImportPaths(paths ...string) Option {
+	return func(s *Source) {
+		s.importPaths = append(s.importPaths, paths...)
+	}
+}
+
+// WithExtVar sets an external variable read by `std.extVar(name)` to a
+// string, like the `--ext-str` flag of jsonnet.
+func WithExtVar(name, value string) Option {
+	return func(s *Source) {
+		s.extVars[name] = value
+	}
+}
+
+// WithExtCode sets an external variable read by `std.extVar(name)` to the
+// value of a Jsonnet expression, like the `--ext-code` flag of jsonnet.
+func WithExtCode(name, code string) Option {
+	return func(s *Source) {
+		s.extCodes[name] = code
+	}
+}
+
+// New create a source evaluating the Jsonnet file, the file must evaluate
+// to an object.
+func New(file string, opts ...Option) *Source {
+	s := &Source{
+		file:     file,
+		extVars:  make(map[string]string),
+		extCodes: make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// FromJsonnet create a config with the result of a Jsonnet source, the
+// file is evaluated again by Config.Reload.
+func FromJsonnet(ctx context.Context, s *Source, opts ...config.Option) (*config.Config, error) {
+	c, err := config.FromString("", opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.AddSource(ctx, s.file, s); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Load implements config.Source.
+func (s *Source) Load(ctx context.Context) ([]byte, config.Format, error) {
+	vm := jsonnet.MakeVM()
+	vm.Importer(&jsonnet.FileImporter{JPaths: s.importPaths})
+	for name, value := range s.extVars {
+		vm.ExtVar(name, value)
+	}
+	for name, code := range s.extCodes {
+		vm.ExtCode(name, code)
+	}
+	out, err := vm.EvaluateFile(s.file)
+	if err != nil {
+		return nil, "", err
+	}
+	return []byte(out), config.FormatJSON, nil
+}
@@ -0,0 +1,125 @@
+package jsonnetsource
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-apibox/config"
+)
+
+// writeFile writes a file in dir and returns its path.
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	file := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoad(t *testing.T) {
+	dir, lib := t.TempDir(), t.TempDir()
+	writeFile(t, lib, "db.libsonnet", "{ db(env): { host: env + '.db', port: 3306 } }\n")
+	writeFile(t, dir, "local.libsonnet", "{ name: 'app' }\n")
+	file := writeFile(t, dir, "main.jsonnet", `
+local db = import 'db.libsonnet';
+local app = import 'local.libsonnet';
+{
+  name: app.name,
+  env: std.extVar('env'),
+  replicas: std.extVar('replicas'),
+  db: db.db(std.extVar('env')),
+}
+`)
+
+	s := New(file, WithImportPaths(lib), WithExtVar("env", "prod"), WithExtCode("replicas", "1 + 2"))
+	b, format, err := s.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != config.FormatJSON {
+		t.Errorf("Load() format = %q, want %q", format, config.FormatJSON)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"name":     "app",
+		"env":      "prod",
+		"replicas": 3.0,
+		"db":       map[string]interface{}{"host": "prod.db", "port": 3306.0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		s    *Source
+	}{
+		{
+			name: "syntax",
+			s:    New(writeFile(t, dir, "syntax.jsonnet", "{ a: }\n")),
+		},
+		{
+			name: "missing ext var",
+			s:    New(writeFile(t, dir, "extvar.jsonnet", "{ a: std.extVar('missing') }\n")),
+		},
+		{
+			name: "missing import",
+			s:    New(writeFile(t, dir, "import.jsonnet", "import 'missing.libsonnet'\n")),
+		},
+		{
+			name: "missing file",
+			s:    New(filepath.Join(dir, "missing.jsonnet")),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := tt.s.Load(context.Background()); err == nil {
+				t.Error("Load() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestFromJsonnet(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "main.jsonnet", "{ server: { host: std.extVar('host'), port: 8080 } }\n")
+
+	c, err := FromJsonnet(context.Background(), New(file, WithExtVar("host", "localhost")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetString("server.host"); err != nil || got != "localhost" {
+		t.Errorf("GetString(server.host) = %q, %v, want localhost", got, err)
+	}
+	if got, err := c.GetInt("server.port"); err != nil || got != 8080 {
+		t.Errorf("GetInt(server.port) = %d, %v, want 8080", got, err)
+	}
+	if got := c.Source("server.port"); got.Name != file {
+		t.Errorf("Source(server.port) = %v, want the jsonnet file", got)
+	}
+
+	// 重新加载时再次求值
+	writeFile(t, dir, "main.jsonnet", "{ server: { host: std.extVar('host'), port: 9090 } }\n")
+	if _, err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetInt("server.port"); err != nil || got != 9090 {
+		t.Errorf("GetInt(server.port) after Reload = %d, %v, want 9090", got, err)
+	}
+
+	writeFile(t, dir, "array.jsonnet", "[1, 2]\n")
+	if _, err := FromJsonnet(context.Background(), New(filepath.Join(dir, "array.jsonnet"))); err == nil {
+		t.Error("FromJsonnet() error = nil for an array, want an error")
+	}
+}
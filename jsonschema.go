@@ -0,0 +1,28 @@
+package config
+
+import (
+	"errors"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// ValidateJSONSchema checks the config against a JSON Schema document,
+// all violations are returned together in a *ValidationError.
+func (c *Config) ValidateJSONSchema(schemaBytes []byte) error {
+	schemaLoader := gojsonschema.NewBytesLoader(schemaBytes)
+	docLoader := gojsonschema.NewGoLoader(stringKeyed(c.data()))
+
+	result, err := gojsonschema.Validate(schemaLoader, docLoader)
+	if err != nil {
+		return err
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	errs := make([]error, 0, len(result.Errors()))
+	for _, e := range result.Errors() {
+		errs = append(errs, errors.New(e.String()))
+	}
+	return &ValidationError{errs}
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateJSONSchema(t *testing.T) {
+	schema := []byte(`{
+  "type": "object",
+  "required": ["server"],
+  "properties": {
+    "server": {
+      "type": "object",
+      "required": ["host"],
+      "properties": {
+        "host": {"type": "string"},
+        "port": {"type": "integer", "maximum": 65535}
+      }
+    }
+  }
+}`)
+	tests := []struct {
+		name   string
+		yaml   string
+		errors int
+	}{
+		{name: "valid", yaml: "server: {host: localhost, port: 8080}\n"},
+		{name: "one violation", yaml: "server: {host: localhost, port: 70000}\n", errors: 1},
+		{name: "all violations", yaml: "server: {host: 1, port: x}\n", errors: 2},
+		{name: "required", yaml: "name: app\n", errors: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := FromString(tt.yaml)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = c.ValidateJSONSchema(schema)
+			if tt.errors == 0 {
+				if err != nil {
+					t.Errorf("ValidateJSONSchema() error = %v", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) || len(verr.Errors) != tt.errors {
+				t.Errorf("ValidateJSONSchema() error = %v, want %d violations", err, tt.errors)
+			}
+		})
+	}
+
+	c, err := FromString("a: 1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ValidateJSONSchema([]byte("{")); err == nil {
+		t.Error("ValidateJSONSchema() error = nil for an invalid schema, want an error")
+	}
+}
@@ -0,0 +1,172 @@
+// Package k8ssource loads config from a directory of projected Kubernetes
+// ConfigMap or Secret files, and watches the symlink swap Kubernetes
+// performs when they are updated.
+package k8ssource
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-apibox/config"
+)
+
+// the symlink to the current version of a projected volume
+const dataLink = "..data"
+
+// Source is a config.RemoteWatcher reading a directory.
+type Source struct {
+	dir      string
+	debounce time.Duration
+}
+
+// Option configures a Source.
+type Option func(*Source)
+
+// WithDebounce reads the directory once it has no events for d while
+// watching, so that the events of a symlink swap are coalesced into one
+// update. The default is 100ms, 0 reads on every event.
+func WithDebounce(d time.Duration) Option {
+	return func(s *Source) {
+		s.debounce = d
+	}
+}
+
+// New create a source reading the files of dir. Files with a yaml or json
+// extension are documents merged in name order, other files are keys split
+// into levels by dots, such as the file `db.password` for `db.password`,
+// their values are the contents without the trailing newline.
+func New(dir string, opts ...Option) *Source {
+	s := &Source{dir: dir, debounce: 100 * time.Millisecond}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// FromDir create a config with the files of dir, the config is updated when
+// the files change until ctx is done.
+func FromDir(ctx context.Context, dir string, opts ...config.Option) (*config.Config, error) {
+	c, err := config.FromString("", opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.AddRemote(ctx, dir, New(dir)); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Fetch implements config.RemoteProvider.
+func (s *Source) Fetch(ctx context.Context) (map[interface{}]interface{}, error) {
+	infos, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[interface{}]interface{})
+	for _, info := range infos {
+		name := info.Name()
+		// ..data等为Kubernetes内部使用的目录
+		if strings.HasPrefix(name, "..") {
+			continue
+		}
+		file := filepath.Join(s.dir, name)
+		if fi, err := os.Stat(file); err != nil || fi.IsDir() {
+			continue
+		}
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		switch filepath.Ext(name) {
+		case ".yaml", ".yml", ".json":
+			doc, err := config.ParseYAML(content, file)
+			if err != nil {
+				return nil, err
+			}
+			merge(data, doc)
+		default:
+			setKey(data, strings.Split(name, "."), strings.TrimRight(string(content), "\r\n"))
+		}
+	}
+	return data, nil
+}
+
+// Watch implements config.RemoteWatcher. Kubernetes updates the files by
+// swapping the `..data` symlink, files written in place are also watched.
+func (s *Source) Watch(ctx context.Context, update func(map[interface{}]interface{})) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	if err := watcher.Add(s.dir); err != nil {
+		return err
+	}
+
+	b := config.NewDebouncer(s.debounce)
+	defer b.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			name := filepath.Base(event.Name)
+			// 忽略Kubernetes创建的带时间戳的目录，等待..data切换
+			if strings.HasPrefix(name, "..") && name != dataLink {
+				continue
+			}
+			if event.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+			b.Reset()
+		case <-b.C:
+			b.C = nil
+			data, err := s.Fetch(ctx)
+			if err != nil {
+				// 文件可能正在写入，等待下次事件
+				continue
+			}
+			update(data)
+		}
+	}
+}
+
+// setKey sets the value at path, missing maps are created.
+func setKey(data map[interface{}]interface{}, path []string, value interface{}) {
+	for _, name := range path[:len(path)-1] {
+		next, ok := data[name].(map[interface{}]interface{})
+		if !ok {
+			next = make(map[interface{}]interface{})
+			data[name] = next
+		}
+		data = next
+	}
+	data[path[len(path)-1]] = value
+}
+
+// merge deep merges src into dst.
+func merge(dst, src map[interface{}]interface{}) {
+	for k, v := range src {
+		srcMap, ok := v.(map[interface{}]interface{})
+		if dstMap, ok2 := dst[k].(map[interface{}]interface{}); ok && ok2 {
+			merge(dstMap, srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+}
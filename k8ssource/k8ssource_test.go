@@ -0,0 +1,143 @@
+package k8ssource
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// project writes files the way Kubernetes projects a volume: the files are
+// in a timestamped directory, `..data` links to it and every file links to
+// the file under `..data`. Projecting again swaps the `..data` link.
+func project(t *testing.T, dir, version string, files map[string]string) {
+	ts := filepath.Join(dir, "..2026_"+version)
+	if err := os.Mkdir(ts, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(ts, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		link := filepath.Join(dir, name)
+		if _, err := os.Lstat(link); os.IsNotExist(err) {
+			if err := os.Symlink(filepath.Join(dataLink, name), link); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	tmp := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink(filepath.Base(ts), tmp); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, dataLink)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	dir := t.TempDir()
+	project(t, dir, "1", map[string]string{
+		"app.yaml":    "server: {port: 8080}\ndb: {host: localhost}\n",
+		"db.password": "hunter2\n",
+		"name":        "app",
+	})
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := New(dir).Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[interface{}]interface{}{
+		"server": map[interface{}]interface{}{"port": 8080},
+		"db":     map[interface{}]interface{}{"host": "localhost", "password": "hunter2"},
+		"name":   "app",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Fetch() = %v, want %v", got, want)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "bad.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(dir).Fetch(context.Background()); err == nil {
+		t.Error("Fetch() error = nil with an invalid document, want an error")
+	}
+}
+
+func TestFromDir(t *testing.T) {
+	dir := t.TempDir()
+	project(t, dir, "1", map[string]string{"db.host": "db1", "db.port": "3306"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c, err := FromDir(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetString("db.host"); err != nil || got != "db1" {
+		t.Errorf("GetString(db.host) = %q, %v, want db1", got, err)
+	}
+
+	// 等待开始监视，切换..data后更新配置
+	time.Sleep(50 * time.Millisecond)
+	project(t, dir, "2", map[string]string{"db.host": "db2", "db.port": "3306"})
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if got, _ := c.GetString("db.host"); got == "db2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("config is not updated after the volume is projected again")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatchDebounce(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "port"), []byte("8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(dir, WithDebounce(50*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates := make(chan map[interface{}]interface{}, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Watch(ctx, func(data map[interface{}]interface{}) {
+			updates <- data
+		})
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// 连续的写入合并为一次更新
+	for _, port := range []string{"8081", "8082", "9090"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, "port"), []byte(port), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case data := <-updates:
+		if data["port"] != "9090" {
+			t.Errorf("update = %v, want port 9090", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update after the file changed")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := len(updates); n != 0 {
+		t.Errorf("%d more updates, want the writes coalesced into one", n)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Watch() error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"strings"
+	"sync"
+)
+
+// Layers of config, from the lowest precedence to the highest.
+const (
+	LayerDefaults = "defaults"
+	LayerFile     = "file"
+	LayerInclude  = "include"
+	LayerProfile  = "profile"
+	LayerRemote   = "remote"
+	LayerEnv      = "env"
+	LayerFlag     = "flag"
+	LayerOverride = "override"
+)
+
+// the name of the section declaring profiles
+const profilesKey = "profiles"
+
+// SourceInfo describes where a config value comes from.
+type SourceInfo struct {
+	Layer string // the layer providing the value, such as LayerFile
+	Name  string // the name of the source in the layer, such as the file path
+}
+
+func (s SourceInfo) String() string {
+	if s.Name == "" {
+		return s.Layer
+	}
+	return s.Layer + " " + s.Name
+}
+
+// layer is one source of config data, layers are merged by precedence.
+type layer struct {
+	source     SourceInfo
+	data       map[interface{}]interface{}
+	doc        []byte                 // 配置文档原文，用于读取注释
+	pending    bool                   // 延迟加载的include文件尚未加载
+	namespace  string                 // 延迟加载的include文件所设置的顶层key
+	loadErr    error                  // 延迟加载的include文件的加载错误
+	vars       map[string]interface{} // include文件模板的变量
+	optional   bool                   // include文件不存在时跳过
+	provider   RemoteProvider         // 远程配置源，用于重新加载
+	cacheFile  string                 // 远程配置源的缓存文件
+	health     *remoteHealth          // 远程配置源的重试和熔断状态
+	policies   map[string]MergePolicy
+	arrayMerge ArrayMergeStrategy
+	directives bool // 合并时应用合并指令和删除标记，用于include文件和overlay
+}
+
+// layers returns all layers, from the lowest precedence to the highest.
+func (c *Config) layers() []*layer {
+	layers := make([]*layer, 0, len(c.defaultLayers)+len(c.fileLayers)+len(c.remoteLayers)+1)
+	layers = append(layers, c.defaultLayers...)
+	if c.defaults != nil {
+		layers = append(layers, &layer{source: SourceInfo{LayerDefaults, ""}, data: c.defaults})
+	}
+	layers = append(layers, c.fileLayers...)
+
+	// 配置文件中所选profile的配置
+	if c.profile != "" {
+		for _, l := range c.fileLayers {
+			if v, ok := lookupPath(l.data, []string{profilesKey, c.profile}); ok {
+				data, _ := v.(map[interface{}]interface{})
+				layers = append(layers, &layer{
+					source:     SourceInfo{LayerProfile, c.profile},
+					data:       data,
+					arrayMerge: c.arrayMerge,
+				})
+			}
+		}
+	}
+	layers = append(layers, c.remoteLayers...)
+	return layers
+}
+
+// rebuild merges all layers into the effective config, and records the
+// source of every key.
+func (c *Config) rebuild() {
+	c.state.Store(c.build())
+}
+
+// build merges all layers into a new effective config.
+func (c *Config) build() *snapshot {
+	cfgData := make(map[interface{}]interface{})
+	sources := make(map[string]SourceInfo)
+	c.evalErr = nil
+	for _, l := range c.layers() {
+		m := &merger{
+			arrayMerge: l.arrayMerge,
+			delimiter:  c.Delimiter,
+			policies:   l.policies,
+			directives: l.directives,
+			source:     l.source,
+			sources:    sources,
+		}
+		m.merge(cfgData, c.layerData(l))
+	}
+	if _, ok := cfgData[profilesKey]; ok {
+		delete(cfgData, profilesKey)
+		(&merger{delimiter: c.Delimiter, sources: sources}).forget(profilesKey)
+	}
+	c.applyEnv(cfgData, sources)
+
+	// 重放运行时的修改
+	if len(c.edits) > 0 {
+		for _, e := range c.edits {
+			e(cfgData, sources)
+		}
+		for key := range sources {
+			if keyArr, err := c.parseKey(key); err != nil {
+				delete(sources, key)
+			} else if _, ok := lookupKeys(cfgData, keyArr); !ok {
+				delete(sources, key)
+			}
+		}
+	}
+
+	cfgData = c.evalExpressions(cfgData)
+
+	// 解密加密的配置值并解析密钥引用，保留原始配置用于导出
+	snap := &snapshot{data: cfgData, sources: sources}
+	c.resolveErr = nil
+	if c.keyProvider != nil || len(c.resolvers) > 0 {
+		plain, err := c.resolveValue(cfgData, "")
+		snap.data, snap.raw, c.resolveErr = plain.(map[interface{}]interface{}), cfgData, err
+	}
+	return snap
+}
+
+// snapshot is an effective config, it is replaced as a whole on rebuilding,
+// so that it can be read while the config is being changed.
+type snapshot struct {
+	data    map[interface{}]interface{} // 合并后的有效配置
+	raw     map[interface{}]interface{} // 解密和解析引用前的有效配置
+	sources map[string]SourceInfo       // 每个key的来源
+
+	indexOnce  sync.Once
+	index      map[string]interface{} // 所有key到值的索引，首次读取时建立
+	indexDelim string
+
+	sumOnce sync.Once
+	sum     string // 有效配置的哈希，首次读取时计算
+}
+
+// snap returns the current effective config, the lazy includes are loaded
+// first. It must not be called with c.mu locked, use current instead.
+func (c *Config) snap() *snapshot {
+	if err := c.loadIncludes(""); err != nil {
+		c.log().Printf("%s", err)
+	}
+	return c.current()
+}
+
+// current returns the current effective config as it is.
+func (c *Config) current() *snapshot {
+	if snap, ok := c.state.Load().(*snapshot); ok {
+		return snap
+	}
+	return &snapshot{}
+}
+
+// data returns the current effective config tree.
+func (c *Config) data() map[interface{}]interface{} {
+	return c.snap().data
+}
+
+// Source returns where the value of a given key comes from.
+// Support multi-level key which concat with '.'.
+// Values in a slice report the source of the slice, the zero SourceInfo is
+// returned if the key does not exist.
+func (c *Config) Source(key string) SourceInfo {
+	if err := c.loadIncludes(key); err != nil {
+		c.log().Printf("%s", err)
+	}
+	return c.source(key)
+}
+
+func (c *Config) source(key string) SourceInfo {
+	sources := c.current().sources
+	for ok := true; ok; key, ok = c.parentKey(key) {
+		if source, ok := sources[key]; ok {
+			return source
+		}
+	}
+	return SourceInfo{}
+}
+
+// parentKey returns the key of the map or slice containing a given key.
+func (c *Config) parentKey(key string) (string, bool) {
+	pos := strings.LastIndex(key, c.Delimiter)
+	if idx := strings.LastIndex(key, "["); idx > pos {
+		pos = idx
+	}
+	if pos <= 0 {
+		return "", false
+	}
+	return key[:pos], true
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// WithLazyIncludes defers loading the files included by the config file
+// until a key under their namespace is read. The namespace of an included
+// file is the top level key named after it, such as `db` for `conf/db`, the
+// file must not set other keys. Reading the whole config, such as by
+// AllSettings or ToYAML, loads all of them.
+func WithLazyIncludes() Option {
+	return func(c *Config) {
+		c.lazyIncludes = true
+	}
+}
+
+// loadIncludes loads the lazy includes of the namespace of a given key, or
+// all of them if key is empty. It returns the first error of the files
+// loaded by this call. The config files are reloaded first if they changed,
+// see WithStatReload.
+func (c *Config) loadIncludes(key string) error {
+	c.checkStat()
+	if atomic.LoadInt32(&c.lazyPending) == 0 {
+		return nil
+	}
+
+	namespace := ""
+	if key != "" {
+		keyArr, err := c.parseKey(key)
+		if err != nil {
+			return nil
+		}
+		namespace, _ = keyArr[0].(string)
+	}
+	return c.loadNamespace(namespace)
+}
+
+// loadNamespace loads the lazy includes of a namespace, or all of them if
+// namespace is empty. An include that failed to load keeps its error, which
+// is returned by every later read of its namespace.
+func (c *Config) loadNamespace(namespace string) error {
+	if atomic.LoadInt32(&c.lazyPending) == 0 {
+		return nil
+	}
+
+	var firstErr error
+	_, err := c.update("load includes", func() (func(), error) {
+		firstErr = nil
+		var loaded []*layer
+		for _, l := range c.fileLayers {
+			if namespace != "" && l.namespace != namespace {
+				continue
+			}
+			if l.loadErr != nil {
+				if firstErr == nil {
+					firstErr = l.loadErr
+				}
+				continue
+			}
+			if !l.pending {
+				continue
+			}
+			if err := c.loadInclude(l); err != nil {
+				// 加载失败的文件仍计入lazyPending，之后的读取都返回该错误
+				l.pending, l.loadErr = false, err
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			loaded = append(loaded, l)
+			l.pending = false
+			atomic.AddInt32(&c.lazyPending, -1)
+		}
+		if len(loaded) == 0 {
+			return nil, errNotLoaded
+		}
+		return func() {
+			for _, l := range loaded {
+				l.data, l.doc, l.policies, l.pending = nil, nil, nil, true
+				atomic.AddInt32(&c.lazyPending, 1)
+			}
+		}, nil
+	})
+	if firstErr != nil {
+		return firstErr
+	}
+	if err != nil && err != errNotLoaded {
+		return err
+	}
+	return nil
+}
+
+// errNotLoaded is returned by the update of loadNamespace when no include
+// is loaded, so that no revision is recorded.
+var errNotLoaded = errors.New("no include is loaded")
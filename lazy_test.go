@@ -0,0 +1,46 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestLazyIncludes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.yaml": "include: [db, cache]\nname: app\n",
+		"db.yaml":     "db: {host: localhost}\n",
+		"cache.yaml":  "cache: [\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := FromFile(filepath.Join(dir, "config.yaml"), WithLazyIncludes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changes []Change
+	c.OnChange(func(cs []Change) {
+		changes = append(changes, cs...)
+	})
+
+	if got, err := c.GetString("db.host"); err != nil || got != "localhost" {
+		t.Errorf("GetString(db.host) = %q, %v, want localhost", got, err)
+	}
+	if len(changes) != 1 || changes[0].Key != "db.host" {
+		t.Errorf("changes = %v, want the change of db.host", changes)
+	}
+	// 加载失败的错误在每次读取时返回
+	for i := 0; i < 2; i++ {
+		if _, err := c.Get("cache.size"); err == nil || errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("Get(cache.size) #%d error = %v, want the load error", i, err)
+		}
+	}
+	if got, err := c.GetString("name"); err != nil || got != "app" {
+		t.Errorf("GetString(name) = %q, %v, want app", got, err)
+	}
+}
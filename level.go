@@ -0,0 +1,131 @@
+package config
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// Level is a log level, as returned by GetLogLevel.
+type Level int
+
+// Log levels, their numbers are accepted as aliases by GetLogLevel.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+var levelNames = []string{"debug", "info", "warn", "error", "fatal"}
+
+func (l Level) String() string {
+	if l >= LevelDebug && l <= LevelFatal {
+		return levelNames[l]
+	}
+	return "Level(" + strconv.Itoa(int(l)) + ")"
+}
+
+// ParseLevel parses a log level name, case insensitively, or its number.
+// `warning` is accepted as `warn`.
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if name == "warning" {
+		return LevelWarn, nil
+	}
+	for i, levelName := range levelNames {
+		if name == levelName {
+			return Level(i), nil
+		}
+	}
+	if n, err := strconv.Atoi(name); err == nil && n >= int(LevelDebug) && n <= int(LevelFatal) {
+		return Level(n), nil
+	}
+	return 0, errors.New("unknown log level `" + s + "`")
+}
+
+// SlogLevel returns the level of log/slog, fatal becomes slog.LevelError+4
+// as slog has no fatal level.
+func (l Level) SlogLevel() slog.Level {
+	switch l {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelError + 4
+	}
+}
+
+// ZapLevel returns the number of the level of go.uber.org/zap, convert it
+// with zapcore.Level(l.ZapLevel()).
+func (l Level) ZapLevel() int8 {
+	switch l {
+	case LevelDebug:
+		return -1
+	case LevelInfo:
+		return 0
+	case LevelWarn:
+		return 1
+	case LevelError:
+		return 2
+	default:
+		return 5
+	}
+}
+
+// LogrusLevel returns the number of the level of github.com/sirupsen/logrus,
+// convert it with logrus.Level(l.LogrusLevel()).
+func (l Level) LogrusLevel() uint32 {
+	switch l {
+	case LevelDebug:
+		return 5
+	case LevelInfo:
+		return 4
+	case LevelWarn:
+		return 3
+	case LevelError:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// GetLogLevel returns the log level for a given key, such as `info` or `WARN`.
+func (c *Config) GetLogLevel(key string) (Level, error) {
+	v, err := c.Get(key)
+	if err != nil {
+		return 0, err
+	}
+
+	switch vv := v.(type) {
+	case string:
+		if level, err := ParseLevel(vv); err == nil {
+			return level, nil
+		}
+	case int:
+		if level, err := ParseLevel(strconv.Itoa(vv)); err == nil {
+			return level, nil
+		}
+	}
+	return 0, typeError(key, "log level", v)
+}
+
+// GetDefaultLogLevel returns the log level for a given key.
+// if error occur, return defaultVal
+func (c *Config) GetDefaultLogLevel(key string, defaultVal Level) Level {
+	if c.missing(key) {
+		return defaultVal
+	}
+	if v, err := c.GetLogLevel(key); err != nil {
+		return defaultVal
+	} else {
+		return v
+	}
+}
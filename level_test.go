@@ -0,0 +1,74 @@
+package config
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestGetLogLevel(t *testing.T) {
+	c, err := FromString("levels: {a: info, b: WARN, c: warning, d: \" Error \", e: 4, f: \"0\", g: trace, h: 9, i: true}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key     string
+		want    Level
+		wantErr bool
+	}{
+		{key: "levels.a", want: LevelInfo},
+		{key: "levels.b", want: LevelWarn},
+		{key: "levels.c", want: LevelWarn},
+		{key: "levels.d", want: LevelError},
+		{key: "levels.e", want: LevelFatal},
+		{key: "levels.f", want: LevelDebug},
+		{key: "levels.g", wantErr: true},
+		{key: "levels.h", wantErr: true},
+		{key: "levels.i", wantErr: true},
+		{key: "levels.x", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := c.GetLogLevel(tt.key)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && got != tt.want) {
+			t.Errorf("GetLogLevel(%q) = %v, %v, want %v", tt.key, got, err, tt.want)
+		}
+	}
+	if got := c.GetDefaultLogLevel("levels.x", LevelError); got != LevelError {
+		t.Errorf("GetDefaultLogLevel(levels.x) = %v, want error", got)
+	}
+	if got := c.GetDefaultLogLevel("levels.a", LevelError); got != LevelInfo {
+		t.Errorf("GetDefaultLogLevel(levels.a) = %v, want info", got)
+	}
+}
+
+func TestLevelConversions(t *testing.T) {
+	tests := []struct {
+		level  Level
+		name   string
+		slog   slog.Level
+		zap    int8
+		logrus uint32
+	}{
+		{LevelDebug, "debug", slog.LevelDebug, -1, 5},
+		{LevelInfo, "info", slog.LevelInfo, 0, 4},
+		{LevelWarn, "warn", slog.LevelWarn, 1, 3},
+		{LevelError, "error", slog.LevelError, 2, 2},
+		{LevelFatal, "fatal", slog.LevelError + 4, 5, 1},
+	}
+	for _, tt := range tests {
+		if got := tt.level.String(); got != tt.name {
+			t.Errorf("String() = %q, want %q", got, tt.name)
+		}
+		if got := tt.level.SlogLevel(); got != tt.slog {
+			t.Errorf("%s SlogLevel() = %v, want %v", tt.name, got, tt.slog)
+		}
+		if got := tt.level.ZapLevel(); got != tt.zap {
+			t.Errorf("%s ZapLevel() = %d, want %d", tt.name, got, tt.zap)
+		}
+		if got := tt.level.LogrusLevel(); got != tt.logrus {
+			t.Errorf("%s LogrusLevel() = %d, want %d", tt.name, got, tt.logrus)
+		}
+	}
+	if got := Level(7).String(); got != "Level(7)" {
+		t.Errorf("String() = %q, want Level(7)", got)
+	}
+}
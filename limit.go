@@ -0,0 +1,283 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// ErrLimitExceeded is reported when a config document exceeds a limit set
+// by WithMaxFileSize, WithMaxDepth or WithMaxAliasExpansion.
+var ErrLimitExceeded = errors.New("config limit exceeded")
+
+// the number of nodes a config document may expand to by aliases, relative
+// to its size in bytes: a document without aliases has fewer nodes than bytes
+const (
+	aliasExpansionRatio = 100
+	aliasExpansionMin   = 10000
+)
+
+// WithMaxFileSize limits the size in bytes of every config document, such as
+// the config file, its included files and the defaults files.
+func WithMaxFileSize(size int64) Option {
+	return func(c *Config) {
+		c.maxFileSize = size
+	}
+}
+
+// WithMaxDepth limits the nesting depth of maps and slices in every config
+// document, a document of flat keys has the depth 1.
+func WithMaxDepth(depth int) Option {
+	return func(c *Config) {
+		c.maxDepth = depth
+	}
+}
+
+// WithMaxAliasExpansion limits the number of nodes every config document
+// may have once its aliases are expanded, the nodes are counted without
+// expanding them. By default a document may expand to 100 nodes per byte
+// of it.
+func WithMaxAliasExpansion(nodes int) Option {
+	return func(c *Config) {
+		c.maxNodes = nodes
+	}
+}
+
+// limitError returns an error naming the limit a document exceeds.
+func limitError(name, format string, args ...interface{}) error {
+	if name == "" {
+		name = "config"
+	} else {
+		name = "config `" + name + "`"
+	}
+	return fmt.Errorf("%w: %s %s", ErrLimitExceeded, name, fmt.Sprintf(format, args...))
+}
+
+// readFile reads a config document, the file is not read beyond the max
+// file size.
+func (c *Config) readFile(file string) ([]byte, error) {
+	if c.maxFileSize <= 0 {
+		return ioutil.ReadFile(file)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfgBytes, err := ioutil.ReadAll(io.LimitReader(f, c.maxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(cfgBytes)) > c.maxFileSize {
+		return nil, limitError(file, "is larger than the max file size of %d bytes", c.maxFileSize)
+	}
+	return cfgBytes, nil
+}
+
+// ParseYAML decodes a yaml config document with the default limit of alias
+// expansion, as the config files are decoded. It is used by the packages of
+// remote sources, so that the documents of config services are protected
+// the same way, name describes the document in errors.
+func ParseYAML(b []byte, name string) (map[interface{}]interface{}, error) {
+	cfgData, _, err := decodeYAML(trimBOM(b), name, 0, 0)
+	return cfgData, err
+}
+
+// parseYAML decodes a config document. Anchors, aliases and the `<<` merge
+// key are resolved by the yaml decoder, as in the yaml spec `<<` merges maps
+// shallowly, keys of the merged maps are replaced rather than deep merged.
+func (c *Config) parseYAML(cfgBytes []byte, name string) (map[interface{}]interface{}, error) {
+	if c.maxFileSize > 0 && int64(len(cfgBytes)) > c.maxFileSize {
+		return nil, limitError(name, "is larger than the max file size of %d bytes", c.maxFileSize)
+	}
+
+	cfgBytes = trimBOM(cfgBytes)
+	cfgData, root, err := decodeYAML(cfgBytes, name, c.maxNodes, c.maxDepth)
+	if err != nil {
+		return nil, err
+	}
+	if root != nil {
+		c.checkDuplicates(root, name)
+	}
+	return cfgData, nil
+}
+
+// decodeYAML decodes a yaml document within the max alias expansion and the
+// max depth, 0 for the default expansion and no max depth. The limits are
+// checked on the nodes of the document before the aliases are expanded, so
+// that a document expanding to too many nodes is rejected before it blows
+// up the memory. The nodes are returned for checking duplicate keys, nil if
+// they can't be parsed, then the limits are checked on the decoded tree.
+func decodeYAML(cfgBytes []byte, name string, maxNodes, maxDepth int) (map[interface{}]interface{}, *yamlv3.Node, error) {
+	limit, limitErr := maxNodes, func() error {
+		return limitError(name, "expands to more than the max alias expansion of %d nodes", maxNodes)
+	}
+	if maxNodes <= 0 {
+		if limit = len(cfgBytes) * aliasExpansionRatio; limit < aliasExpansionMin {
+			limit = aliasExpansionMin
+		}
+		limitErr = func() error {
+			return errors.New("yaml: document contains excessive aliasing")
+		}
+	}
+
+	var root yamlv3.Node
+	parsed := yamlv3.Unmarshal(cfgBytes, &root) == nil
+	if parsed {
+		nodes, depth := expandedSize(&root, limit, make(map[*yamlv3.Node]nodeSize))
+		if nodes > limit {
+			return nil, nil, limitErr()
+		}
+		if maxDepth > 0 && depth > maxDepth {
+			return nil, nil, limitError(name, "is nested deeper than the max depth of %d", maxDepth)
+		}
+	}
+
+	cfgData := make(map[interface{}]interface{})
+	if err := yaml.Unmarshal(cfgBytes, &cfgData); err != nil {
+		return nil, nil, err
+	}
+	if parsed {
+		return cfgData, &root, nil
+	}
+	// yaml.v3无法解析的文档在展开后检查
+	if countNodes(cfgData, limit) > limit {
+		return nil, nil, limitErr()
+	}
+	if maxDepth > 0 && treeDepth(cfgData, maxDepth) > maxDepth {
+		return nil, nil, limitError(name, "is nested deeper than the max depth of %d", maxDepth)
+	}
+	return cfgData, nil, nil
+}
+
+// nodeSize is the number of nodes and the depth of a yaml node once its
+// aliases are expanded.
+type nodeSize struct {
+	nodes, depth int
+}
+
+// expandedSize returns the number of nodes and the nesting depth of a yaml
+// node once its aliases are expanded, counted as countNodes and treeDepth
+// count the decoded tree. The sizes of the anchored nodes are memoized, so
+// that it takes linear time however many times they are aliased, the
+// number of nodes stops growing once limit is exceeded.
+func expandedSize(node *yamlv3.Node, limit int, memo map[*yamlv3.Node]nodeSize) (nodes, depth int) {
+	if size, ok := memo[node]; ok {
+		return size.nodes, size.depth
+	}
+	if node.Kind == yamlv3.SequenceNode || node.Kind == yamlv3.MappingNode {
+		// 包含自身的别名无限展开
+		memo[node] = nodeSize{limit + 1, 0}
+	}
+
+	var items []*yamlv3.Node // 展开后的子节点
+	childDepth := 0
+	switch node.Kind {
+	case yamlv3.DocumentNode:
+		if len(node.Content) == 0 {
+			return 1, 1
+		}
+		return expandedSize(node.Content[0], limit, memo)
+	case yamlv3.AliasNode:
+		if node.Alias == nil {
+			return 1, 0
+		}
+		return expandedSize(node.Alias, limit, memo)
+	case yamlv3.SequenceNode:
+		items = node.Content
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			if k.Kind != yamlv3.ScalarNode || k.Value != "<<" {
+				items = append(items, v)
+				continue
+			}
+			// `<<`合并的map的子节点与当前map同级
+			sources := []*yamlv3.Node{v}
+			if v.Kind == yamlv3.SequenceNode {
+				sources = v.Content
+			}
+			for _, source := range sources {
+				n, d := expandedSize(source, limit, memo)
+				if nodes += n - 1; nodes > limit {
+					nodes = limit + 1
+				}
+				if d-1 > childDepth {
+					childDepth = d - 1
+				}
+			}
+		}
+	default:
+		return 1, 0
+	}
+
+	for _, item := range items {
+		n, d := expandedSize(item, limit, memo)
+		if nodes += n; nodes > limit {
+			nodes = limit + 1
+		}
+		if d > childDepth {
+			childDepth = d
+		}
+	}
+	nodes, depth = nodes+1, childDepth+1
+	memo[node] = nodeSize{nodes, depth}
+	return nodes, depth
+}
+
+// countNodes returns the number of nodes in a config tree, it stops counting
+// once limit is exceeded.
+func countNodes(v interface{}, limit int) int {
+	n := 1
+	switch vv := v.(type) {
+	case map[interface{}]interface{}:
+		for _, item := range vv {
+			if n += countNodes(item, limit-n); n > limit {
+				break
+			}
+		}
+	case []interface{}:
+		for _, item := range vv {
+			if n += countNodes(item, limit-n); n > limit {
+				break
+			}
+		}
+	}
+	return n
+}
+
+// treeDepth returns the nesting depth of maps and slices in a config tree,
+// it stops descending once limit is exceeded.
+func treeDepth(v interface{}, limit int) int {
+	var items []interface{}
+	switch vv := v.(type) {
+	case map[interface{}]interface{}:
+		for _, item := range vv {
+			items = append(items, item)
+		}
+	case []interface{}:
+		items = vv
+	default:
+		return 0
+	}
+
+	depth := 0
+	if limit > 0 {
+		for _, item := range items {
+			if d := treeDepth(item, limit-1); d > depth {
+				depth = d
+			}
+			if depth >= limit {
+				break
+			}
+		}
+	}
+	return depth + 1
+}
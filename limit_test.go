@@ -0,0 +1,181 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMergeKey(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		key  string
+		want interface{}
+	}{
+		{
+			name: "alias",
+			yaml: "base: &base {host: localhost}\ndb: *base\n",
+			key:  "db.host",
+			want: "localhost",
+		},
+		{
+			name: "merge",
+			yaml: "base: &base {host: localhost, port: 3306}\ndb:\n  <<: *base\n  port: 3307\n",
+			key:  "db",
+			want: map[interface{}]interface{}{"host": "localhost", "port": 3307},
+		},
+		{
+			name: "merge list",
+			yaml: "a: &a {host: a, port: 1}\nb: &b {port: 2, user: b}\nc:\n  <<: [*a, *b]\n",
+			key:  "c",
+			want: map[interface{}]interface{}{"host": "a", "port": 1, "user": "b"},
+		},
+		{
+			name: "merge shallow",
+			yaml: "base: &base {db: {host: localhost, port: 3306}}\nprod:\n  <<: *base\n  db: {port: 3307}\n",
+			key:  "prod.db",
+			want: map[interface{}]interface{}{"port": 3307},
+		},
+		{
+			name: "anchor kept",
+			yaml: "base: &base {host: localhost}\ndb:\n  <<: *base\n  host: db\n",
+			key:  "base.host",
+			want: "localhost",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := FromString(tt.yaml)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := c.Get(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Get(%q) = %#v, want %#v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+// laughs returns a yaml document of n levels, each aliasing the previous
+// level 10 times.
+func laughs(n int) string {
+	var b strings.Builder
+	b.WriteString("l0: &l0 lol\n")
+	for i := 1; i <= n; i++ {
+		b.WriteString("l" + strconv.Itoa(i) + ": &l" + strconv.Itoa(i) + " [")
+		for j := 0; j < 10; j++ {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString("*l" + strconv.Itoa(i-1))
+		}
+		b.WriteString("]\n")
+	}
+	return b.String()
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		opts    []Option
+		wantErr bool
+		limit   bool // the error is ErrLimitExceeded
+	}{
+		{
+			name: "few aliases",
+			yaml: laughs(2),
+		},
+		{
+			name:    "billion laughs",
+			yaml:    laughs(9),
+			wantErr: true,
+		},
+		{
+			name:    "max alias expansion",
+			yaml:    laughs(2),
+			opts:    []Option{WithMaxAliasExpansion(50)},
+			wantErr: true,
+			limit:   true,
+		},
+		{
+			name: "max alias expansion not exceeded",
+			yaml: laughs(2),
+			opts: []Option{WithMaxAliasExpansion(1000)},
+		},
+		{
+			name:    "max depth",
+			yaml:    "a: {b: {c: {d: 1}}}\n",
+			opts:    []Option{WithMaxDepth(3)},
+			wantErr: true,
+			limit:   true,
+		},
+		{
+			name: "max depth not exceeded",
+			yaml: "a: {b: {c: 1}}\n",
+			opts: []Option{WithMaxDepth(3)},
+		},
+		{
+			name:    "max depth by alias",
+			yaml:    "a: &a {b: {c: 1}}\nd: {e: *a}\n",
+			opts:    []Option{WithMaxDepth(3)},
+			wantErr: true,
+			limit:   true,
+		},
+		{
+			name: "max depth by merge key",
+			yaml: "a: &a {b: {c: 1}}\nd: {<<: *a}\n",
+			opts: []Option{WithMaxDepth(3)},
+		},
+		{
+			name:    "max depth by merge key exceeded",
+			yaml:    "a: &a {b: {c: 1}}\nd: {e: {<<: *a}}\n",
+			opts:    []Option{WithMaxDepth(3)},
+			wantErr: true,
+			limit:   true,
+		},
+		{
+			name:    "recursive alias",
+			yaml:    "a: &a [*a]\n",
+			wantErr: true,
+		},
+		{
+			name:    "max file size",
+			yaml:    "name: " + strings.Repeat("x", 100) + "\n",
+			opts:    []Option{WithMaxFileSize(64)},
+			wantErr: true,
+			limit:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromString(tt.yaml, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.limit && !errors.Is(err, ErrLimitExceeded) {
+				t.Errorf("FromString() error = %v, want ErrLimitExceeded", err)
+			}
+		})
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	data, err := ParseYAML([]byte("\ufeffa: &a {host: localhost}\nb: *a\n"), "doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := data["b"].(map[interface{}]interface{}); b["host"] != "localhost" {
+		t.Errorf("ParseYAML() = %v, want b.host localhost", data)
+	}
+	if _, err := ParseYAML([]byte(laughs(9)), "doc"); err == nil {
+		t.Error("ParseYAML() error = nil, want an error for excessive aliasing")
+	}
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"log"
+	"os"
+)
+
+// Logger receives the warnings of a config, such as a deprecated key being used.
+// *log.Logger satisfies this interface.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+var defaultLogger Logger = log.New(os.Stderr, "[config] ", log.LstdFlags)
+
+// log returns the logger of the config.
+func (c *Config) log() Logger {
+	if c.logger == nil {
+		return defaultLogger
+	}
+	return c.logger
+}
+
+// WithLogger sets the logger receiving warnings, the default logs to stderr.
+func WithLogger(logger Logger) Option {
+	return func(c *Config) {
+		c.logger = logger
+	}
+}
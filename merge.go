@@ -0,0 +1,272 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ArrayMergeStrategy decides how a slice in an included config is combined
+// with the slice of the same key in the base config.
+type ArrayMergeStrategy int
+
+const (
+	// ArrayReplace replaces the base slice with the included one.
+	ArrayReplace ArrayMergeStrategy = iota
+	// ArrayAppend appends the included elements to the base slice.
+	ArrayAppend
+	// ArrayMergeByIndex merges elements at the same index, extra elements are appended.
+	ArrayMergeByIndex
+)
+
+// MergePolicy declares how a single key of an included config is merged.
+// It can be given inline as a key suffix (`servers !append: [...]`) or in
+// the `merge` section of the included file.
+type MergePolicy string
+
+const (
+	// MergeDefault deep merges maps and merges slices with the global strategy.
+	MergeDefault MergePolicy = ""
+	// MergeReplace replaces the base value, maps are not deep merged.
+	MergeReplace MergePolicy = "replace"
+	// MergeAppend appends slice elements to the base slice.
+	MergeAppend MergePolicy = "append"
+	// MergeByIndex merges slice elements at the same index.
+	MergeByIndex MergePolicy = "merge-by-index"
+	// MergeDelete removes the key from the base config.
+	MergeDelete MergePolicy = "delete"
+)
+
+// Tombstone is a value removing its key from the base config, like the
+// MergeDelete policy, rather than setting it to null. Like the inline
+// directives, it only applies in included files and overlays:
+//
+//	cache: ~delete
+const Tombstone = "~delete"
+
+// the name of the section in an included file declaring merge policies
+const mergeSectionKey = "merge"
+
+func parseMergePolicy(s string) (MergePolicy, bool) {
+	switch p := MergePolicy(s); p {
+	case MergeReplace, MergeAppend, MergeByIndex, MergeDelete:
+		return p, true
+	default:
+		return MergeDefault, false
+	}
+}
+
+// parseMergeDirective splits `name !directive` into name and policy.
+func parseMergeDirective(key string) (string, MergePolicy, bool) {
+	pos := strings.LastIndex(key, " !")
+	if pos == -1 {
+		return key, MergeDefault, false
+	}
+	policy, ok := parseMergePolicy(key[pos+2:])
+	if !ok {
+		return key, MergeDefault, false
+	}
+	return strings.TrimRight(key[:pos], " "), policy, true
+}
+
+type merger struct {
+	arrayMerge ArrayMergeStrategy
+	delimiter  string
+	policies   map[string]MergePolicy // 按key路径指定的合并方式
+	directives bool                   // 应用key后缀的合并指令和删除标记
+
+	source  SourceInfo
+	sources map[string]SourceInfo // 记录写入的key的来源，为nil时不记录
+}
+
+func (c *Config) newMerger() *merger {
+	return &merger{arrayMerge: c.arrayMerge, delimiter: c.Delimiter}
+}
+
+// takePolicies removes the `merge` section from an included config and
+// returns the policies declared in it.
+func takePolicies(src map[interface{}]interface{}) (map[string]MergePolicy, error) {
+	v, ok := src[mergeSectionKey]
+	if !ok {
+		return nil, nil
+	}
+	delete(src, mergeSectionKey)
+
+	section, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("unrecoginzed config value of `" + mergeSectionKey + "`")
+	}
+	policies := make(map[string]MergePolicy, len(section))
+	for k, vv := range section {
+		key, ok := k.(string)
+		if !ok {
+			return nil, errors.New("unrecoginzed config value of `" + mergeSectionKey + "`")
+		}
+		str, _ := vv.(string)
+		policy, ok := parseMergePolicy(str)
+		if !ok {
+			return nil, errors.New("unrecoginzed merge policy of `" + key + "`")
+		}
+		policies[key] = policy
+	}
+	return policies, nil
+}
+
+// merge two config maps
+func (m *merger) merge(dst map[interface{}]interface{}, src map[interface{}]interface{}) {
+	m.mergeMap(dst, src, "")
+
+	// merge段中声明删除的key，即使源中不存在也要删除
+	for key, policy := range m.policies {
+		if policy == MergeDelete {
+			deletePath(dst, strings.Split(key, m.delimiter))
+			m.forget(key)
+		}
+	}
+}
+
+// record sets the source of a key.
+func (m *merger) record(key string) {
+	if m.sources != nil {
+		m.forget(key)
+		m.sources[key] = m.source
+	}
+}
+
+// replace sets the source of a key whose value was old, see forgetValue.
+func (m *merger) replace(key string, old interface{}) {
+	if m.sources != nil {
+		m.forgetValue(key, old)
+		m.sources[key] = m.source
+	}
+}
+
+// forgetValue removes the sources of a key whose value was old, the keys
+// under it are removed only if old is a map or a slice, so that merging
+// scalars doesn't scan the sources of all keys.
+func (m *merger) forgetValue(key string, old interface{}) {
+	switch old.(type) {
+	case map[interface{}]interface{}, []interface{}:
+		m.forget(key)
+	default:
+		if m.sources != nil {
+			delete(m.sources, key)
+		}
+	}
+}
+
+// forget removes the sources of a key and the keys under it.
+func (m *merger) forget(key string) {
+	if m.sources == nil {
+		return
+	}
+	delete(m.sources, key)
+	for k := range m.sources {
+		if strings.HasPrefix(k, key) && len(k) > len(key) &&
+			(strings.HasPrefix(k[len(key):], m.delimiter) || k[len(key)] == '[') {
+			delete(m.sources, k)
+		}
+	}
+}
+
+func (m *merger) mergeMap(dst map[interface{}]interface{}, src map[interface{}]interface{}, pKey string) {
+	for k, v := range src {
+		policy := MergeDefault
+		if key, ok := k.(string); ok && m.directives {
+			if name, p, ok := parseMergeDirective(key); ok {
+				k, policy = name, p
+			}
+		}
+
+		cKey := joinKey(pKey, fmt.Sprint(k), m.delimiter)
+		if p, ok := m.policies[cKey]; ok {
+			policy = p
+		}
+		if str, ok := v.(string); ok && str == Tombstone && m.directives {
+			policy = MergeDelete
+		}
+
+		if policy == MergeDelete {
+			m.forgetValue(cKey, dst[k])
+			delete(dst, k)
+			continue
+		}
+
+		_, dstIsMap := dst[k].(map[interface{}]interface{})
+		if _, ok := v.(map[interface{}]interface{}); ok {
+			if !dstIsMap || policy == MergeReplace {
+				m.forgetValue(cKey, dst[k])
+			}
+		} else {
+			m.replace(cKey, dst[k])
+		}
+		dst[k] = m.mergeValue(dst[k], v, cKey, policy)
+	}
+}
+
+// mergeValue merges src over dst and returns the result.
+// policy applies to the value itself, values nested in it use their own.
+func (m *merger) mergeValue(dst, src interface{}, key string, policy MergePolicy) interface{} {
+	if policy == MergeReplace {
+		dst = nil
+	}
+
+	switch srcV := src.(type) {
+	case map[interface{}]interface{}:
+		// 目标不是map，则直接覆盖
+		dstV, ok := dst.(map[interface{}]interface{})
+		if !ok {
+			dstV = make(map[interface{}]interface{}, len(srcV))
+		}
+		// 目标是map，则递归合并
+		m.mergeMap(dstV, srcV, key)
+		return dstV
+
+	case []interface{}:
+		strategy := m.arrayMerge
+		switch policy {
+		case MergeReplace:
+			strategy = ArrayReplace
+		case MergeAppend:
+			strategy = ArrayAppend
+		case MergeByIndex:
+			strategy = ArrayMergeByIndex
+		}
+
+		dstV, _ := dst.([]interface{})
+		switch strategy {
+		case ArrayAppend:
+			result := make([]interface{}, 0, len(dstV)+len(srcV))
+			result = append(result, dstV...)
+			for _, item := range srcV {
+				result = append(result, m.mergeValue(nil, item, key, MergeDefault))
+			}
+			return result
+
+		case ArrayMergeByIndex:
+			result := make([]interface{}, 0, len(dstV)+len(srcV))
+			for i, item := range srcV {
+				if i < len(dstV) {
+					result = append(result, m.mergeValue(dstV[i], item, key, MergeDefault))
+				} else {
+					result = append(result, m.mergeValue(nil, item, key, MergeDefault))
+				}
+			}
+			if len(dstV) > len(srcV) {
+				result = append(result, dstV[len(srcV):]...)
+			}
+			return result
+
+		default:
+			result := make([]interface{}, 0, len(srcV))
+			for _, item := range srcV {
+				result = append(result, m.mergeValue(nil, item, key, MergeDefault))
+			}
+			return result
+		}
+
+	default:
+		// 源不是map或slice，直接覆盖
+		return src
+	}
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMergeSources(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.yaml")
+	env := filepath.Join(dir, "config.prod.yaml")
+	files := map[string]string{
+		base: "a: {b: 1, c: 2}\nd: {e: 1}\nl: [1, {f: 2}]\ns: 1\nm: 1\nkeep: {g: 1}\n",
+		env:  "a: 3\nd: ~delete\nl: 4\ns: 2\nm: {h: 1}\nkeep: {i: 2}\n",
+	}
+	for file, content := range files {
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := FromFileForEnv(base, "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"a", env},
+		{"a.b", env},
+		{"l", env},
+		{"l[1].f", env},
+		{"s", env},
+		{"m.h", env},
+		{"keep.g", base},
+		{"keep.i", env},
+	}
+	for _, tt := range tests {
+		if got := c.Source(tt.key); got.Name != tt.want {
+			t.Errorf("Source(%q) = %v, want %s", tt.key, got, tt.want)
+		}
+	}
+	if got := c.Source("d.e"); got != (SourceInfo{}) {
+		t.Errorf("Source(%q) = %v, want none", "d.e", got)
+	}
+}
+
+func BenchmarkMerge(b *testing.B) {
+	var doc strings.Builder
+	for i := 0; i < 10000; i++ {
+		doc.WriteString("key" + strconv.Itoa(i) + ": {a: 1, b: [1, 2]}\n")
+	}
+	data, err := ParseYAML([]byte(doc.String()), "")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m := &merger{delimiter: ".", sources: make(map[string]SourceInfo)}
+		m.merge(make(map[interface{}]interface{}), data)
+		m.merge(make(map[interface{}]interface{}), data)
+	}
+}
+
+func TestMergeDirectives(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.yaml")
+	files := map[string]string{
+		base:                          "include: [db]\nb: ~delete\n\"c !replace\": 1\nd: 1\ne: [1]\n",
+		filepath.Join(dir, "db.yaml"): "d: ~delete\ne !append: [2]\n",
+	}
+	for file, content := range files {
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := FromFile(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetDefault("f", Tombstone)
+	c.SetDefault("g !delete", 1)
+
+	// 基础配置和默认值中的合并指令和删除标记按原样保留
+	tests := []struct {
+		key  string
+		want interface{}
+	}{
+		{"b", Tombstone},
+		{`"c !replace"`, 1},
+		{"e", []interface{}{1, 2}},
+		{"f", Tombstone},
+		{`"g !delete"`, 1},
+	}
+	for _, tt := range tests {
+		if got, err := c.Get(tt.key); err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Get(%q) = %v, %v, want %v", tt.key, got, err, tt.want)
+		}
+	}
+	if c.Has("d") {
+		t.Error(`Has("d") = true, want the key deleted by the include`)
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+)
+
+// the key declaring the version of a config file
+const versionKey = "config_version"
+
+type migration struct {
+	from int
+	to   int
+	fn   func(tree map[interface{}]interface{}) map[interface{}]interface{}
+}
+
+// RegisterMigration registers a migration upgrading the config files of
+// version `from` to version `to`, the version of a file is declared by the
+// `config_version` key, files without it are of version 0. Migrations are
+// chained and applied to the config files in memory, fn receives a copy of
+// the tree of a file and returns the upgraded tree.
+func (c *Config) RegisterMigration(from, to int, fn func(tree map[interface{}]interface{}) map[interface{}]interface{}) error {
+	if to <= from {
+		return errors.New("migration should upgrade to a greater version")
+	}
+
+	_, err := c.update("migration from "+strconv.Itoa(from), func() (func(), error) {
+		for _, m := range c.migrations {
+			if m.from == from {
+				return nil, errors.New("migration from version " + strconv.Itoa(from) + " already exists")
+			}
+		}
+		old := c.migrations
+		c.migrations = append(c.migrations[:len(c.migrations):len(c.migrations)], migration{from, to, fn})
+		return func() {
+			c.migrations = old
+		}, nil
+	})
+	return err
+}
+
+// SaveMigrated writes the config files upgraded by migrations back, encoded
+// by the codecs of their extensions, the comments of the files are not kept.
+func (c *Config) SaveMigrated() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, l := range c.fileLayers {
+		if l.source.Name == "" {
+			continue
+		}
+		data, migrated := c.migrate(l)
+		if !migrated {
+			continue
+		}
+		// 写回include文件的merge段
+		if len(l.policies) > 0 {
+			section := make(map[interface{}]interface{}, len(l.policies))
+			for key, policy := range l.policies {
+				section[key] = string(policy)
+			}
+			data[mergeSectionKey] = section
+		}
+		b, err := codecOf(l.source.Name).Encode(data)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(l.source.Name, b, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrate returns the data of a config file or a remote source upgraded by
+// migrations, and whether it is upgraded.
+func (c *Config) migrate(l *layer) (map[interface{}]interface{}, bool) {
+	if len(c.migrations) == 0 || l.data == nil {
+		return l.data, false
+	}
+	switch l.source.Layer {
+	case LayerFile, LayerInclude, LayerRemote:
+	default:
+		return l.data, false
+	}
+
+	version := 0
+	if v, ok := l.data[versionKey]; ok {
+		var err error
+		if version, err = strconv.Atoi(fmt.Sprint(v)); err != nil {
+			return l.data, false
+		}
+	}
+
+	data, migrated := l.data, false
+	for {
+		var next *migration
+		for i := range c.migrations {
+			if c.migrations[i].from == version {
+				next = &c.migrations[i]
+			}
+		}
+		if next == nil {
+			break
+		}
+		// 复制一份，不修改原layer
+		data = next.fn(copyTree(data).(map[interface{}]interface{}))
+		if data == nil {
+			data = make(map[interface{}]interface{})
+		}
+		version = next.to
+		data[versionKey] = version
+		migrated = true
+	}
+	return data, migrated
+}
+
+// layerData returns the data of a layer to merge, with migrations,
+// deprecated keys and conditional values applied.
+func (c *Config) layerData(l *layer) map[interface{}]interface{} {
+	data, _ := c.migrate(l)
+	return c.applyConditions(c.applyDeprecations(data))
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegisterMigration(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(file, []byte("# old\ndb_host: localhost\nport: 8080\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := FromFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 版本0到1：db_host移到db.host
+	err = c.RegisterMigration(0, 1, func(tree map[interface{}]interface{}) map[interface{}]interface{} {
+		tree["db"] = map[interface{}]interface{}{"host": tree["db_host"]}
+		delete(tree, "db_host")
+		return tree
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 版本1到2：port移到server.port
+	err = c.RegisterMigration(1, 2, func(tree map[interface{}]interface{}) map[interface{}]interface{} {
+		tree["server"] = map[interface{}]interface{}{"port": tree["port"]}
+		delete(tree, "port")
+		return tree
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetString("db.host"); err != nil || got != "localhost" {
+		t.Errorf("GetString(db.host) = %q, %v, want localhost", got, err)
+	}
+	if got, err := c.GetInt("server.port"); err != nil || got != 8080 {
+		t.Errorf("GetInt(server.port) = %d, %v, want 8080", got, err)
+	}
+	if got, err := c.GetInt("config_version"); err != nil || got != 2 {
+		t.Errorf("GetInt(config_version) = %d, %v, want 2", got, err)
+	}
+	if c.Has("db_host") {
+		t.Error("Has(db_host) = true, want the key migrated")
+	}
+
+	if err := c.RegisterMigration(1, 3, nil); err == nil {
+		t.Error("RegisterMigration() error = nil for a duplicated version, want an error")
+	}
+	if err := c.RegisterMigration(3, 3, nil); err == nil {
+		t.Error("RegisterMigration() error = nil for a downgrade, want an error")
+	}
+
+	// 写回升级后的文件
+	if err := c.SaveMigrated(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(b); !strings.Contains(s, "config_version: 2") || strings.Contains(s, "db_host") {
+		t.Errorf("SaveMigrated() wrote %q", s)
+	}
+	saved, err := FromFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := saved.GetInt("server.port"); err != nil || got != 8080 {
+		t.Errorf("GetInt(server.port) of the saved file = %d, %v, want 8080", got, err)
+	}
+}
+
+func TestRegisterMigrationRejected(t *testing.T) {
+	c, err := FromString("port: 8080\n", WithSchema(Schema{"port": {Type: TypeInt, Required: true}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.RegisterMigration(0, 1, func(tree map[interface{}]interface{}) map[interface{}]interface{} {
+		delete(tree, "port")
+		return tree
+	})
+	if err == nil {
+		t.Fatal("RegisterMigration() error = nil for a migration failing the schema, want an error")
+	}
+	if got, err := c.GetInt("port"); err != nil || got != 8080 {
+		t.Errorf("GetInt(port) = %d, %v, want 8080", got, err)
+	}
+	// 被拒绝的迁移不保留
+	if err := c.RegisterMigration(0, 1, func(tree map[interface{}]interface{}) map[interface{}]interface{} { return tree }); err != nil {
+		t.Errorf("RegisterMigration() error = %v after a rejected migration", err)
+	}
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// mustPanic panics with the key and the error of a getter.
+func mustPanic(key string, err error) {
+	panic(fmt.Errorf("config: can not get `%s`: %w", key, err))
+}
+
+// MustGet is like Get but panics if error occur.
+func (c *Config) MustGet(key string) interface{} {
+	v, err := c.Get(key)
+	if err != nil {
+		mustPanic(key, err)
+	}
+	return v
+}
+
+// MustString is like GetString but panics if error occur.
+func (c *Config) MustString(key string) string {
+	v, err := c.GetString(key)
+	if err != nil {
+		mustPanic(key, err)
+	}
+	return v
+}
+
+// MustStringArray is like GetStringArray but panics if error occur.
+func (c *Config) MustStringArray(key string) []string {
+	v, err := c.GetStringArray(key)
+	if err != nil {
+		mustPanic(key, err)
+	}
+	return v
+}
+
+// MustInt is like GetInt but panics if error occur.
+func (c *Config) MustInt(key string) int {
+	v, err := c.GetInt(key)
+	if err != nil {
+		mustPanic(key, err)
+	}
+	return v
+}
+
+// MustIntArray is like GetIntArray but panics if error occur.
+func (c *Config) MustIntArray(key string) []int {
+	v, err := c.GetIntArray(key)
+	if err != nil {
+		mustPanic(key, err)
+	}
+	return v
+}
+
+// MustBool is like GetBool but panics if error occur.
+func (c *Config) MustBool(key string) bool {
+	v, err := c.GetBool(key)
+	if err != nil {
+		mustPanic(key, err)
+	}
+	return v
+}
+
+// MustBoolArray is like GetBoolArray but panics if error occur.
+func (c *Config) MustBoolArray(key string) []bool {
+	v, err := c.GetBoolArray(key)
+	if err != nil {
+		mustPanic(key, err)
+	}
+	return v
+}
+
+// MustFloat is like GetFloat but panics if error occur.
+func (c *Config) MustFloat(key string) float64 {
+	v, err := c.GetFloat(key)
+	if err != nil {
+		mustPanic(key, err)
+	}
+	return v
+}
+
+// MustFloatArray is like GetFloatArray but panics if error occur.
+func (c *Config) MustFloatArray(key string) []float64 {
+	v, err := c.GetFloatArray(key)
+	if err != nil {
+		mustPanic(key, err)
+	}
+	return v
+}
+
+// MustDuration is like GetDuration but panics if error occur.
+func (c *Config) MustDuration(key string) time.Duration {
+	v, err := c.GetDuration(key)
+	if err != nil {
+		mustPanic(key, err)
+	}
+	return v
+}
+
+// MustMap is like GetMap but panics if error occur.
+func (c *Config) MustMap(key string) map[string]interface{} {
+	v, err := c.GetMap(key)
+	if err != nil {
+		mustPanic(key, err)
+	}
+	return v
+}
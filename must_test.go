@@ -0,0 +1,31 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMust(t *testing.T) {
+	c, err := FromString("name: app\nport: 8080\ndebug: true\nratio: 0.5\ntimeout: 1s\nhosts: [a]\nports: [1]\nflags: [true]\nratios: [0.5]\ndb: {host: x}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.MustString("name") != "app" || c.MustInt("port") != 8080 || !c.MustBool("debug") ||
+		c.MustFloat("ratio") != 0.5 || c.MustDuration("timeout") != time.Second || c.MustGet("name") != "app" {
+		t.Error("Must getters return wrong values")
+	}
+	if len(c.MustStringArray("hosts")) != 1 || len(c.MustIntArray("ports")) != 1 ||
+		len(c.MustBoolArray("flags")) != 1 || len(c.MustFloatArray("ratios")) != 1 || len(c.MustMap("db")) != 1 {
+		t.Error("Must getters of lists and maps return wrong values")
+	}
+
+	defer func() {
+		err, _ := recover().(error)
+		if err == nil || !errors.Is(err, ErrKeyNotFound) || !strings.Contains(err.Error(), "`missing`") {
+			t.Errorf("MustInt() panics with %v, want an error of the missing key", err)
+		}
+	}()
+	c.MustInt("missing")
+}
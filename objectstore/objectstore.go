@@ -0,0 +1,150 @@
+// Package objectstore loads config files from object storage, such as
+// `s3://bucket/config.yaml` or `gs://bucket/config.yaml`.
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-apibox/config"
+	"gocloud.dev/blob"
+	_ "gocloud.dev/blob/gcsblob"
+	_ "gocloud.dev/blob/s3blob"
+)
+
+// Source is a config.RemoteProvider reading a yaml object.
+type Source struct {
+	bucketURL string
+	key       string
+	interval  time.Duration
+}
+
+// Option configures a Source.
+type Option func(*Source)
+
+// WithPollInterval makes the source a config.RemoteWatcher, it checks the
+// ETag of the object at the interval and reads it again if changed.
+func WithPollInterval(d time.Duration) Option {
+	return func(s *Source) {
+		s.interval = d
+	}
+}
+
+// New create a source reading the object at rawURL. Query parameters are
+// passed to the bucket, such as `s3://bucket/config.yaml?region=us-west-1`.
+func New(rawURL string, opts ...Option) (*Source, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || key == "" {
+		return nil, errors.New("invalid object url `" + rawURL + "`")
+	}
+	s := &Source{key: key}
+	u.Path = ""
+	s.bucketURL = u.String()
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// FromObjectStore create a config with the object at rawURL.
+func FromObjectStore(ctx context.Context, rawURL string, opts ...config.Option) (*config.Config, error) {
+	s, err := New(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	c, err := config.FromString("", opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.AddRemote(ctx, rawURL, s); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Fetch implements config.RemoteProvider.
+func (s *Source) Fetch(ctx context.Context) (map[interface{}]interface{}, error) {
+	data, _, err := s.fetch(ctx)
+	return data, err
+}
+
+// Watch polls the ETag of the object if WithPollInterval is set, otherwise
+// it blocks until ctx is done.
+func (s *Source) Watch(ctx context.Context, update func(map[interface{}]interface{})) error {
+	if s.interval <= 0 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	_, etag, err := s.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		newETag, err := s.etag(ctx)
+		if err != nil || newETag == etag {
+			// 读取失败时等待下次检查
+			continue
+		}
+		data, newETag, err := s.fetch(ctx)
+		if err != nil {
+			continue
+		}
+		etag = newETag
+		update(data)
+	}
+}
+
+// etag returns the ETag of the object.
+func (s *Source) etag(ctx context.Context) (string, error) {
+	bucket, err := blob.OpenBucket(ctx, s.bucketURL)
+	if err != nil {
+		return "", err
+	}
+	defer bucket.Close()
+
+	attrs, err := bucket.Attributes(ctx, s.key)
+	if err != nil {
+		return "", err
+	}
+	return attrs.ETag, nil
+}
+
+// fetch reads the object and returns its config tree and ETag.
+func (s *Source) fetch(ctx context.Context) (map[interface{}]interface{}, string, error) {
+	bucket, err := blob.OpenBucket(ctx, s.bucketURL)
+	if err != nil {
+		return nil, "", err
+	}
+	defer bucket.Close()
+
+	// 先取ETag，读取期间对象被修改时下次检查会重新读取
+	attrs, err := bucket.Attributes(ctx, s.key)
+	if err != nil {
+		return nil, "", err
+	}
+	content, err := bucket.ReadAll(ctx, s.key)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := config.ParseYAML(content, s.key)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, attrs.ETag, nil
+}
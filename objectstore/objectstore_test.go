@@ -0,0 +1,124 @@
+package objectstore
+
+import (
+	"context"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/blob/fileblob"
+)
+
+// testOpener opens the buckets of the `test` scheme from the directories
+// registered by the tests, such as `test://bucket/config.yaml`.
+type testOpener struct {
+	dirs sync.Map
+}
+
+func (o *testOpener) OpenBucketURL(ctx context.Context, u *url.URL) (*blob.Bucket, error) {
+	dir, _ := o.dirs.Load(u.Host)
+	return fileblob.OpenBucket(dir.(string), nil)
+}
+
+var opener = &testOpener{}
+
+func init() {
+	blob.DefaultURLMux().RegisterBucket("test", opener)
+}
+
+// newBucket registers a bucket of the test scheme with files, and returns
+// its directory.
+func newBucket(t *testing.T, name string, files map[string]string) string {
+	dir := t.TempDir()
+	for file, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opener.dirs.Store(name, dir)
+	return dir
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		url       string
+		bucketURL string
+		key       string
+		wantErr   bool
+	}{
+		{url: "s3://bucket/config.yaml", bucketURL: "s3://bucket", key: "config.yaml"},
+		{url: "s3://bucket/conf/app.yaml?region=us-west-1", bucketURL: "s3://bucket?region=us-west-1", key: "conf/app.yaml"},
+		{url: "gs://bucket", wantErr: true},
+		{url: "config.yaml", wantErr: true},
+	}
+	for _, tt := range tests {
+		s, err := New(tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("New(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (s.bucketURL != tt.bucketURL || s.key != tt.key) {
+			t.Errorf("New(%q) = %s, %s, want %s, %s", tt.url, s.bucketURL, s.key, tt.bucketURL, tt.key)
+		}
+	}
+}
+
+func TestFromObjectStore(t *testing.T) {
+	newBucket(t, "fetch", map[string]string{"config.yaml": "db: {host: localhost, port: 3306}\n"})
+	c, err := FromObjectStore(context.Background(), "test://fetch/config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.Get("db")
+	if want := map[interface{}]interface{}{"host": "localhost", "port": 3306}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Get(db) = %v, %v, want %v", got, err, want)
+	}
+
+	if _, err := FromObjectStore(context.Background(), "test://fetch/missing.yaml"); err == nil {
+		t.Error("FromObjectStore() error = nil for a missing object, want an error")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := newBucket(t, "watch", map[string]string{"config.yaml": "port: 8080\n"})
+	s, err := New("test://watch/config.yaml", WithPollInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates := make(chan map[interface{}]interface{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Watch(ctx, func(data map[interface{}]interface{}) {
+			select {
+			case updates <- data:
+			default:
+			}
+		})
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte("port: 9090\nhost: db\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case data := <-updates:
+		if data["port"] != 9090 {
+			t.Errorf("update = %v, want port 9090", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update after the object changed")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Watch() error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,438 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ApplyMergePatch applies a JSON merge patch as defined by RFC 7386 at the
+// highest precedence, such as a patch delivered by an admin API. Keys with
+// null values are removed, maps are merged and all other values, including
+// lists, replace the current value.
+//
+//	{"server": {"port": 9090, "debug": null}}
+func (c *Config) ApplyMergePatch(patch []byte) error {
+	v, err := decodeJSON(patch)
+	if err != nil {
+		return fmt.Errorf("invalid merge patch: %w", err)
+	}
+	p, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return errors.New("invalid merge patch: should be a JSON object")
+	}
+	if err := c.loadIncludes(""); err != nil {
+		return err
+	}
+
+	source := SourceInfo{Layer: LayerOverride, Name: "merge patch"}
+	_, err = c.update("merge patch", func() (func(), error) {
+		return c.addEdits(func(data map[interface{}]interface{}, sources map[string]SourceInfo) {
+			m := &merger{delimiter: c.Delimiter, source: source, sources: sources}
+			mergePatch(data, p, "", m)
+		}), nil
+	})
+	return err
+}
+
+// mergePatch applies a merge patch to target and returns the result, the
+// keys changed are recorded by m.
+func mergePatch(target interface{}, patch interface{}, pKey string, m *merger) interface{} {
+	p, ok := patch.(map[interface{}]interface{})
+	if !ok {
+		// 复制补丁中的值，避免之后的修改影响补丁
+		m.record(pKey)
+		return copyTree(patch)
+	}
+
+	t, ok := target.(map[interface{}]interface{})
+	if !ok {
+		t = make(map[interface{}]interface{}, len(p))
+		if pKey != "" {
+			m.record(pKey)
+		}
+	}
+	for k, v := range p {
+		cKey := joinKey(pKey, k.(string), m.delimiter)
+		if v == nil {
+			delete(t, k)
+			m.forget(cKey)
+			continue
+		}
+		t[k] = mergePatch(t[k], v, cKey, m)
+	}
+	return t
+}
+
+// decodeJSON decodes a JSON document into the form yaml decodes into, such
+// as map[interface{}]interface{} for objects and int for integers.
+func decodeJSON(b []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after the JSON document")
+	}
+	return fromJSON(v), nil
+}
+
+func fromJSON(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		m := make(map[interface{}]interface{}, len(vv))
+		for k, item := range vv {
+			m[k] = fromJSON(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range vv {
+			vv[i] = fromJSON(item)
+		}
+		return vv
+	case json.Number:
+		if i, err := strconv.Atoi(vv.String()); err == nil {
+			return i
+		}
+		f, _ := vv.Float64()
+		return f
+	default:
+		return v
+	}
+}
+
+// patchOperation is an operation of a JSON patch.
+type patchOperation struct {
+	op    string
+	path  string
+	from  string
+	value interface{}
+}
+
+// ApplyJSONPatch applies a JSON patch as defined by RFC 6902 at the highest
+// precedence, the operations add, remove, replace, move, copy and test are
+// supported. Paths are JSON pointers, such as `/servers/0/host`, or keys of
+// the config, such as `servers[0].host`. The patch is applied only if all
+// operations succeed, so that a `test` operation guards the changes after
+// it. If a `test` fails when the config is rebuilt later, such as after the
+// config files are reloaded, the patch is not applied any more.
+//
+//	[{"op": "test", "path": "/server/port", "value": 8080},
+//	 {"op": "replace", "path": "/server/port", "value": 9090}]
+func (c *Config) ApplyJSONPatch(patch []byte) error {
+	v, err := decodeJSON(patch)
+	if err != nil {
+		return fmt.Errorf("invalid json patch: %w", err)
+	}
+	items, ok := v.([]interface{})
+	if !ok {
+		return errors.New("invalid json patch: should be a JSON array")
+	}
+	ops := make([]patchOperation, 0, len(items))
+	for i, item := range items {
+		op, err := parsePatchOperation(item)
+		if err != nil {
+			return fmt.Errorf("invalid json patch: operation %d %s", i, err)
+		}
+		ops = append(ops, op)
+	}
+	if err := c.loadIncludes(""); err != nil {
+		return err
+	}
+
+	source := SourceInfo{Layer: LayerOverride, Name: "json patch"}
+	_, err = c.update("json patch", func() (func(), error) {
+		// 在副本上检查
+		if _, err := c.applyPatch(c.copyData(), ops); err != nil {
+			return nil, err
+		}
+		return c.addEdits(c.patchEdit(ops, source)), nil
+	})
+	return err
+}
+
+// patchEdit returns the edit applying the operations of a json patch.
+func (c *Config) patchEdit(ops []patchOperation, source SourceInfo) edit {
+	return func(data map[interface{}]interface{}, sources map[string]SourceInfo) {
+		patched := copyTree(data).(map[interface{}]interface{})
+		keys, err := c.applyPatch(patched, ops)
+		if err != nil {
+			c.log().Printf("json patch is not applied: %s", err)
+			return
+		}
+		for k := range data {
+			delete(data, k)
+		}
+		for k, v := range patched {
+			data[k] = v
+		}
+		m := &merger{delimiter: c.Delimiter, source: source, sources: sources}
+		for _, key := range keys {
+			m.record(key)
+		}
+	}
+}
+
+func parsePatchOperation(item interface{}) (patchOperation, error) {
+	var op patchOperation
+	m, ok := item.(map[interface{}]interface{})
+	if !ok {
+		return op, errors.New("should be a JSON object")
+	}
+	op.op, _ = m["op"].(string)
+	if op.path, ok = m["path"].(string); !ok {
+		return op, errors.New("has no path")
+	}
+	switch op.op {
+	case "add", "replace", "test":
+		if op.value, ok = m["value"]; !ok {
+			return op, errors.New("has no value")
+		}
+	case "move", "copy":
+		if op.from, ok = m["from"].(string); !ok {
+			return op, errors.New("has no from")
+		}
+	case "remove":
+	default:
+		return op, errors.New("has unknown op `" + op.op + "`")
+	}
+	return op, nil
+}
+
+// applyPatch applies the operations of a JSON patch to data, and returns
+// the keys set.
+func (c *Config) applyPatch(data map[interface{}]interface{}, ops []patchOperation) ([]string, error) {
+	var keys []string
+	for i, op := range ops {
+		key, err := c.applyOperation(data, op)
+		if err != nil {
+			return nil, fmt.Errorf("json patch operation %d (%s `%s`) failed: %w", i, op.op, op.path, err)
+		}
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// applyOperation applies an operation of a JSON patch to data, and returns
+// the key set, if any.
+func (c *Config) applyOperation(data map[interface{}]interface{}, op patchOperation) (string, error) {
+	switch op.op {
+	case "add":
+		return c.patchAdd(data, op.path, copyTree(op.value))
+
+	case "remove", "replace", "test":
+		keyArr, key, err := c.patchKey(data, op.path, false)
+		if err != nil {
+			return "", err
+		}
+		v, ok := lookupKeys(data, keyArr)
+		if !ok {
+			return "", notFoundError(key)
+		}
+		switch op.op {
+		case "remove":
+			deleteKeys(data, keyArr, false)
+			return "", nil
+		case "replace":
+			_, err := setKeys(data, keyArr, copyTree(op.value), c.Delimiter)
+			return key, err
+		default:
+			// 错误信息中不包含值，避免泄露密钥
+			if !valueEqual(v, op.value) {
+				return "", errors.New("value of `" + key + "` does not match")
+			}
+			return "", nil
+		}
+
+	case "move", "copy":
+		fromArr, fromKey, err := c.patchKey(data, op.from, false)
+		if err != nil {
+			return "", err
+		}
+		v, ok := lookupKeys(data, fromArr)
+		if !ok {
+			return "", notFoundError(fromKey)
+		}
+		if op.op == "copy" {
+			return c.patchAdd(data, op.path, copyTree(v))
+		}
+
+		if op.path == op.from {
+			return "", nil
+		}
+		_, key, err := c.patchKey(data, op.path, true)
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(key, fromKey) && len(key) > len(fromKey) &&
+			(strings.HasPrefix(key[len(fromKey):], c.Delimiter) || key[len(fromKey)] == '[') {
+			return "", errors.New("can not move `" + fromKey + "` into itself")
+		}
+		deleteKeys(data, fromArr, false)
+		return c.patchAdd(data, op.path, v)
+	}
+	return "", errors.New("unknown op `" + op.op + "`")
+}
+
+// patchAdd adds a value at a path, the value is inserted if the path is an
+// index of a slice.
+func (c *Config) patchAdd(data map[interface{}]interface{}, path string, value interface{}) (string, error) {
+	keyArr, key, err := c.patchKey(data, path, true)
+	if err != nil {
+		return "", err
+	}
+
+	// 父节点必须存在
+	parentArr := keyArr[:len(keyArr)-1]
+	parentKey := c.joinKeyArr(parentArr)
+	var parent interface{} = data
+	if len(parentArr) > 0 {
+		var ok bool
+		if parent, ok = lookupKeys(data, parentArr); !ok {
+			return "", notFoundError(parentKey)
+		}
+	}
+
+	switch k := keyArr[len(keyArr)-1].(type) {
+	case int:
+		s, ok := parent.([]interface{})
+		if !ok {
+			return "", nodeError(parentKey, ErrNotASlice, parent)
+		}
+		if k < 0 || k > len(s) {
+			return "", indexError(parentKey, k, len(s))
+		}
+		result := make([]interface{}, 0, len(s)+1)
+		result = append(result, s[:k]...)
+		result = append(result, value)
+		result = append(result, s[k:]...)
+		if _, err := setKeys(data, parentArr, result, c.Delimiter); err != nil {
+			return "", err
+		}
+	default:
+		if _, ok := parent.(map[interface{}]interface{}); !ok {
+			return "", nodeError(parentKey, ErrNotAMap, parent)
+		}
+		if _, err := setKeys(data, keyArr, value, c.Delimiter); err != nil {
+			return "", err
+		}
+	}
+	return key, nil
+}
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// patchKey translates a path of a JSON patch to a parsed key and the key,
+// a JSON pointer is resolved against data, the index `-` is the length of
+// a slice if add is true.
+func (c *Config) patchKey(data map[interface{}]interface{}, path string, add bool) ([]interface{}, string, error) {
+	if path == "" {
+		return nil, "", ErrEmptyKey
+	}
+	if path[0] != '/' {
+		keyArr, err := c.parseKey(path)
+		if err != nil {
+			return nil, "", err
+		}
+		return keyArr, c.joinKeyArr(keyArr), nil
+	}
+
+	segs := strings.Split(path[1:], "/")
+	keyArr := make([]interface{}, 0, len(segs))
+	var node interface{} = data
+	for i, seg := range segs {
+		seg = pointerUnescaper.Replace(seg)
+		s, ok := node.([]interface{})
+		if !ok {
+			keyArr = append(keyArr, seg)
+			if m, ok := node.(map[interface{}]interface{}); ok {
+				node = m[seg]
+			} else {
+				node = nil
+			}
+			continue
+		}
+
+		if seg == "-" && add && i == len(segs)-1 {
+			keyArr = append(keyArr, len(s))
+			break
+		}
+		index, err := strconv.Atoi(seg)
+		if err != nil || index < 0 || strconv.Itoa(index) != seg {
+			return nil, "", fmt.Errorf("%w: invalid index `%s` in `%s`", ErrKeyFormat, seg, path)
+		}
+		keyArr = append(keyArr, index)
+		node = nil
+		if index < len(s) {
+			node = s[index]
+		}
+	}
+	return keyArr, c.joinKeyArr(keyArr), nil
+}
+
+// joinKeyArr returns the key of a parsed key.
+func (c *Config) joinKeyArr(keyArr []interface{}) string {
+	var key string
+	for _, k := range keyArr {
+		switch kk := k.(type) {
+		case string:
+			key = joinKey(key, kk, c.Delimiter)
+		case int:
+			key = fmt.Sprintf("%s[%d]", key, kk)
+		}
+	}
+	return key
+}
+
+// valueEqual returns whether two config values are equal, numbers are
+// compared by value as in JSON.
+func valueEqual(a, b interface{}) bool {
+	switch av := a.(type) {
+	case int, float64:
+		af, _ := toFloat(av)
+		bf, ok := toFloat(b)
+		return ok && af == bf
+	case map[interface{}]interface{}:
+		bv, ok := b.(map[interface{}]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, item := range av {
+			bItem, ok := bv[k]
+			if !ok || !valueEqual(item, bItem) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !valueEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch vv := v.(type) {
+	case int:
+		return float64(vv), true
+	case float64:
+		return vv, true
+	}
+	return 0, false
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	c, err := FromString("server: {host: localhost, port: 8080, debug: true}\nhosts: [a, b]\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ApplyMergePatch([]byte(`{"server": {"port": 9090, "debug": null, "tls": {"enabled": true}}, "hosts": ["c"]}`)); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]interface{}{
+		"server.host":        "localhost",
+		"server.port":        9090,
+		"server.tls.enabled": true,
+		"hosts":              []interface{}{"c"},
+	}
+	for key, want := range tests {
+		if got, err := c.Get(key); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("Get(%q) = %#v, %v, want %#v", key, got, err, want)
+		}
+	}
+	if c.Has("server.debug") {
+		t.Error("Has(server.debug) = true, want the key removed by null")
+	}
+	if got, want := c.Source("server.port"), (SourceInfo{LayerOverride, "merge patch"}); got != want {
+		t.Errorf("Source(server.port) = %v, want %v", got, want)
+	}
+
+	for _, patch := range []string{`[1]`, `{"a":`, `{} {}`} {
+		if err := c.ApplyMergePatch([]byte(patch)); err == nil {
+			t.Errorf("ApplyMergePatch(%s) error = nil, want an error", patch)
+		}
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	const yaml = "server: {host: localhost, port: 8080}\nhosts: [a, b]\n\"a/b\": {\"c~d\": 1}\n"
+	tests := []struct {
+		name    string
+		patch   string
+		want    map[string]interface{}
+		missing []string
+		wantErr bool
+	}{
+		{
+			name:  "replace guarded by test",
+			patch: `[{"op": "test", "path": "/server/port", "value": 8080.0}, {"op": "replace", "path": "/server/port", "value": 9090}]`,
+			want:  map[string]interface{}{"server.port": 9090},
+		},
+		{
+			name:    "failed test",
+			patch:   `[{"op": "test", "path": "/server/port", "value": 1}, {"op": "replace", "path": "/server/port", "value": 9090}]`,
+			want:    map[string]interface{}{"server.port": 8080},
+			wantErr: true,
+		},
+		{
+			name:  "add to list",
+			patch: `[{"op": "add", "path": "/hosts/1", "value": "x"}, {"op": "add", "path": "/hosts/-", "value": "y"}]`,
+			want:  map[string]interface{}{"hosts": []interface{}{"a", "x", "b", "y"}},
+		},
+		{
+			name:    "remove",
+			patch:   `[{"op": "remove", "path": "/server/host"}]`,
+			missing: []string{"server.host"},
+		},
+		{
+			name:    "move and copy",
+			patch:   `[{"op": "move", "from": "/server/host", "path": "/host"}, {"op": "copy", "from": "/hosts/0", "path": "/first"}]`,
+			want:    map[string]interface{}{"host": "localhost", "first": "a"},
+			missing: []string{"server.host"},
+		},
+		{
+			name:  "escaped path",
+			patch: `[{"op": "replace", "path": "/a~1b/c~0d", "value": 2}]`,
+			want:  map[string]interface{}{`"a/b"."c~d"`: 2},
+		},
+		{
+			name:  "dotted path",
+			patch: `[{"op": "replace", "path": "hosts[1]", "value": "z"}]`,
+			want:  map[string]interface{}{"hosts[1]": "z"},
+		},
+		{name: "move into itself", patch: `[{"op": "move", "from": "/server", "path": "/server/x"}]`, wantErr: true},
+		{name: "missing parent", patch: `[{"op": "add", "path": "/x/y", "value": 1}]`, wantErr: true},
+		{name: "index out of range", patch: `[{"op": "add", "path": "/hosts/5", "value": 1}]`, wantErr: true},
+		{name: "unknown op", patch: `[{"op": "merge", "path": "/a"}]`, wantErr: true},
+		{name: "no value", patch: `[{"op": "add", "path": "/a"}]`, wantErr: true},
+		{name: "not array", patch: `{"op": "add"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := FromString(yaml)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := c.ApplyJSONPatch([]byte(tt.patch)); (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyJSONPatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for key, want := range tt.want {
+				if got, err := c.Get(key); err != nil || !reflect.DeepEqual(got, want) {
+					t.Errorf("Get(%q) = %#v, %v, want %#v", key, got, err, want)
+				}
+			}
+			for _, key := range tt.missing {
+				if c.Has(key) {
+					t.Errorf("Has(%q) = true, want the key removed", key)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatchReload(t *testing.T) {
+	c, err := FromString("server: {port: 8080}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ApplyJSONPatch([]byte(`[{"op": "replace", "path": "/server/port", "value": 9090}]`)); err != nil {
+		t.Fatal(err)
+	}
+	// 重建配置时再次应用
+	if err := c.SetWithSource("name", "app", SourceInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetInt("server.port"); err != nil || got != 9090 {
+		t.Errorf("GetInt(server.port) = %d, %v, want 9090", got, err)
+	}
+}
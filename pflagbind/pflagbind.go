@@ -0,0 +1,236 @@
+// Package pflagbind binds spf13/pflag flag sets, and hence cobra commands,
+// to config keys.
+package pflagbind
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-apibox/config"
+	"github.com/spf13/pflag"
+)
+
+// Bind sets config keys from the flags of fs, mapping maps flag names to
+// config keys. Only the flags changed on the command line are applied, so it
+// should be called after parsing, such as in the PreRunE of a cobra command.
+func Bind(c *config.Config, fs *pflag.FlagSet, mapping map[string]string) error {
+	var err error
+	fs.Visit(func(f *pflag.Flag) {
+		key, ok := mapping[f.Name]
+		if !ok || err != nil {
+			return
+		}
+		source := config.SourceInfo{Layer: config.LayerFlag, Name: "--" + f.Name}
+		if err = c.SetWithSource(key, flagValue(f), source); err != nil {
+			err = errors.New("can not set `" + key + "` by flag `" + f.Name + "`: " + err.Error())
+		}
+	})
+	return err
+}
+
+// flagValue returns the typed value of a flag.
+func flagValue(f *pflag.Flag) interface{} {
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		return sv.GetSlice()
+	}
+
+	str := f.Value.String()
+	switch f.Value.Type() {
+	case "bool":
+		if b, err := strconv.ParseBool(str); err == nil {
+			return b
+		}
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		if i, err := strconv.Atoi(str); err == nil {
+			return i
+		}
+	case "float32", "float64":
+		if f, err := strconv.ParseFloat(str, 64); err == nil {
+			return f
+		}
+	}
+	return str
+}
+
+// FlagName returns the flag name of a config key, such as `server-port`
+// for `server.port`.
+func FlagName(key, delimiter string) string {
+	name := strings.Replace(key, delimiter, "-", -1)
+	name = strings.Replace(name, "_", "-", -1)
+	return strings.ToLower(name)
+}
+
+// FlagsFromSchema defines a flag for every key of the schema, and returns
+// the mapping to pass to Bind.
+func FlagsFromSchema(fs *pflag.FlagSet, schema config.Schema) map[string]string {
+	mapping := make(map[string]string, len(schema))
+	for key, rule := range schema {
+		name := FlagName(key, ".")
+		if fs.Lookup(name) != nil {
+			continue
+		}
+		switch rule.Type {
+		case config.TypeInt:
+			fs.Int(name, 0, "")
+		case config.TypeFloat:
+			fs.Float64(name, 0, "")
+		case config.TypeBool:
+			fs.Bool(name, false, "")
+		case config.TypeDuration:
+			fs.Duration(name, 0, "")
+		case config.TypeStringList:
+			fs.StringSlice(name, nil, "")
+		case config.TypeIntList:
+			fs.IntSlice(name, nil, "")
+		case config.TypeFloatList:
+			fs.Float64Slice(name, nil, "")
+		case config.TypeBoolList:
+			fs.BoolSlice(name, nil, "")
+		case config.TypeMap, config.TypeList:
+			continue
+		default:
+			fs.String(name, "", "")
+		}
+		mapping[name] = key
+	}
+	return mapping
+}
+
+// FlagsFromStruct defines a flag for every field of a struct tagged like
+// Unmarshal expects, and returns the mapping to pass to Bind. Defaults of
+// flags are taken from `default` tags, and usages from `usage` tags.
+func FlagsFromStruct(fs *pflag.FlagSet, v interface{}) (map[string]string, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.New("flags should be defined from a struct")
+	}
+
+	mapping := make(map[string]string)
+	if err := structFlags(fs, t, "", mapping); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func structFlags(fs *pflag.FlagSet, t reflect.Type, prefix string, mapping map[string]string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		key, ok := fieldKey(field)
+		if !ok {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && ft != durationType {
+			if field.Anonymous && field.Tag.Get("config") == "" {
+				key = prefix
+			}
+			if err := structFlags(fs, ft, key, mapping); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := FlagName(key, ".")
+		if fs.Lookup(name) != nil {
+			continue
+		}
+		def := field.Tag.Get("default")
+		usage := field.Tag.Get("usage")
+		if err := defineFlag(fs, ft, name, def, usage); err != nil {
+			return errors.New("can not define flag of `" + key + "`: " + err.Error())
+		}
+		mapping[name] = key
+	}
+	return nil
+}
+
+func defineFlag(fs *pflag.FlagSet, t reflect.Type, name, def, usage string) error {
+	if t == durationType {
+		d := time.Duration(0)
+		if def != "" {
+			var err error
+			if d, err = time.ParseDuration(def); err != nil {
+				return err
+			}
+		}
+		fs.Duration(name, d, usage)
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		fs.String(name, def, usage)
+	case reflect.Bool:
+		b := false
+		if def != "" {
+			var err error
+			if b, err = strconv.ParseBool(def); err != nil {
+				return err
+			}
+		}
+		fs.Bool(name, b, usage)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i := 0
+		if def != "" {
+			var err error
+			if i, err = strconv.Atoi(def); err != nil {
+				return err
+			}
+		}
+		fs.Int(name, i, usage)
+	case reflect.Float32, reflect.Float64:
+		f := 0.0
+		if def != "" {
+			var err error
+			if f, err = strconv.ParseFloat(def, 64); err != nil {
+				return err
+			}
+		}
+		fs.Float64(name, f, usage)
+	case reflect.Slice:
+		var items []string
+		if def = strings.Trim(def, "[] "); def != "" {
+			for _, item := range strings.Split(def, ",") {
+				items = append(items, strings.TrimSpace(item))
+			}
+		}
+		fs.StringSlice(name, items, usage)
+	default:
+		// map等类型不支持用flag设置
+	}
+	return nil
+}
+
+// fieldKey returns the config key of a struct field, as Unmarshal does.
+func fieldKey(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("config")
+	if tag == "-" {
+		return "", false
+	}
+	if pos := strings.Index(tag, ","); pos != -1 {
+		tag = tag[:pos]
+	}
+	if tag == "" {
+		return strings.ToLower(field.Name), true
+	}
+	return tag, true
+}
@@ -0,0 +1,155 @@
+package pflagbind
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-apibox/config"
+	"github.com/spf13/pflag"
+)
+
+func TestBind(t *testing.T) {
+	c, err := config.FromString("server: {port: 8080, host: localhost}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	fs.Int("port", 0, "")
+	fs.String("host", "flag-default", "")
+	fs.Bool("debug", false, "")
+	fs.Float64("ratio", 0, "")
+	fs.StringSlice("tags", nil, "")
+	if err := fs.Parse([]string{"--port=9090", "--debug", "--ratio=0.5", "--tags=a,b"}); err != nil {
+		t.Fatal(err)
+	}
+	mapping := map[string]string{
+		"port":  "server.port",
+		"host":  "server.host",
+		"debug": "debug",
+		"ratio": "ratio",
+		"tags":  "tags",
+	}
+	if err := Bind(c, fs, mapping); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]interface{}{
+		"server.port": 9090,
+		"server.host": "localhost", // 未设置的flag不覆盖配置
+		"debug":       true,
+		"ratio":       0.5,
+		"tags":        []interface{}{"a", "b"},
+	}
+	for key, want := range tests {
+		if got, err := c.Get(key); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("Get(%q) = %#v, %v, want %#v", key, got, err, want)
+		}
+	}
+	if got, want := c.Source("server.port"), (config.SourceInfo{Layer: config.LayerFlag, Name: "--port"}); got != want {
+		t.Errorf("Source(server.port) = %v, want %v", got, want)
+	}
+
+	fs = pflag.NewFlagSet("app", pflag.ContinueOnError)
+	fs.Int("port", 0, "")
+	fs.Parse([]string{"--port=1"})
+	if err := Bind(c, fs, map[string]string{"port": "server.port[0]"}); err == nil {
+		t.Error("Bind() error = nil for a key not of a list, want an error")
+	}
+}
+
+func TestFlagName(t *testing.T) {
+	tests := map[string]string{
+		"server.port":     "server-port",
+		"db.max_open":     "db-max-open",
+		"Server.ReadTime": "server-readtime",
+	}
+	for key, want := range tests {
+		if got := FlagName(key, "."); got != want {
+			t.Errorf("FlagName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestFlagsFromSchema(t *testing.T) {
+	schema := config.Schema{
+		"server.port": {Type: config.TypeInt},
+		"server.host": {Type: config.TypeString},
+		"timeout":     {Type: config.TypeDuration},
+		"tags":        {Type: config.TypeStringList},
+		"labels":      {Type: config.TypeMap},
+	}
+	fs := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	mapping := FlagsFromSchema(fs, schema)
+	want := map[string]string{
+		"server-port": "server.port",
+		"server-host": "server.host",
+		"timeout":     "timeout",
+		"tags":        "tags",
+	}
+	if !reflect.DeepEqual(mapping, want) {
+		t.Errorf("FlagsFromSchema() = %v, want %v", mapping, want)
+	}
+	types := map[string]string{"server-port": "int", "server-host": "string", "timeout": "duration", "tags": "stringSlice"}
+	for name, typ := range types {
+		if f := fs.Lookup(name); f == nil || f.Value.Type() != typ {
+			t.Errorf("flag %s = %v, want a flag of type %s", name, f, typ)
+		}
+	}
+}
+
+type flagServer struct {
+	Host    string        `config:"host" default:"localhost" usage:"listen host"`
+	Port    int           `config:"port" default:"8080"`
+	Timeout time.Duration `config:"timeout" default:"5s"`
+	Debug   bool
+	Tags    []string `default:"[a, b]"`
+	Ignored string   `config:"-"`
+}
+
+func TestFlagsFromStruct(t *testing.T) {
+	var v struct {
+		Server flagServer `config:"server"`
+		Ratio  float64    `config:"ratio" default:"0.5"`
+	}
+	fs := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	mapping, err := FlagsFromStruct(fs, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(mapping))
+	for name := range mapping {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	want := []string{"ratio", "server-debug", "server-host", "server-port", "server-tags", "server-timeout"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("FlagsFromStruct() flags = %v, want %v", names, want)
+	}
+	defaults := map[string]string{
+		"server-host":    "localhost",
+		"server-port":    "8080",
+		"server-timeout": "5s",
+		"server-tags":    "[a,b]",
+		"ratio":          "0.5",
+	}
+	for name, def := range defaults {
+		if f := fs.Lookup(name); f == nil || f.DefValue != def {
+			t.Errorf("default of flag %s = %v, want %s", name, f, def)
+		}
+	}
+	if usage := fs.Lookup("server-host").Usage; usage != "listen host" {
+		t.Errorf("usage of flag server-host = %q, want listen host", usage)
+	}
+
+	var bad struct {
+		Port int `default:"x"`
+	}
+	if _, err := FlagsFromStruct(pflag.NewFlagSet("app", pflag.ContinueOnError), &bad); err == nil {
+		t.Error("FlagsFromStruct() error = nil for an invalid default, want an error")
+	}
+	if _, err := FlagsFromStruct(fs, 1); err == nil {
+		t.Error("FlagsFromStruct() error = nil for a non struct, want an error")
+	}
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ohler55/ojg/jp"
+)
+
+// anyIndex matches every element of a slice in a pattern.
+type anyIndex struct{}
+
+// GetAll returns the values of all keys matching a pattern, by their
+// concrete keys. `*` matches any map key of a level and `[*]` matches any
+// element of a slice, such as `servers.*.host` or `upstreams[*].port`.
+func (c *Config) GetAll(pattern string) (map[string]interface{}, error) {
+	patternArr, err := c.parsePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]interface{})
+	c.match(c.data(), patternArr, "", result)
+	return result, nil
+}
+
+// parsePattern parses a key pattern of GetAll.
+func (c *Config) parsePattern(pattern string) ([]interface{}, error) {
+	keyArr, err := c.parseKey(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	// 将 name[*] 形式的进行分隔
+	patternArr := make([]interface{}, 0, len(keyArr))
+	for _, v := range keyArr {
+		name, ok := v.(string)
+		if !ok {
+			patternArr = append(patternArr, v)
+			continue
+		}
+		wildcards := 0
+		for name != "[*]" && strings.HasSuffix(name, "[*]") {
+			name = name[:len(name)-3]
+			wildcards++
+		}
+		patternArr = append(patternArr, name)
+		for ; wildcards > 0; wildcards-- {
+			patternArr = append(patternArr, anyIndex{})
+		}
+	}
+	return patternArr, nil
+}
+
+func (c *Config) match(node interface{}, patternArr []interface{}, pKey string, result map[string]interface{}) {
+	if len(patternArr) == 0 {
+		result[pKey] = node
+		return
+	}
+
+	switch p := patternArr[0].(type) {
+	case string:
+		tMap, ok := node.(map[interface{}]interface{})
+		if !ok {
+			return
+		}
+		if p != "*" {
+			if v, ok := tMap[p]; ok {
+				c.match(v, patternArr[1:], joinKey(pKey, p, c.Delimiter), result)
+			}
+			return
+		}
+		for k, v := range tMap {
+			c.match(v, patternArr[1:], joinKey(pKey, fmt.Sprint(k), c.Delimiter), result)
+		}
+
+	case int:
+		tSlice, ok := node.([]interface{})
+		if !ok {
+			return
+		}
+		if index, ok := sliceIndex(p, len(tSlice)); ok {
+			c.match(tSlice[index], patternArr[1:], fmt.Sprintf("%s[%d]", pKey, index), result)
+		}
+
+	case anyIndex:
+		tSlice, ok := node.([]interface{})
+		if !ok {
+			return
+		}
+		for i, v := range tSlice {
+			c.match(v, patternArr[1:], fmt.Sprintf("%s[%d]", pKey, i), result)
+		}
+	}
+}
+
+// Query returns the values matching a JSONPath expression, such as
+// `$.upstreams[?(@.port > 8000)].name`, `$..timeout` or `$.servers[0:2]`.
+// The values are returned with string keyed maps, like AllSettings.
+func (c *Config) Query(expr string) ([]interface{}, error) {
+	x, err := jp.ParseString(expr)
+	if err != nil {
+		return nil, err
+	}
+	return x.Get(stringKeyed(c.data())), nil
+}
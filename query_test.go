@@ -0,0 +1,96 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+const queryYAML = `servers:
+  a: {host: a.internal, port: 80}
+  b: {host: b.internal, port: 8080}
+upstreams:
+  - {name: api, port: 8000, timeout: 1s}
+  - {name: web, port: 9000}
+matrix: [[1, 2], [3]]
+timeout: 5s
+`
+
+func TestGetAll(t *testing.T) {
+	c, err := FromString(queryYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		pattern string
+		want    map[string]interface{}
+	}{
+		{
+			pattern: "servers.*.host",
+			want:    map[string]interface{}{"servers.a.host": "a.internal", "servers.b.host": "b.internal"},
+		},
+		{
+			pattern: "upstreams[*].port",
+			want:    map[string]interface{}{"upstreams[0].port": 8000, "upstreams[1].port": 9000},
+		},
+		{
+			pattern: "upstreams[-1].name",
+			want:    map[string]interface{}{"upstreams[1].name": "web"},
+		},
+		{
+			pattern: "matrix[*][*]",
+			want:    map[string]interface{}{"matrix[0][0]": 1, "matrix[0][1]": 2, "matrix[1][0]": 3},
+		},
+		{
+			pattern: "upstreams[*].timeout",
+			want:    map[string]interface{}{"upstreams[0].timeout": "1s"},
+		},
+		{
+			pattern: "servers.*.user",
+			want:    map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		if got, err := c.GetAll(tt.pattern); err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetAll(%q) = %v, %v, want %v", tt.pattern, got, err, tt.want)
+		}
+	}
+	if _, err := c.GetAll(""); err == nil {
+		t.Error("GetAll() error = nil for an empty pattern, want an error")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	c, err := FromString(queryYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		expr string
+		want []interface{}
+	}{
+		{expr: "$.upstreams[?(@.port > 8500)].name", want: []interface{}{"web"}},
+		{expr: "$..timeout", want: []interface{}{"1s", "5s"}},
+		{expr: "$.upstreams[0:1].name", want: []interface{}{"api"}},
+		{expr: "$.servers.a", want: []interface{}{map[string]interface{}{"host": "a.internal", "port": 80}}},
+	}
+	for _, tt := range tests {
+		got, err := c.Query(tt.expr)
+		if err != nil {
+			t.Errorf("Query(%q) error = %v", tt.expr, err)
+			continue
+		}
+		// 递归查询的顺序不固定
+		if len(got) > 1 {
+			sort.Slice(got, func(i, j int) bool {
+				return got[i].(string) < got[j].(string)
+			})
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Query(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+	if _, err := c.Query("$.[?("); err == nil {
+		t.Error("Query() error = nil for an invalid expression, want an error")
+	}
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+)
+
+// the value replacing secrets in exports
+const redactedValue = "***"
+
+// MarkSecret marks the keys matching the patterns as secrets, their values
+// are replaced with `***` in ToYAML, ToJSON, Explain and Diff. The values
+// decrypted or resolved from secret references are always replaced. Patterns are
+// the same as GetAll, such as `*.password` or `upstreams[*].token`.
+func (c *Config) MarkSecret(patterns ...string) error {
+	for _, pattern := range patterns {
+		patternArr, err := c.parsePattern(pattern)
+		if err != nil {
+			return err
+		}
+		c.secrets = append(c.secrets, patternArr)
+	}
+	return nil
+}
+
+// isSecret reports whether the parsed key or a parent of it matches a
+// secret pattern.
+func (c *Config) isSecret(keyArr []interface{}) bool {
+	for _, patternArr := range c.secrets {
+		if len(patternArr) > len(keyArr) {
+			continue
+		}
+		matched := true
+		for i, p := range patternArr {
+			switch pp := p.(type) {
+			case anyIndex:
+				_, matched = keyArr[i].(int)
+			case string:
+				s, ok := keyArr[i].(string)
+				matched = ok && (pp == "*" || pp == s)
+			default:
+				matched = p == keyArr[i]
+			}
+			if !matched {
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// redact returns a copy of the value at the parsed key with the secrets
+// replaced, v is returned as is if there is no secret.
+func (c *Config) redact(v interface{}, keyArr []interface{}) interface{} {
+	if len(c.secrets) == 0 {
+		return v
+	}
+	if c.isSecret(keyArr) {
+		return redactedValue
+	}
+
+	switch vv := v.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[interface{}]interface{}, len(vv))
+		for k, item := range vv {
+			result[k] = c.redact(item, append(keyArr[:len(keyArr):len(keyArr)], fmt.Sprint(k)))
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(vv))
+		for i, item := range vv {
+			result[i] = c.redact(item, append(keyArr[:len(keyArr):len(keyArr)], i))
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// exported returns the effective config to export, the secrets marked by
+// MarkSecret and the values decrypted or resolved from secret references
+// are redacted.
+func (c *Config) exported(snap *snapshot) interface{} {
+	return c.redact(redactResolved(snap.data, snap.raw), nil)
+}
+
+// redactResolved returns a copy of v with the values differing from those of
+// raw replaced, raw is v before decrypting and resolving the references. v
+// is returned as is if raw is nil.
+func redactResolved(v, raw interface{}) interface{} {
+	if raw == nil {
+		return v
+	}
+	switch vv := v.(type) {
+	case map[interface{}]interface{}:
+		rawMap, _ := raw.(map[interface{}]interface{})
+		result := make(map[interface{}]interface{}, len(vv))
+		for k, item := range vv {
+			result[k] = redactResolved(item, rawMap[k])
+		}
+		return result
+	case []interface{}:
+		rawSlice, _ := raw.([]interface{})
+		result := make([]interface{}, len(vv))
+		for i, item := range vv {
+			var rawItem interface{}
+			if i < len(rawSlice) {
+				rawItem = rawSlice[i]
+			}
+			result[i] = redactResolved(item, rawItem)
+		}
+		return result
+	case string:
+		// 解密或解析引用得到的值
+		if rawStr, ok := raw.(string); ok && rawStr != vv {
+			return redactedValue
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// redactKey is the same as redact, but the key is not parsed.
+func (c *Config) redactKey(v interface{}, key string) interface{} {
+	if len(c.secrets) == 0 {
+		return v
+	}
+	keyArr, err := c.parseKey(key)
+	if err != nil {
+		return v
+	}
+	return c.redact(v, keyArr)
+}
+
+// redactChanges replaces the secret values of changes.
+func (c *Config) redactChanges(changes []Change) []Change {
+	for i, change := range changes {
+		if change.OldValue != nil {
+			changes[i].OldValue = c.redactKey(change.OldValue, change.Key)
+		}
+		if change.NewValue != nil {
+			changes[i].NewValue = c.redactKey(change.NewValue, change.Key)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedactResolved(t *testing.T) {
+	kp, err := NewStaticKeyProvider([]byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := kp.Encrypt("hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := ResolverFunc(func(ref string) (string, error) {
+		return "token-" + ref, nil
+	})
+	yaml := "db: {host: localhost, password: \"" + enc + "\"}\napi: {token: \"vault://api\"}\nname: app\n"
+	c, err := FromString(yaml, WithKeyProvider(kp), WithResolver("vault", resolver))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := c.GetString("db.password"); v != "hunter2" {
+		t.Fatalf("GetString(db.password) = %q, want hunter2", v)
+	}
+
+	y, err := c.ToYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, err := c.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/config", nil))
+
+	outputs := map[string]string{
+		"ToYAML":  string(y),
+		"ToJSON":  string(j),
+		"ToEnv":   strings.Join(c.ToEnv("app"), "\n"),
+		"Handler": rec.Body.String(),
+		"Explain": c.Explain("db.password") + c.Explain("api"),
+	}
+	for name, out := range outputs {
+		for _, plain := range []string{"hunter2", "token-api"} {
+			if strings.Contains(out, plain) {
+				t.Errorf("%s contains the resolved value %q:\n%s", name, plain, out)
+			}
+		}
+		if name != "Explain" && !strings.Contains(out, "localhost") {
+			t.Errorf("%s doesn't contain the plain value localhost:\n%s", name, out)
+		}
+	}
+	if env := strings.Join(c.ToEnv("app"), "\n"); !strings.Contains(env, "APP_DB_PASSWORD=***") || !strings.Contains(env, "APP_API_TOKEN=***") {
+		t.Errorf("ToEnv() = %s, want the resolved values redacted", env)
+	}
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// Reload reads the config files and the remote sources again, then replaces
+// the effective config as a whole and returns the changed keys, such as on
+// SIGHUP. The includes are discovered again, the lazy includes are loaded,
+// and the profile is read again from the environment variable given by
+// WithProfileEnv. The callbacks registered by OnChange are called as well.
+// The config is not changed if any source fails to load, or the new config
+// fails the checks of reloading, see WithSchema.
+func (c *Config) Reload() ([]Change, error) {
+	return c.reload(true)
+}
+
+// reload reads the config files again, and the remote sources if remote
+// is true, then rebuilds the config.
+func (c *Config) reload(remote bool) ([]Change, error) {
+	var layers []*layer
+	if c.configFile != "" {
+		var err error
+		if layers, _, err = c.readFiles(false); err != nil {
+			return nil, err
+		}
+	}
+
+	c.mu.Lock()
+	remotes := append([]*layer(nil), c.remoteLayers...)
+	c.mu.Unlock()
+
+	// 先读取所有配置源，任何一个失败都不修改配置
+	fetched := make(map[*layer]map[interface{}]interface{})
+	cached := make(map[*layer]map[interface{}]interface{})
+	if remote {
+		for _, l := range remotes {
+			if l.provider == nil {
+				continue
+			}
+			data, raw, err := c.fetchRemote(context.Background(), l.source.Name, l.provider, l.health, l.cacheFile != "")
+			if err != nil {
+				return nil, fmt.Errorf("reload remote source `%s` failed: %w", l.source.Name, err)
+			}
+			fetched[l] = data
+			if raw != nil {
+				cached[l] = raw
+			}
+		}
+	}
+
+	changes, err := c.update("reload", func() (func(), error) {
+		profile := c.profile
+		if c.profileEnv != "" {
+			if p := os.Getenv(c.profileEnv); p != "" {
+				profile = p
+			}
+		}
+		if layers == nil {
+			layers = c.fileLayers
+		}
+		if profile != "" && !profileDefined(layers, profile) {
+			return nil, errors.New("profile `" + profile + "` is not defined")
+		}
+
+		oldLayers, oldProfile, oldPending := c.fileLayers, c.profile, atomic.LoadInt32(&c.lazyPending)
+		oldData := make(map[*layer]map[interface{}]interface{}, len(fetched))
+		c.fileLayers, c.profile = layers, profile
+		if c.configFile != "" {
+			atomic.StoreInt32(&c.lazyPending, 0)
+		}
+		for l, data := range fetched {
+			oldData[l], l.data = l.data, data
+		}
+		return func() {
+			c.fileLayers, c.profile = oldLayers, oldProfile
+			atomic.StoreInt32(&c.lazyPending, oldPending)
+			for l, data := range oldData {
+				l.data = data
+			}
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for l, raw := range cached {
+		c.writeCache(l.cacheFile, l.source.Name, raw)
+	}
+	return changes, nil
+}
+
+// update changes the sources of the config with apply under the lock, then
+// rebuilds the config, cause is recorded in the history. If the new config fails the checks of reloading, the
+// change is undone and the current config is kept, the error is reported to
+// the callbacks registered by OnReloadError. Otherwise the new config is
+// used and the callbacks registered by OnChange are called.
+func (c *Config) update(cause string, apply func() (undo func(), err error)) ([]Change, error) {
+	c.mu.Lock()
+	undo, err := apply()
+	if err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	old := c.current()
+	oldEvalErr, oldResolveErr := c.evalErr, c.resolveErr
+	snap := c.build()
+	if err := c.checkReload(snap); err != nil {
+		// 保留之前的有效配置
+		undo()
+		c.evalErr, c.resolveErr = oldEvalErr, oldResolveErr
+		c.reloadErr = err
+		hooks := c.reloadErrorHooks
+		c.mu.Unlock()
+
+		for _, fn := range hooks {
+			fn(err)
+		}
+		return nil, err
+	}
+	c.state.Store(snap)
+	c.reloadErr = nil
+	c.recordRevision(cause)
+	c.mu.Unlock()
+
+	return c.notifyChange(old, snap), nil
+}
+
+// checkReload checks a new effective config before it replaces the current.
+func (c *Config) checkReload(snap *snapshot) error {
+	if c.evalErr != nil {
+		return c.evalErr
+	}
+	if c.resolveErr != nil {
+		return c.resolveErr
+	}
+	if c.schema == nil && len(c.validators) == 0 {
+		return nil
+	}
+	return c.view(snap).validate(c.schema)
+}
+
+// view returns a config reading a given effective config, for checking it
+// before it is used. The access hooks are not called.
+func (c *Config) view(snap *snapshot) *Config {
+	v := &Config{
+		Delimiter:    c.Delimiter,
+		arrayMerge:   c.arrayMerge,
+		strictKeys:   c.strictKeys,
+		coercion:     c.coercion,
+		secrets:      c.secrets,
+		decodeHooks:  c.decodeHooks,
+		weakDecoding: c.weakDecoding,
+		validators:   c.validators,
+		logger:       c.logger,
+	}
+	v.state.Store(snap)
+	return v
+}
+
+// OnReloadError registers a callback called with the error every time the
+// config fails to be updated by a source, such as a new config rejected by
+// the schema given by WithSchema. The current config is kept in that case.
+func (c *Config) OnReloadError(fn func(err error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reloadErrorHooks = append(c.reloadErrorHooks, fn)
+}
+
+// ReloadError returns the error of the last update of the config by a
+// source, nil if it succeeded.
+func (c *Config) ReloadError() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.reloadErr
+}
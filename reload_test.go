@@ -0,0 +1,74 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(doc string) {
+		t.Helper()
+		if err := ioutil.WriteFile(file, []byte(doc), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("server: {port: 8080, host: localhost}\n")
+	c, err := FromFile(file, WithSchema(Schema{"server.port": {Type: TypeInt, Required: true}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changed []Change
+	c.OnChange(func(cs []Change) {
+		changed = cs
+	})
+	var reloadErrs []error
+	c.OnReloadError(func(err error) {
+		reloadErrs = append(reloadErrs, err)
+	})
+
+	write("server: {port: 9090, user: root}\n")
+	changes, err := c.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Key: "server.host", Type: ChangeRemoved, OldValue: "localhost"},
+		{Key: "server.port", Type: ChangeModified, OldValue: 8080, NewValue: 9090},
+		{Key: "server.user", Type: ChangeAdded, NewValue: "root"},
+	}
+	if len(changes) != len(want) || len(changed) != len(want) {
+		t.Fatalf("Reload() = %v, OnChange got %v, want %v", changes, changed, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] || changed[i] != want[i] {
+			t.Errorf("change %d = %+v, %+v, want %+v", i, changes[i], changed[i], want[i])
+		}
+	}
+
+	// 不符合schema时保留原配置
+	write("server: {port: x}\n")
+	if _, err := c.Reload(); err == nil {
+		t.Fatal("Reload() error = nil for a config failing the schema, want an error")
+	}
+	if got, err := c.GetInt("server.port"); err != nil || got != 9090 {
+		t.Errorf("GetInt(server.port) = %d, %v, want 9090", got, err)
+	}
+	if len(reloadErrs) != 1 || c.ReloadError() == nil {
+		t.Errorf("OnReloadError got %v, ReloadError() = %v, want the error", reloadErrs, c.ReloadError())
+	}
+
+	write("server: [\n")
+	if _, err := c.Reload(); err == nil {
+		t.Error("Reload() error = nil for an invalid file, want an error")
+	}
+
+	write("server: {port: 7070}\n")
+	if _, err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ReloadError(); err != nil {
+		t.Errorf("ReloadError() = %v after a successful reload, want nil", err)
+	}
+}
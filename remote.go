@@ -0,0 +1,263 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// RemoteProvider is a config source outside the local files, such as etcd.
+type RemoteProvider interface {
+	// Fetch returns the config tree of the source.
+	Fetch(ctx context.Context) (map[interface{}]interface{}, error)
+}
+
+// RemoteWatcher is a RemoteProvider which can watch for changes.
+type RemoteWatcher interface {
+	RemoteProvider
+
+	// Watch calls update with the new config tree every time the source
+	// changes, until ctx is done or an unrecoverable error occurs.
+	Watch(ctx context.Context, update func(map[interface{}]interface{})) error
+}
+
+// AddRemote merges the config tree of a remote source over the config
+// files, name is reported by Source and Explain. If p is a RemoteWatcher or
+// WithRefreshInterval is given, the config is updated on changes of the
+// source until ctx is done, and the callbacks registered by OnChange are
+// called. The source is not added if the config with it fails the checks
+// of reloading, see WithSchema.
+func (c *Config) AddRemote(ctx context.Context, name string, p RemoteProvider, opts ...RemoteOption) error {
+	var ro remoteOptions
+	for _, opt := range opts {
+		opt(&ro)
+	}
+
+	health := newRemoteHealth(ro)
+	data, raw, err := c.fetchRemote(ctx, name, p, health, ro.cacheFile != "")
+	if err != nil {
+		if ro.cacheFile == "" {
+			return err
+		}
+		// 远程配置源不可用时使用上次的缓存
+		var cacheErr error
+		if data, cacheErr = c.loadCache(ro.cacheFile, name, err); cacheErr != nil {
+			return err
+		}
+	}
+
+	_, err = c.update("remote "+name, func() (func(), error) {
+		for _, l := range c.remoteLayers {
+			if l.source.Name == name {
+				return nil, errors.New("remote source `" + name + "` already exists")
+			}
+		}
+		oldLayers := c.remoteLayers
+		c.remoteLayers = append(c.remoteLayers[:len(c.remoteLayers):len(c.remoteLayers)], &layer{
+			source:     SourceInfo{LayerRemote, name},
+			data:       data,
+			provider:   p,
+			cacheFile:  ro.cacheFile,
+			health:     health,
+			arrayMerge: c.arrayMerge,
+		})
+		return func() {
+			c.remoteLayers = oldLayers
+		}, nil
+	})
+	if err != nil {
+		return err
+	}
+	if raw != nil {
+		c.writeCache(ro.cacheFile, name, raw)
+	}
+
+	if w, ok := p.(RemoteWatcher); ok {
+		go func() {
+			err := w.Watch(ctx, func(data map[interface{}]interface{}) {
+				c.updateRemote(name, data)
+			})
+			if err != nil && ctx.Err() == nil {
+				c.log().Printf("watch remote source `%s` failed: %s", name, err)
+			}
+		}()
+	}
+	if ro.refreshInterval > 0 {
+		go c.refreshRemote(ctx, name, p, health, checksum(data))
+	}
+	return nil
+}
+
+// RemoteOption configures a remote source added by AddRemote.
+type RemoteOption func(*remoteOptions)
+
+type remoteOptions struct {
+	refreshInterval time.Duration
+	refreshJitter   time.Duration
+	cacheFile       string
+
+	retryAttempts    int
+	retryBackoff     time.Duration
+	retryMaxBackoff  time.Duration
+	breakerThreshold int
+	breakerCooldown  time.Duration
+}
+
+// WithRefreshInterval fetches the remote source again at the interval plus
+// a random duration up to jitter, so that the instances of a service don't
+// fetch at the same time. The config is only updated if the content changed.
+func WithRefreshInterval(d, jitter time.Duration) RemoteOption {
+	return func(o *remoteOptions) {
+		o.refreshInterval = d
+		o.refreshJitter = jitter
+	}
+}
+
+// refreshRemote fetches a remote source periodically until ctx is done.
+func (c *Config) refreshRemote(ctx context.Context, name string, p RemoteProvider, h *remoteHealth, sum string) {
+	ro := h.opts
+	for {
+		d := ro.refreshInterval
+		if ro.refreshJitter > 0 {
+			d += time.Duration(rand.Int63n(int64(ro.refreshJitter)))
+		}
+		timer := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		data, raw, err := c.fetchRemote(ctx, name, p, h, ro.cacheFile != "")
+		if err != nil {
+			if ctx.Err() == nil {
+				c.log().Printf("refresh remote source `%s` failed: %s", name, err)
+			}
+			continue
+		}
+		// 内容未变化时不重建配置，与初始的sum一样使用验证后的配置树计算
+		if newSum := checksum(data); newSum != sum {
+			sum = newSum
+			c.replaceRemote(name, data, raw)
+		}
+	}
+}
+
+// checksum returns the hash of a config value.
+func checksum(data interface{}) string {
+	// yaml编码时map的key是排序的
+	b, _ := yaml.Marshal(data)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// updateRemote replaces the config tree of a remote source.
+func (c *Config) updateRemote(name string, data map[interface{}]interface{}) {
+	cacheFile := ""
+	c.mu.Lock()
+	for _, l := range c.remoteLayers {
+		if l.source.Name == name {
+			cacheFile = l.cacheFile
+		}
+	}
+	c.mu.Unlock()
+	var raw map[interface{}]interface{}
+	if cacheFile != "" {
+		raw = cacheCopy(data)
+	}
+
+	if err := c.verifyTree(data, "remote source `"+name+"`"); err != nil {
+		c.log().Printf("update of remote source `%s` is rejected: %s", name, err)
+		return
+	}
+	c.replaceRemote(name, data, raw)
+}
+
+// replaceRemote replaces the config tree of a remote source by a verified
+// tree, raw is the tree to cache if the source is cached.
+func (c *Config) replaceRemote(name string, data, raw map[interface{}]interface{}) {
+	cacheFile := ""
+	_, err := c.update("remote "+name, func() (func(), error) {
+		oldData := make(map[*layer]map[interface{}]interface{})
+		for _, l := range c.remoteLayers {
+			if l.source.Name == name {
+				oldData[l], l.data = l.data, data
+				cacheFile = l.cacheFile
+			}
+		}
+		return func() {
+			for l, data := range oldData {
+				l.data = data
+			}
+		}, nil
+	})
+	if err != nil {
+		c.log().Printf("update of remote source `%s` is rejected: %s", name, err)
+		return
+	}
+	if raw != nil && cacheFile != "" {
+		c.writeCache(cacheFile, name, raw)
+	}
+}
+
+// OnChange registers a callback called with the changed keys every time
+// the config is updated by a source, such as a remote source being watched.
+func (c *Config) OnChange(fn func(changes []Change)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.changeHooks = append(c.changeHooks, fn)
+}
+
+// notifyChange calls the OnChange callbacks if the config is changed from
+// the snapshot old to cur, and returns the changes.
+func (c *Config) notifyChange(old, cur *snapshot) []Change {
+	changes := c.redactChanges(diffTrees(old.data, cur.data, c.Delimiter))
+	if len(changes) == 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	hooks := c.changeHooks
+	c.mu.Unlock()
+	for _, fn := range hooks {
+		fn(changes)
+	}
+	return changes
+}
+
+// TreeFromKeys builds a config tree from flat keys, such as the keys of a
+// key-value store. Keys are split into levels by separator, values are
+// converted to int, float64 or bool if possible.
+//
+//	TreeFromKeys(map[string]string{"db/host": "localhost", "db/port": "3306"}, "/")
+func TreeFromKeys(kvs map[string]string, separator string) map[interface{}]interface{} {
+	keys := make([]string, 0, len(kvs))
+	for key := range kvs {
+		keys = append(keys, key)
+	}
+	// 排序后子key总在父key之后设置
+	sort.Strings(keys)
+
+	data := make(map[interface{}]interface{})
+	for _, key := range keys {
+		path := make([]string, 0)
+		for _, name := range strings.Split(key, separator) {
+			if name != "" {
+				path = append(path, name)
+			}
+		}
+		if len(path) > 0 {
+			setPath(data, path, parseScalar(kvs[key]))
+		}
+	}
+	return data
+}
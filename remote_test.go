@@ -0,0 +1,110 @@
+package config
+
+import (
+	"context"
+	"crypto/ed25519"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// signedProvider serves a signed copy of its tree on every fetch.
+type signedProvider struct {
+	key     ed25519.PrivateKey
+	mu      sync.Mutex
+	port    int
+	fetches int32
+}
+
+func (p *signedProvider) Fetch(ctx context.Context) (map[interface{}]interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	atomic.AddInt32(&p.fetches, 1)
+	data := map[interface{}]interface{}{"server": map[interface{}]interface{}{"port": p.port}}
+	SignTree(data, p.key)
+	return data, nil
+}
+
+func (p *signedProvider) setPort(port int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.port = port
+}
+
+func TestRefreshRemoteSigned(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := FromString("name: app\n", WithSignatureKey(pub), WithHistory(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每次更新配置源都记录一个版本
+	updates := func() int {
+		n := 0
+		for _, rev := range c.History() {
+			if rev.Source == "remote signed" {
+				n++
+			}
+		}
+		return n
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := &signedProvider{key: priv, port: 8080}
+	if err := c.AddRemote(ctx, "signed", p, WithRefreshInterval(time.Millisecond, 0)); err != nil {
+		t.Fatal(err)
+	}
+	waitFetches(t, p, 5)
+	// 内容未变化的刷新不更新配置
+	if n := updates(); n != 1 {
+		t.Errorf("remote source updated %d times by unchanged refreshes, want 0", n-1)
+	}
+
+	p.setPort(9090)
+	waitFetches(t, p, atomic.LoadInt32(&p.fetches)+2)
+	if port, err := c.GetInt("server.port"); err != nil || port != 9090 {
+		t.Errorf("GetInt(server.port) = %d, %v, want 9090", port, err)
+	}
+	if n := updates(); n != 2 {
+		t.Errorf("remote source updated %d times by the changed refresh, want 1", n-1)
+	}
+}
+
+// waitFetches waits until p is fetched n times.
+func waitFetches(t *testing.T, p *signedProvider, n int32) {
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&p.fetches) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("remote source is fetched %d times, want %d", atomic.LoadInt32(&p.fetches), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTreeFromKeys(t *testing.T) {
+	got := TreeFromKeys(map[string]string{
+		"/db/host":  "localhost",
+		"/db/port":  "3306",
+		"debug":     "true",
+		"ratio":     "0.5",
+		"name":      `"42"`,
+		"/":         "root",
+		"db//user/": "root",
+	}, "/")
+	want := map[interface{}]interface{}{
+		"db":    map[interface{}]interface{}{"host": "localhost", "port": 3306, "user": "root"},
+		"debug": true,
+		"ratio": 0.5,
+		"name":  "42",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TreeFromKeys() = %v, want %v", got, want)
+	}
+}
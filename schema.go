@@ -0,0 +1,342 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Type is the expected type of a config value.
+type Type string
+
+// Types that can be declared in a Rule, checked with the getter of the same type.
+const (
+	TypeAny        Type = ""
+	TypeString     Type = "string"
+	TypeInt        Type = "int"
+	TypeFloat      Type = "float"
+	TypeBool       Type = "bool"
+	TypeDuration   Type = "duration"
+	TypeMap        Type = "map"
+	TypeList       Type = "list"
+	TypeStringList Type = "[]string"
+	TypeIntList    Type = "[]int"
+	TypeFloatList  Type = "[]float"
+	TypeBoolList   Type = "[]bool"
+)
+
+// Rule declares the constraints of a config key.
+type Rule struct {
+	Type     Type          `yaml:"type"`
+	Required bool          `yaml:"required"`
+	Min      *float64      `yaml:"min"` // minimum of a number or a duration in seconds, or minimum length of a string, list or map
+	Max      *float64      `yaml:"max"` // maximum of a number or a duration in seconds, or maximum length of a string, list or map
+	Enum     []interface{} `yaml:"enum"`
+	Pattern  string        `yaml:"pattern"` // regular expression matching a string or the elements of a string list
+
+	re *regexp.Regexp // 编译后的Pattern
+}
+
+// Schema maps config keys to their rules.
+// Support multi-level key which concat with '.'.
+type Schema map[string]Rule
+
+// ParseSchema parses a schema from yaml, such as:
+//
+//	server.port: {type: int, required: true, min: 1, max: 65535}
+//	log.level: {type: string, enum: [debug, info, warn]}
+//	name: {type: string, pattern: '^[a-z][a-z0-9-]*$'}
+func ParseSchema(schemaBytes []byte) (Schema, error) {
+	schema := make(Schema)
+	if err := yaml.UnmarshalStrict(trimBOM(schemaBytes), &schema); err != nil {
+		return nil, err
+	}
+	return compileSchema(schema)
+}
+
+// compileSchema returns a copy of schema with the patterns compiled, the
+// first invalid pattern is returned as an error.
+func compileSchema(schema Schema) (Schema, error) {
+	compiled := make(Schema, len(schema))
+	var firstErr error
+	for key, rule := range schema {
+		if rule.Pattern != "" {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("invalid pattern in schema of `%s`: %w", key, err)
+			}
+			rule.re = re
+		}
+		compiled[key] = rule
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return compiled, nil
+}
+
+// WithSchema checks the config against schema and the registered validators
+// as Validate does, when it is created and every time it is updated by a
+// source, such as by Reload, WatchFiles or a remote source being watched.
+// A new config failing the checks is rejected and the current one is kept,
+// see OnReloadError.
+func WithSchema(schema Schema) Option {
+	return func(c *Config) {
+		// 无效的pattern由校验时报告
+		if compiled, err := compileSchema(schema); err == nil {
+			schema = compiled
+		}
+		c.schema = schema
+	}
+}
+
+// Bound returns a pointer to f, for use as Rule.Min and Rule.Max.
+func Bound(f float64) *float64 {
+	return &f
+}
+
+// ValidationError lists all violations found by Validate.
+type ValidationError struct {
+	Errors []error
+}
+
+// Unwrap returns the violations, so that errors.Is and errors.As match any
+// of them, such as ErrUnknownKey.
+func (e *ValidationError) Unwrap() []error {
+	return e.Errors
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return "config validation failed: " + strings.Join(msgs, "; ")
+}
+
+type keyValidator struct {
+	key string
+	fn  func(v interface{}) error
+}
+
+// RegisterValidator registers a function to check the value of a given key,
+// it is executed by Validate if the key exists. The function receives the
+// value as returned by Get, an empty key passes the whole config tree so
+// that rules across top level keys can be checked. The validators are also
+// run when the config is updated by a source, see WithSchema.
+func (c *Config) RegisterValidator(key string, fn func(v interface{}) error) {
+	c.validators = append(c.validators, keyValidator{key, fn})
+}
+
+// Validate checks the config against the schema, then runs the registered
+// validators. All violations are returned together in a *ValidationError.
+// schema may be nil if only the registered validators should be run.
+// The keys read by Validate are not reported to OnAccess.
+func (c *Config) Validate(schema Schema) error {
+	// 通过视图读取，校验读取的key不计为已使用
+	return c.view(c.snap()).validate(schema)
+}
+
+func (c *Config) validate(schema Schema) error {
+	keys := make([]string, 0, len(schema))
+	for key := range schema {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		errs = append(errs, c.validateKey(key, schema[key])...)
+	}
+
+	if c.strictKeys && schema != nil {
+		unknown := c.unknownKeys(schema)
+		sort.Strings(unknown)
+		for _, key := range unknown {
+			errs = append(errs, unknownKeyError(key))
+		}
+	}
+
+	for _, validator := range c.validators {
+		var v interface{} = c.data()
+		if validator.key != "" {
+			var err error
+			if v, err = c.Get(validator.key); err != nil {
+				continue
+			}
+		}
+		if err := validator.fn(v); err != nil {
+			var verr *ValidationError
+			if validator.key == "" && errors.As(err, &verr) {
+				// 校验函数返回的多个错误逐个列出
+				errs = append(errs, verr.Errors...)
+			} else if validator.key == "" {
+				errs = append(errs, err)
+			} else {
+				errs = append(errs, fmt.Errorf("value of `%s` is invalid: %w", validator.key, err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{errs}
+	}
+	return nil
+}
+
+func (c *Config) validateKey(key string, rule Rule) []error {
+	v, err := c.Get(key)
+	if err != nil {
+		if rule.Required {
+			return []error{requiredError(key)}
+		}
+		return nil
+	}
+
+	// 使用getter检测类型，保证与读取时的类型转换一致
+	var typed interface{}
+	switch rule.Type {
+	case TypeString:
+		typed, err = c.GetString(key)
+	case TypeInt:
+		typed, err = c.GetInt(key)
+	case TypeFloat:
+		typed, err = c.GetFloat(key)
+	case TypeBool:
+		typed, err = c.GetBool(key)
+	case TypeDuration:
+		typed, err = c.GetDuration(key)
+	case TypeMap:
+		typed, err = c.GetMap(key)
+	case TypeList:
+		if _, ok := v.([]interface{}); !ok {
+			err = fmt.Errorf("value of `%s` is not a list", key)
+		}
+		typed = v
+	case TypeStringList:
+		typed, err = c.GetStringArray(key)
+	case TypeIntList:
+		typed, err = c.GetIntArray(key)
+	case TypeFloatList:
+		typed, err = c.GetFloatArray(key)
+	case TypeBoolList:
+		typed, err = c.GetBoolArray(key)
+	case TypeAny:
+		typed = v
+	default:
+		err = fmt.Errorf("unknown type `%s` in schema of `%s`", rule.Type, key)
+	}
+	if err != nil {
+		return []error{err}
+	}
+
+	// 错误信息中的值不包含密钥
+	got := formatValue(c.redactKey(typed, key))
+	var errs []error
+	if rule.Min != nil || rule.Max != nil {
+		if n, ok := measure(typed); ok {
+			if rule.Min != nil && n < *rule.Min {
+				errs = append(errs, fmt.Errorf("value of `%s` should not be less than %v, got %s", key, *rule.Min, got))
+			}
+			if rule.Max != nil && n > *rule.Max {
+				errs = append(errs, fmt.Errorf("value of `%s` should not be greater than %v, got %s", key, *rule.Max, got))
+			}
+		}
+	}
+	if len(rule.Enum) > 0 && !inEnum(typed, rule.Enum) {
+		errs = append(errs, fmt.Errorf("value of `%s` should be one of %v, got %s", key, rule.Enum, got))
+	}
+	if rule.Pattern != "" {
+		errs = append(errs, c.matchPattern(key, typed, rule)...)
+	}
+	return errs
+}
+
+// matchPattern checks a string or the elements of a string list against the
+// regular expression of a rule, compiled by ParseSchema or WithSchema.
+func (c *Config) matchPattern(key string, v interface{}, rule Rule) []error {
+	pattern, re := rule.Pattern, rule.re
+	if re == nil || re.String() != pattern {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return []error{fmt.Errorf("invalid pattern in schema of `%s`: %w", key, err)}
+		}
+	}
+
+	var errs []error
+	switch vv := v.(type) {
+	case string:
+		if !re.MatchString(vv) {
+			errs = append(errs, fmt.Errorf("value of `%s` should match `%s`, got %s", key, pattern, formatValue(c.redactKey(vv, key))))
+		}
+	case []string:
+		for i, item := range vv {
+			if !re.MatchString(item) {
+				itemKey := fmt.Sprintf("%s[%d]", key, i)
+				errs = append(errs, fmt.Errorf("value of `%s` should match `%s`, got %s", itemKey, pattern, formatValue(c.redactKey(item, itemKey))))
+			}
+		}
+	}
+	return errs
+}
+
+// measure returns the number to compare with a range: numbers themselves,
+// durations in seconds, lengths of strings, lists and maps.
+func measure(v interface{}) (float64, bool) {
+	switch vv := v.(type) {
+	case int:
+		return float64(vv), true
+	case float64:
+		return vv, true
+	case time.Duration:
+		return vv.Seconds(), true
+	case string:
+		return float64(len(vv)), true
+	case []interface{}:
+		return float64(len(vv)), true
+	case []string:
+		return float64(len(vv)), true
+	case []int:
+		return float64(len(vv)), true
+	case []float64:
+		return float64(len(vv)), true
+	case []bool:
+		return float64(len(vv)), true
+	case map[string]interface{}:
+		return float64(len(vv)), true
+	case map[interface{}]interface{}:
+		return float64(len(vv)), true
+	default:
+		return 0, false
+	}
+}
+
+func inEnum(v interface{}, enum []interface{}) bool {
+	for _, item := range enum {
+		if fmt.Sprint(normalizeValue(item)) == fmt.Sprint(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// MustHave checks that all the given keys exist, every missing key is
+// listed in the returned *ValidationError.
+func (c *Config) MustHave(keys ...string) error {
+	var errs []error
+	for _, key := range keys {
+		if _, err := c.Get(key); err != nil {
+			errs = append(errs, requiredError(key))
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{errs}
+	}
+	return nil
+}
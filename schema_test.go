@@ -0,0 +1,173 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidateDuration(t *testing.T) {
+	schema, err := ParseSchema([]byte("timeout: {type: duration, min: 1, max: 60}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		yaml    string
+		wantErr bool
+	}{
+		{"timeout: 30s\n", false},
+		{"timeout: 1s\n", false},
+		{"timeout: 500ms\n", true},
+		{"timeout: 2m\n", true},
+	}
+	for _, tt := range tests {
+		c, err := FromString(tt.yaml)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Validate(schema); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%q) error = %v, wantErr %v", tt.yaml, err, tt.wantErr)
+		}
+	}
+
+	if n, ok := measure(90 * time.Second); !ok || n != 90 {
+		t.Errorf("measure(90s) = %v, %v, want 90, true", n, ok)
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	schema, err := ParseSchema([]byte("name: {type: string, pattern: '^[a-z]+$'}\ntags: {type: '[]string', pattern: '^[a-z]+$'}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema["name"].re == nil {
+		t.Error("ParseSchema() doesn't compile the pattern")
+	}
+	if _, err := ParseSchema([]byte("name: {pattern: '['}\n")); err == nil {
+		t.Error("ParseSchema() error = nil, want an error for the invalid pattern")
+	}
+
+	tests := []struct {
+		schema  Schema
+		yaml    string
+		wantErr bool
+	}{
+		{schema, "name: abc\ntags: [a, b]\n", false},
+		{schema, "name: Abc\n", true},
+		{schema, "tags: [a, B]\n", true},
+		{Schema{"name": {Pattern: "^[0-9]+$"}}, "name: '123'\n", false},
+		{Schema{"name": {Pattern: "["}}, "name: abc\n", true},
+	}
+	for _, tt := range tests {
+		c, err := FromString(tt.yaml)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Validate(tt.schema); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%q) error = %v, wantErr %v", tt.yaml, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	schema, err := ParseSchema([]byte(`server.port: {type: int, required: true, min: 1, max: 65535}
+server.host: {type: string}
+log.level: {type: string, enum: [debug, info, warn]}
+hosts: {type: '[]string', min: 1}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		yaml   string
+		errors int
+	}{
+		{name: "valid", yaml: "server: {port: 8080, host: localhost}\nlog: {level: info}\nhosts: [a]\n"},
+		{name: "optional keys", yaml: "server: {port: 1}\n"},
+		{name: "required", yaml: "server: {host: localhost}\n", errors: 1},
+		{name: "all violations", yaml: "server: {port: 70000, host: 1}\nlog: {level: trace}\nhosts: []\n", errors: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := FromString(tt.yaml)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = c.Validate(schema)
+			if tt.errors == 0 {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) || len(verr.Errors) != tt.errors {
+				t.Errorf("Validate() error = %v, want %d violations", err, tt.errors)
+			}
+		})
+	}
+
+	for _, doc := range []string{"a: {type: int, unknown: 1}\n", "a: {pattern: '['}\n", "a: [\n"} {
+		if _, err := ParseSchema([]byte(doc)); err == nil {
+			t.Errorf("ParseSchema(%q) error = nil, want an error", doc)
+		}
+	}
+}
+
+func TestRegisterValidator(t *testing.T) {
+	c, err := FromString("pool: {min: 10, max: 5}\nname: app\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 跨key的规则检查整个配置
+	c.RegisterValidator("", func(v interface{}) error {
+		pool := v.(map[interface{}]interface{})["pool"].(map[interface{}]interface{})
+		if pool["min"].(int) > pool["max"].(int) {
+			return errors.New("pool.min should not be greater than pool.max")
+		}
+		return nil
+	})
+	c.RegisterValidator("name", func(v interface{}) error {
+		if v != "app" {
+			return errors.New("name should be app")
+		}
+		return nil
+	})
+	c.RegisterValidator("missing", func(v interface{}) error {
+		return errors.New("validator of a missing key is run")
+	})
+	var verr *ValidationError
+	if err := c.Validate(nil); !errors.As(err, &verr) || len(verr.Errors) != 1 {
+		t.Errorf("Validate() error = %v, want the violation of pool", err)
+	}
+
+	// 校验器也检查更新
+	if err := c.SetWithSource("pool.max", 20, SourceInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Validate(nil); err != nil {
+		t.Errorf("Validate() error = %v after fixing pool.max", err)
+	}
+	if err := c.SetWithSource("name", "api", SourceInfo{}); err == nil {
+		t.Error("SetWithSource() error = nil for a value rejected by a validator, want an error")
+	}
+	if got, _ := c.GetString("name"); got != "app" {
+		t.Errorf("GetString(name) = %q, want app kept", got)
+	}
+}
+
+func TestMustHave(t *testing.T) {
+	c, err := FromString("server: {port: 8080}\nnull: ~\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MustHave("server.port"); err != nil {
+		t.Errorf("MustHave() error = %v", err)
+	}
+	err = c.MustHave("server.port", "server.host", "null")
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 2 || !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("MustHave() error = %v, want 2 missing keys", err)
+	}
+}
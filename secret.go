@@ -0,0 +1,208 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Resolver resolves the secret references of a scheme, such as
+// `vault://secret/data/db#password`. The reference without `scheme://` is
+// passed to Resolve.
+type Resolver interface {
+	Resolve(ref string) (string, error)
+}
+
+// ResolverFunc is an adapter to use ordinary functions as resolvers.
+type ResolverFunc func(ref string) (string, error)
+
+// Resolve calls f(ref).
+func (f ResolverFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+// EnvResolver resolves `env://NAME` to the value of the environment variable.
+var EnvResolver Resolver = ResolverFunc(func(ref string) (string, error) {
+	v, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", errors.New("environment variable `" + ref + "` is not set")
+	}
+	return v, nil
+})
+
+// FileResolver resolves `file:///path` to the content of the file, the
+// trailing newline is removed.
+var FileResolver Resolver = ResolverFunc(func(ref string) (string, error) {
+	b, err := ioutil.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+})
+
+// NewSecretResolver create a resolver of a secret manager, fetch returns the
+// value of a secret by name. A reference `name#field` selects a field of a
+// JSON secret, such as `mysecret#username`. Secrets are cached for ttl so
+// that the fields of a secret are fetched once, 0 disables the cache.
+func NewSecretResolver(fetch func(name string) (string, error), ttl time.Duration) Resolver {
+	return &secretResolver{fetch: fetch, ttl: ttl, cache: make(map[string]cachedSecret)}
+}
+
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+type secretResolver struct {
+	fetch func(name string) (string, error)
+	ttl   time.Duration
+	mu    sync.Mutex
+	cache map[string]cachedSecret
+}
+
+// Resolve implements Resolver.
+func (r *secretResolver) Resolve(ref string) (string, error) {
+	name, field := ref, ""
+	if pos := strings.LastIndexByte(ref, '#'); pos != -1 {
+		name, field = ref[:pos], ref[pos+1:]
+	}
+
+	value, err := r.get(name)
+	if err != nil || field == "" {
+		return value, err
+	}
+
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", errors.New("secret `" + name + "` is not a JSON object")
+	}
+	v, ok := fields[field]
+	if !ok {
+		return "", errors.New("field `" + field + "` not found in secret `" + name + "`")
+	}
+	if str, ok := v.(string); ok {
+		return str, nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// get returns the value of a secret, from the cache if not expired.
+func (r *secretResolver) get(name string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cached, ok := r.cache[name]; ok && time.Now().Before(cached.expires) {
+		return cached.value, nil
+	}
+	value, err := r.fetch(name)
+	if err != nil {
+		return "", err
+	}
+	if r.ttl > 0 {
+		r.cache[name] = cachedSecret{value, time.Now().Add(r.ttl)}
+	}
+	return value, nil
+}
+
+// WithResolver resolves the secret references of the scheme with the
+// specified resolver when the config is loaded, for example:
+//
+//	config.WithResolver("env", config.EnvResolver)
+//	config.WithResolver("file", config.FileResolver)
+func WithResolver(scheme string, r Resolver) Option {
+	return func(c *Config) {
+		if c.resolvers == nil {
+			c.resolvers = make(map[string]Resolver)
+		}
+		c.resolvers[scheme] = r
+	}
+}
+
+// ToYAMLRaw returns the effective config encoded as yaml, the encrypted
+// values and the secret references are kept as they are in the files.
+func (c *Config) ToYAMLRaw() ([]byte, error) {
+	raw := c.snap().raw
+	if raw == nil {
+		return c.ToYAML()
+	}
+	return yaml.Marshal(c.redact(raw, nil))
+}
+
+// resolveString returns the plain text of an encrypted value or a secret
+// reference, ok is false if s is neither.
+func (c *Config) resolveString(s string) (plain string, ok bool, err error) {
+	if c.keyProvider != nil {
+		if text, ok := cipherText(s); ok {
+			plain, err := c.keyProvider.Decrypt(text)
+			return plain, true, err
+		}
+	}
+	if pos := strings.Index(s, "://"); pos > 0 {
+		if r, ok := c.resolvers[s[:pos]]; ok {
+			plain, err := r.Resolve(s[pos+3:])
+			return plain, true, err
+		}
+	}
+	return "", false, nil
+}
+
+// resolveValue returns a copy of v with the encrypted values and the
+// secret references resolved. The values failed to resolve are kept, the
+// first error is returned.
+func (c *Config) resolveValue(v interface{}, key string) (interface{}, error) {
+	switch vv := v.(type) {
+	case string:
+		// 缓存解析结果，避免每次重建配置都调用KMS等外部服务
+		if plain, ok := c.resolved[vv]; ok {
+			return plain, nil
+		}
+		plain, ok, err := c.resolveString(vv)
+		if !ok {
+			return v, nil
+		}
+		if err != nil {
+			return v, errors.New("resolve value of `" + key + "` failed: " + err.Error())
+		}
+		if c.resolved == nil {
+			c.resolved = make(map[string]string)
+		}
+		c.resolved[vv] = plain
+		return plain, nil
+
+	case map[interface{}]interface{}:
+		var firstErr error
+		result := make(map[interface{}]interface{}, len(vv))
+		for k, item := range vv {
+			plain, err := c.resolveValue(item, joinKey(key, fmt.Sprint(k), c.Delimiter))
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			result[k] = plain
+		}
+		return result, firstErr
+
+	case []interface{}:
+		var firstErr error
+		result := make([]interface{}, len(vv))
+		for i, item := range vv {
+			plain, err := c.resolveValue(item, key+"["+strconv.Itoa(i)+"]")
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			result[i] = plain
+		}
+		return result, firstErr
+
+	default:
+		return v, nil
+	}
+}
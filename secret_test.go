@@ -0,0 +1,97 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithResolver(t *testing.T) {
+	t.Setenv("TEST_DB_USER", "root")
+	file := filepath.Join(t.TempDir(), "password")
+	if err := ioutil.WriteFile(file, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	yaml := "db: {user: \"env://TEST_DB_USER\", password: \"file://" + file + "\", host: \"http://localhost\"}\n"
+	c, err := FromString(yaml, WithResolver("env", EnvResolver), WithResolver("file", FileResolver))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"db.user":     "root",
+		"db.password": "s3cret",
+		"db.host":     "http://localhost", // 未注册的scheme保留原样
+	}
+	for key, want := range tests {
+		if got, err := c.GetString(key); err != nil || got != want {
+			t.Errorf("GetString(%q) = %q, %v, want %q", key, got, err, want)
+		}
+	}
+	raw, err := c.ToYAMLRaw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(raw); !strings.Contains(s, "env://TEST_DB_USER") || strings.Contains(s, "s3cret") {
+		t.Errorf("ToYAMLRaw() = %q, want the references kept", s)
+	}
+
+	if _, err := FromString("a: \"env://TEST_MISSING_VAR\"\n", WithResolver("env", EnvResolver)); err == nil {
+		t.Error("FromString() error = nil for an unset environment variable, want an error")
+	}
+	if _, err := FromString("a: \"file:///missing/file\"\n", WithResolver("file", FileResolver)); err == nil {
+		t.Error("FromString() error = nil for a missing file, want an error")
+	}
+}
+
+func TestNewSecretResolver(t *testing.T) {
+	fetches := 0
+	secrets := map[string]string{
+		"db":    `{"username": "root", "port": 3306}`,
+		"token": "abc",
+	}
+	r := NewSecretResolver(func(name string) (string, error) {
+		fetches++
+		v, ok := secrets[name]
+		if !ok {
+			return "", errors.New("secret `" + name + "` not found")
+		}
+		return v, nil
+	}, time.Minute)
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{ref: "db#username", want: "root"},
+		{ref: "db#port", want: "3306"},
+		{ref: "token", want: "abc"},
+		{ref: "db#password", wantErr: true},
+		{ref: "token#field", wantErr: true},
+		{ref: "missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := r.Resolve(tt.ref)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Resolve(%q) = %q, %v, want %q", tt.ref, got, err, tt.want)
+		}
+	}
+	// 缓存的secret只获取一次
+	if fetches != 3 {
+		t.Errorf("fetches = %d, want 3", fetches)
+	}
+
+	fetches = 0
+	r = NewSecretResolver(func(name string) (string, error) {
+		fetches++
+		return "v", nil
+	}, 0)
+	r.Resolve("a")
+	r.Resolve("a")
+	if fetches != 2 {
+		t.Errorf("fetches without cache = %d, want 2", fetches)
+	}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ErrInvalidSignature is reported for a config file or remote document
+// that is not signed or whose signature doesn't match.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// the key of the signature embedded in a config document
+const signatureKey = "signature"
+
+// WithSignatureKey verifies the signatures of the config files and the
+// documents of the remote sources with an ed25519 public key, unsigned
+// documents and documents with invalid signatures are rejected.
+//
+// A file is signed by a detached signature in the file of the same name
+// with the `.sig` suffix, which contains the base64 encoded signature of
+// the content, or by a `signature` key embedded by SignDocument. Remote
+// documents are signed by the embedded key set by SignTree. Included files
+// templated with variables must be signed by detached signatures. Configs
+// created from strings or maps in code are not verified.
+func WithSignatureKey(key ed25519.PublicKey) Option {
+	return func(c *Config) {
+		c.signatureKey = key
+	}
+}
+
+// SignDocument signs a yaml config document with an ed25519 private key,
+// and returns the document with the signature embedded.
+func SignDocument(doc []byte, key ed25519.PrivateKey) ([]byte, error) {
+	data := make(map[interface{}]interface{})
+	if err := yaml.Unmarshal(trimBOM(doc), &data); err != nil {
+		return nil, err
+	}
+	if _, ok := data[signatureKey]; ok {
+		return nil, errors.New("config document is already signed")
+	}
+	SignTree(data, key)
+
+	signed := make([]byte, 0, len(doc)+100)
+	signed = append(signed, doc...)
+	if len(signed) > 0 && signed[len(signed)-1] != '\n' {
+		signed = append(signed, '\n')
+	}
+	return append(signed, signatureKey+": "+data[signatureKey].(string)+"\n"...), nil
+}
+
+// SignTree signs a config tree with an ed25519 private key, the signature is
+// set as the `signature` key of the tree, such as for the documents of a
+// remote source.
+func SignTree(data map[interface{}]interface{}, key ed25519.PrivateKey) {
+	delete(data, signatureKey)
+	sig := ed25519.Sign(key, signedContent(data))
+	data[signatureKey] = base64.StdEncoding.EncodeToString(sig)
+}
+
+// signedContent returns the content signed for an embedded signature, the
+// tree is encoded as yaml, in which the keys of maps are sorted.
+func signedContent(data map[interface{}]interface{}) []byte {
+	b, _ := yaml.Marshal(data)
+	return b
+}
+
+// parseSigned decodes a config file like parseFile, the signature of the
+// file is verified if WithSignatureKey is given.
+func (c *Config) parseSigned(cfgBytes []byte, file string) (map[interface{}]interface{}, error) {
+	detached, err := c.verifyFile(cfgBytes, file)
+	if err != nil {
+		return nil, err
+	}
+	cfgData, err := c.parseFile(cfgBytes, file)
+	if err != nil {
+		return nil, err
+	}
+	if c.signatureKey != nil && !detached {
+		if err := c.verifyTree(cfgData, "config `"+file+"`"); err != nil {
+			return nil, err
+		}
+	}
+	return cfgData, nil
+}
+
+// verifyFile verifies the detached signature of a config file, it returns
+// false if the file has no detached signature.
+func (c *Config) verifyFile(cfgBytes []byte, file string) (bool, error) {
+	if c.signatureKey == nil {
+		return false, nil
+	}
+	b, err := ioutil.ReadFile(file + ".sig")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil || !ed25519.Verify(c.signatureKey, cfgBytes, sig) {
+		return false, fmt.Errorf("%w: signature of config `%s` does not match", ErrInvalidSignature, file)
+	}
+	return true, nil
+}
+
+// verifyTree verifies and removes the signature embedded in a config tree,
+// name describes the tree in errors.
+func (c *Config) verifyTree(data map[interface{}]interface{}, name string) error {
+	if c.signatureKey == nil {
+		return nil
+	}
+	str, ok := data[signatureKey].(string)
+	if !ok {
+		return fmt.Errorf("%w: %s is not signed", ErrInvalidSignature, name)
+	}
+	delete(data, signatureKey)
+	sig, err := base64.StdEncoding.DecodeString(str)
+	if err != nil || !ed25519.Verify(c.signatureKey, signedContent(data), sig) {
+		return fmt.Errorf("%w: signature of %s does not match", ErrInvalidSignature, name)
+	}
+	return nil
+}
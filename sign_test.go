@@ -0,0 +1,118 @@
+package config
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithSignatureKey(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := []byte("# app\nserver: {port: 8080}\n")
+	signed, err := SignDocument(doc, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SignDocument(signed, priv); err == nil {
+		t.Error("SignDocument() error = nil for a signed document, want an error")
+	}
+	detached := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, doc)) + "\n"
+
+	tests := []struct {
+		name    string
+		doc     []byte
+		sig     string // 分离的签名，为空时没有.sig文件
+		invalid bool
+	}{
+		{name: "embedded", doc: signed},
+		{name: "detached", doc: doc, sig: detached},
+		{name: "unsigned", doc: doc, invalid: true},
+		{name: "tampered", doc: []byte(strings.Replace(string(signed), "8080", "9090", 1)), invalid: true},
+		{name: "detached tampered", doc: []byte("server: {port: 9090}\n"), sig: detached, invalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "config.yaml")
+			if err := ioutil.WriteFile(file, tt.doc, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.sig != "" {
+				if err := ioutil.WriteFile(file+".sig", []byte(tt.sig), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			c, err := FromFile(file, WithSignatureKey(pub))
+			if tt.invalid {
+				if !errors.Is(err, ErrInvalidSignature) {
+					t.Errorf("FromFile() error = %v, want ErrInvalidSignature", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err := c.GetInt("server.port"); err != nil || got != 8080 {
+				t.Errorf("GetInt(server.port) = %d, %v, want 8080", got, err)
+			}
+			// 签名不出现在配置中
+			if c.Has("signature") {
+				t.Error("Has(signature) = true, want the signature removed")
+			}
+		})
+	}
+
+	// 代码中创建的配置不验证
+	if _, err := FromString("a: 1\n", WithSignatureKey(pub)); err != nil {
+		t.Errorf("FromString() error = %v, want configs from strings not verified", err)
+	}
+}
+
+func TestSignTree(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := FromString("", WithSignatureKey(pub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := map[interface{}]interface{}{"port": 8080}
+	SignTree(data, priv)
+	p := &treeProvider{data: data}
+	if err := c.AddRemote(context.Background(), "signed", p); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetInt("port"); err != nil || got != 8080 {
+		t.Errorf("GetInt(port) = %d, %v, want 8080", got, err)
+	}
+
+	_, other, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = map[interface{}]interface{}{"port": 9090}
+	SignTree(data, other)
+	if err := c.AddRemote(context.Background(), "other", &treeProvider{data: data}); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("AddRemote() error = %v, want ErrInvalidSignature", err)
+	}
+	if err := c.AddRemote(context.Background(), "unsigned", &flakyProvider{}); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("AddRemote() error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+// treeProvider serves a copy of its tree on every fetch.
+type treeProvider struct {
+	data map[interface{}]interface{}
+}
+
+func (p *treeProvider) Fetch(ctx context.Context) (map[interface{}]interface{}, error) {
+	return copyTree(p.data).(map[interface{}]interface{}), nil
+}
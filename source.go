@@ -0,0 +1,265 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v2"
+)
+
+// Format is the format of a config document loaded by a Source, it is the
+// extension of a codec registered by RegisterCodec without the dot.
+type Format string
+
+// Formats of config documents.
+const (
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+)
+
+// Source is a pluggable config backend, such as a proprietary config
+// service. It is added by AddSource and merged like a remote source.
+type Source interface {
+	// Load returns the config document of the source and its format.
+	Load(ctx context.Context) ([]byte, Format, error)
+}
+
+// SourceWatcher is a Source which can watch for changes.
+type SourceWatcher interface {
+	Source
+
+	// Watch calls changed every time the source changes, until ctx is done
+	// or an unrecoverable error occurs. The source is loaded again by Load.
+	Watch(ctx context.Context, changed func()) error
+}
+
+// AddSource merges the config document of a source over the config files,
+// name is reported by Source and Explain. The options and the updates are
+// the same as AddRemote.
+func (c *Config) AddSource(ctx context.Context, name string, s Source, opts ...RemoteOption) error {
+	p := c.sourceProvider(name, s)
+	return c.AddRemote(ctx, name, p, opts...)
+}
+
+// sourceProvider adapts a Source to a RemoteProvider.
+func (c *Config) sourceProvider(name string, s Source) RemoteProvider {
+	if rs, ok := s.(*remoteSource); ok {
+		return rs.provider
+	}
+	p := &sourceProvider{config: c, name: name, source: s}
+	if w, ok := s.(SourceWatcher); ok {
+		return &sourceWatcher{p, w}
+	}
+	return p
+}
+
+type sourceProvider struct {
+	config *Config
+	name   string
+	source Source
+}
+
+// Fetch implements RemoteProvider.
+func (p *sourceProvider) Fetch(ctx context.Context) (map[interface{}]interface{}, error) {
+	b, format, err := p.source.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.config.parseFormat(b, format, "source `"+p.name+"`")
+}
+
+type sourceWatcher struct {
+	*sourceProvider
+	watcher SourceWatcher
+}
+
+// Watch implements RemoteWatcher.
+func (w *sourceWatcher) Watch(ctx context.Context, update func(map[interface{}]interface{})) error {
+	return w.watcher.Watch(ctx, func() {
+		data, err := w.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.config.log().Printf("load source `%s` failed: %s", w.name, err)
+			}
+			return
+		}
+		update(data)
+	})
+}
+
+// parseFormat decodes a config document by the codec registered for a
+// given format, name describes the document in errors.
+func (c *Config) parseFormat(b []byte, format Format, name string) (map[interface{}]interface{}, error) {
+	if format == "" {
+		format = FormatYAML
+	}
+	codec, ok := lookupCodec("." + string(format))
+	if !ok {
+		return nil, fmt.Errorf("unsupported format `%s` of %s", format, name)
+	}
+	return c.parseCodec(codec, b, name)
+}
+
+// formatOf returns the format of a file by its extension.
+func formatOf(file string) Format {
+	ext := filepath.Ext(file)
+	if _, ok := lookupCodec(ext); ok {
+		return Format(strings.ToLower(ext[1:]))
+	}
+	return FormatYAML
+}
+
+// FileSource returns a Source reading a file, the format is the extension
+// of the file if a codec is registered for it, otherwise yaml. The file is
+// watched for changes, bursts of events are coalesced like WatchFiles.
+func FileSource(file string) Source {
+	return &fileSource{file: file}
+}
+
+type fileSource struct {
+	file string
+}
+
+func (s *fileSource) Load(ctx context.Context) ([]byte, Format, error) {
+	b, err := ioutil.ReadFile(s.file)
+	if err != nil {
+		return nil, "", err
+	}
+	return b, formatOf(s.file), nil
+}
+
+func (s *fileSource) Watch(ctx context.Context, changed func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// 监视文件所在的目录，文件被替换后也能收到事件
+	file := filepath.Clean(s.file)
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		return err
+	}
+	b := NewDebouncer(defaultDebounce)
+	defer b.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) == file && event.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Rename) != 0 {
+				b.Reset()
+			}
+		case <-b.C:
+			b.C = nil
+			changed()
+		}
+	}
+}
+
+// EnvSource returns a Source reading the environment variables with a
+// given prefix, the rest of the name is lower cased and split into levels
+// by `_`, values are converted like TreeFromKeys.
+//
+//	EnvSource("APP") // APP_DB_HOST=localhost gives db.host
+func EnvSource(prefix string) Source {
+	return &envSource{prefix: prefix}
+}
+
+type envSource struct {
+	prefix string
+}
+
+func (s *envSource) Load(ctx context.Context) ([]byte, Format, error) {
+	prefix := envName(s.prefix) + "_"
+	kvs := make(map[string]string)
+	for _, kv := range os.Environ() {
+		pos := strings.Index(kv, "=")
+		if pos <= 0 || !strings.HasPrefix(kv[:pos], prefix) {
+			continue
+		}
+		kvs[strings.ToLower(kv[len(prefix):pos])] = kv[pos+1:]
+	}
+	b, err := yaml.Marshal(TreeFromKeys(kvs, "_"))
+	if err != nil {
+		return nil, "", err
+	}
+	return b, FormatYAML, nil
+}
+
+// HTTPSource returns a Source reading a config document by a GET request,
+// responses with a JSON content type are JSON and all others are yaml. If
+// client is nil, http.DefaultClient is used.
+func HTTPSource(url string, client *http.Client) Source {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpSource{url: url, client: client}
+}
+
+type httpSource struct {
+	url    string
+	client *http.Client
+}
+
+func (s *httpSource) Load(ctx context.Context) ([]byte, Format, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", "application/yaml, application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", errors.New("load `" + s.url + "` failed: " + resp.Status)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	if strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		return b, FormatJSON, nil
+	}
+	return b, FormatYAML, nil
+}
+
+// RemoteSource adapts a RemoteProvider to a Source, such as the providers
+// of etcd or consul, AddSource uses the provider directly.
+func RemoteSource(p RemoteProvider) Source {
+	return &remoteSource{provider: p}
+}
+
+type remoteSource struct {
+	provider RemoteProvider
+}
+
+func (s *remoteSource) Load(ctx context.Context) ([]byte, Format, error) {
+	data, err := s.provider.Fetch(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	b, err := yaml.Marshal(data)
+	if err != nil {
+		return nil, "", err
+	}
+	return b, FormatYAML, nil
+}
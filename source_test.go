@@ -0,0 +1,139 @@
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileSource(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "remote.json")
+	if err := ioutil.WriteFile(file, []byte(`{"server": {"port": 8080}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := FromString("server: {port: 80, host: localhost}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := c.AddSource(ctx, "file", FileSource(file)); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetInt("server.port"); err != nil || got != 8080 {
+		t.Errorf("GetInt(server.port) = %d, %v, want 8080", got, err)
+	}
+	if got, want := c.Source("server.port"), (SourceInfo{LayerRemote, "file"}); got != want {
+		t.Errorf("Source(server.port) = %v, want %v", got, want)
+	}
+	if got, _ := c.GetString("server.host"); got != "localhost" {
+		t.Errorf("GetString(server.host) = %q, want localhost merged from the string", got)
+	}
+
+	changed := make(chan struct{}, 10)
+	c.OnChange(func([]Change) {
+		changed <- struct{}{}
+	})
+	// 等待开始监视后修改文件
+	time.Sleep(50 * time.Millisecond)
+	if err := ioutil.WriteFile(file, []byte(`{"server": {"port": 9090}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the change of the file is not watched")
+	}
+	if got, err := c.GetInt("server.port"); err != nil || got != 9090 {
+		t.Errorf("GetInt(server.port) after the change = %d, %v, want 9090", got, err)
+	}
+
+	if err := c.AddSource(ctx, "missing", FileSource(filepath.Join(dir, "missing.yaml"))); err == nil {
+		t.Error("AddSource() error = nil for a missing file, want an error")
+	}
+}
+
+func TestEnvSource(t *testing.T) {
+	t.Setenv("TEST_SRC_DB_HOST", "localhost")
+	t.Setenv("TEST_SRC_DB_PORT", "3306")
+	t.Setenv("TEST_SRC_DEBUG", "true")
+	c, err := FromString("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddSource(context.Background(), "env", EnvSource("test_src")); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]interface{}{
+		"db.host": "localhost",
+		"db.port": 3306,
+		"debug":   true,
+	}
+	for key, want := range tests {
+		if got, err := c.Get(key); err != nil || got != want {
+			t.Errorf("Get(%q) = %#v, %v, want %#v", key, got, err, want)
+		}
+	}
+}
+
+func TestHTTPSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/config.json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"port": 8080}`))
+		case "/config.yaml":
+			w.Write([]byte("host: localhost\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := FromString("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddSource(context.Background(), "json", HTTPSource(srv.URL+"/config.json", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddSource(context.Background(), "yaml", HTTPSource(srv.URL+"/config.yaml", srv.Client())); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetInt("port"); err != nil || got != 8080 {
+		t.Errorf("GetInt(port) = %d, %v, want 8080", got, err)
+	}
+	if got, err := c.GetString("host"); err != nil || got != "localhost" {
+		t.Errorf("GetString(host) = %q, %v, want localhost", got, err)
+	}
+	if err := c.AddSource(context.Background(), "missing", HTTPSource(srv.URL+"/missing", nil)); err == nil {
+		t.Error("AddSource() error = nil for a 404 response, want an error")
+	}
+}
+
+func TestRemoteSource(t *testing.T) {
+	p := &treeProvider{data: map[interface{}]interface{}{"port": 8080}}
+	b, format, err := RemoteSource(p).Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != FormatYAML || string(b) != "port: 8080\n" {
+		t.Errorf("Load() = %q, %q, want the yaml of the tree", b, format)
+	}
+
+	c, err := FromString("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddSource(context.Background(), "remote", RemoteSource(p)); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetInt("port"); err != nil || got != 8080 {
+		t.Errorf("GetInt(port) = %d, %v, want 8080", got, err)
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// WithStatReload checks the modification times of the config files on
+// reads, if the last check is older than interval, and reloads the files if
+// any of them changed, for platforms where WatchFiles is unreliable, such as
+// NFS. Only one reader checks the files at a time, the others read the
+// current config. Reload failures are logged and the current config is
+// kept until the files change again.
+func WithStatReload(interval time.Duration) Option {
+	return func(c *Config) {
+		c.statInterval = interval
+	}
+}
+
+// checkStat reloads the config files if their modification times changed,
+// it must not be called with c.mu locked.
+func (c *Config) checkStat() {
+	if c.statInterval <= 0 || c.configFile == "" {
+		return
+	}
+	now := time.Now().UnixNano()
+	last := atomic.LoadInt64(&c.statChecked)
+	if now-last < int64(c.statInterval) || !atomic.CompareAndSwapInt64(&c.statChecked, last, now) {
+		return
+	}
+
+	mtimes := c.statFiles()
+	c.statMu.Lock()
+	changed := !sameTimes(mtimes, c.mtimes)
+	c.statMu.Unlock()
+	if !changed {
+		return
+	}
+
+	if _, err := c.reload(false); err != nil {
+		c.log().Printf("reload config files failed: %s", err)
+	}
+	// 重新加载期间文件又被修改时，使用加载前的时间以便下次检查时发现
+	after := c.statFiles()
+	for file, t := range mtimes {
+		if _, ok := after[file]; ok {
+			after[file] = t
+		}
+	}
+	c.statMu.Lock()
+	c.mtimes = after
+	c.statMu.Unlock()
+}
+
+// statFiles returns the modification times of the config files, the zero
+// time for files not existing.
+func (c *Config) statFiles() map[string]time.Time {
+	files := c.configFiles()
+	mtimes := make(map[string]time.Time, len(files))
+	for file := range files {
+		if info, err := os.Stat(file); err == nil {
+			mtimes[file] = info.ModTime()
+		} else {
+			mtimes[file] = time.Time{}
+		}
+	}
+	return mtimes
+}
+
+func sameTimes(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for file, t := range a {
+		if bt, ok := b[file]; !ok || !bt.Equal(t) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWithStatReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(doc string, mtime time.Time) {
+		t.Helper()
+		if err := ioutil.WriteFile(file, []byte(doc), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// 修改时间的精度可能不足，明确设置
+		if err := os.Chtimes(file, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now().Add(-time.Hour)
+	write("port: 8080\n", start)
+	c, err := FromFile(file, WithStatReload(10*time.Millisecond), WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write("port: 9090\n", start.Add(time.Minute))
+	// 间隔内不检查
+	if got, _ := c.GetInt("port"); got != 8080 {
+		t.Errorf("GetInt(port) within the interval = %d, want 8080", got)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got, _ := c.GetInt("port"); got != 9090 {
+		t.Errorf("GetInt(port) after the interval = %d, want 9090", got)
+	}
+
+	// 加载失败时保留原配置
+	write("port: [\n", start.Add(2*time.Minute))
+	time.Sleep(20 * time.Millisecond)
+	if got, _ := c.GetInt("port"); got != 9090 {
+		t.Errorf("GetInt(port) after an invalid change = %d, want 9090", got)
+	}
+	write("port: 7070\n", start.Add(3*time.Minute))
+	time.Sleep(20 * time.Millisecond)
+	if got, _ := c.GetInt("port"); got != 7070 {
+		t.Errorf("GetInt(port) after the fix = %d, want 7070", got)
+	}
+}
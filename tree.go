@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// setPath sets the value at the given map path, missing or non-map parents
+// are replaced with maps.
+func setPath(node map[interface{}]interface{}, path []string, value interface{}) {
+	for i, key := range path {
+		if i == len(path)-1 {
+			node[key] = value
+			return
+		}
+		next, ok := node[key].(map[interface{}]interface{})
+		if !ok {
+			next = make(map[interface{}]interface{})
+			node[key] = next
+		}
+		node = next
+	}
+}
+
+// deletePath removes the value at the given map path.
+func deletePath(node map[interface{}]interface{}, path []string) {
+	for i, key := range path {
+		if i == len(path)-1 {
+			delete(node, key)
+			return
+		}
+		next, ok := node[key].(map[interface{}]interface{})
+		if !ok {
+			return
+		}
+		node = next
+	}
+}
+
+// lookupPath returns the value at the given map path.
+func lookupPath(node map[interface{}]interface{}, path []string) (interface{}, bool) {
+	for i, key := range path {
+		v, ok := node[key]
+		if !ok {
+			return nil, false
+		}
+		if i == len(path)-1 {
+			return v, true
+		}
+		if node, ok = v.(map[interface{}]interface{}); !ok {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// walkLeaves visits every leaf value under node with its key, keys of maps
+// are visited in sorted order. Empty maps and slices are leaves too.
+func walkLeaves(node interface{}, pKey, delimiter string, fn func(key string, v interface{}) error) error {
+	switch vv := node.(type) {
+	case map[interface{}]interface{}:
+		if len(vv) == 0 && pKey != "" {
+			return fn(pKey, vv)
+		}
+		keys := make([]string, 0, len(vv))
+		values := make(map[string]interface{}, len(vv))
+		for k, v := range vv {
+			key := fmt.Sprint(k)
+			keys = append(keys, key)
+			values[key] = v
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			cKey := joinKey(pKey, key, delimiter)
+			if err := walkLeaves(values[key], cKey, delimiter, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case []interface{}:
+		if len(vv) == 0 {
+			return fn(pKey, vv)
+		}
+		for i, v := range vv {
+			if err := walkLeaves(v, fmt.Sprintf("%s[%d]", pKey, i), delimiter, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return fn(pKey, node)
+	}
+}
+
+// joinKey appends a map key to a key path, the map key is quoted if it
+// contains the delimiter, so that the result can be passed to Get.
+func joinKey(pKey, key, delimiter string) string {
+	if strings.Contains(key, delimiter) {
+		key = `"` + key + `"`
+	}
+	if pKey == "" {
+		return key
+	}
+	return pKey + delimiter + key
+}
+
+// cloneNode returns a shallow copy of a map or slice, other values are
+// returned as they are.
+func cloneNode(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[interface{}]interface{}, len(vv))
+		for k, item := range vv {
+			m[k] = item
+		}
+		return m
+	case []interface{}:
+		return append([]interface{}(nil), vv...)
+	default:
+		return v
+	}
+}
+
+// copyTree returns a deep copy of the maps and slices of a config tree.
+func copyTree(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[interface{}]interface{}, len(vv))
+		for k, item := range vv {
+			m[k] = copyTree(item)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(vv))
+		for i, item := range vv {
+			s[i] = copyTree(item)
+		}
+		return s
+	default:
+		return v
+	}
+}
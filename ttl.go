@@ -0,0 +1,125 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// ExpireAfter resolves the values of the keys matching a pattern again
+// every ttl until ctx is done, it is useful for short-lived credentials,
+// such as the database credentials from Vault. Keys are matched as by
+// GetAll, the encrypted values and the secret references under them are
+// resolved again by the key provider and the resolvers, so the caches of
+// the resolvers should not outlive ttl. If resolving fails, the previous
+// values are kept. onRotate, if not nil, is called with every key whose
+// value changed, the callbacks registered by OnChange are called too.
+func (c *Config) ExpireAfter(ctx context.Context, pattern string, ttl time.Duration, onRotate func(key string)) error {
+	if ttl <= 0 {
+		return errors.New("ttl of `" + pattern + "` should be positive")
+	}
+	patternArr, err := c.parsePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(ttl)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			for _, key := range c.expireResolved(pattern, patternArr) {
+				if onRotate != nil {
+					onRotate(key)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// expireResolved resolves the values of the keys matching a pattern again,
+// it returns the keys whose value changed. The values failing to resolve
+// keep their previous plain text, the new config is checked like Reload.
+func (c *Config) expireResolved(pattern string, patternArr []interface{}) []string {
+	c.mu.Lock()
+	old := c.current()
+	if old.raw == nil {
+		c.mu.Unlock()
+		return nil
+	}
+
+	matched := make(map[string]interface{})
+	c.match(old.raw, patternArr, "", matched)
+	expired := make(map[string]bool) // 需要重新解析的原值
+	keys := make([]string, 0)
+	for key, node := range matched {
+		walkLeaves(node, key, c.Delimiter, func(key string, v interface{}) error {
+			if s, ok := v.(string); ok {
+				if _, ok := c.resolved[s]; ok {
+					expired[s] = true
+					keys = append(keys, key)
+				}
+			}
+			return nil
+		})
+	}
+	c.mu.Unlock()
+	if len(expired) == 0 {
+		return nil
+	}
+
+	_, err := c.update("expire "+pattern, func() (func(), error) {
+		previous := make(map[string]string, len(expired))
+		var resolveErr error
+		for s := range expired {
+			plain, ok := c.resolved[s]
+			if !ok {
+				continue
+			}
+			// 解析失败的值保留之前的明文
+			newPlain, _, err := c.resolveString(s)
+			if err != nil {
+				if resolveErr == nil {
+					resolveErr = err
+				}
+				continue
+			}
+			previous[s], c.resolved[s] = plain, newPlain
+		}
+		if resolveErr != nil {
+			c.log().Printf("resolve values of `%s` failed: %s", pattern, resolveErr)
+		}
+		return func() {
+			for s, plain := range previous {
+				c.resolved[s] = plain
+			}
+		}, nil
+	})
+	if err != nil {
+		c.log().Printf("rotation of `%s` is rejected: %s", pattern, err)
+		return nil
+	}
+	cur := c.current()
+
+	sort.Strings(keys)
+	rotated := make([]string, 0, len(keys))
+	for _, key := range keys {
+		keyArr, err := c.parseKey(key)
+		if err != nil {
+			continue
+		}
+		oldV, _ := lookupKeys(old.data, keyArr)
+		curV, _ := lookupKeys(cur.data, keyArr)
+		if !reflect.DeepEqual(oldV, curV) {
+			rotated = append(rotated, key)
+		}
+	}
+	return rotated
+}
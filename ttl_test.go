@@ -0,0 +1,70 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExpireAfter(t *testing.T) {
+	var version int32
+	var failing atomic.Value
+	failing.Store(false)
+	resolver := ResolverFunc(func(ref string) (string, error) {
+		if failing.Load().(bool) {
+			return "", errors.New("unavailable")
+		}
+		return ref + "-" + strconv.Itoa(int(atomic.AddInt32(&version, 1))), nil
+	})
+	c, err := FromString("db: {user: \"vault://user\", host: localhost}\napi: {token: \"vault://token\"}\n",
+		WithResolver("vault", resolver), WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, _ := c.GetString("db.user")
+	token, _ := c.GetString("api.token")
+
+	rotated := make(chan string, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err = c.ExpireAfter(ctx, "db.*", 20*time.Millisecond, func(key string) {
+		rotated <- key
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case key := <-rotated:
+		if key != "db.user" {
+			t.Errorf("rotated key = %q, want db.user", key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("db.user is not rotated")
+	}
+	if got, _ := c.GetString("db.user"); got == user {
+		t.Errorf("GetString(db.user) = %q, want a rotated value", got)
+	}
+	// 不匹配的key不重新解析
+	if got, _ := c.GetString("api.token"); got != token {
+		t.Errorf("GetString(api.token) = %q, want %q", got, token)
+	}
+
+	// 解析失败时保留之前的值
+	failing.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	kept, _ := c.GetString("db.user")
+	time.Sleep(60 * time.Millisecond)
+	if got, _ := c.GetString("db.user"); got != kept || got == "" {
+		t.Errorf("GetString(db.user) while failing = %q, want %q kept", got, kept)
+	}
+
+	if err := c.ExpireAfter(ctx, "db.*", 0, nil); err == nil {
+		t.Error("ExpireAfter() error = nil for a zero ttl, want an error")
+	}
+	if err := c.ExpireAfter(ctx, "[0]", time.Second, nil); err == nil {
+		t.Error("ExpireAfter() error = nil for an invalid pattern, want an error")
+	}
+}
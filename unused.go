@@ -0,0 +1,37 @@
+package config
+
+// UnusedKeys returns the keys set by the config files that were never read
+// through any getter, in sorted order. A key is read if it or any key
+// containing it was read, the keys decoded into struct fields by Unmarshal
+// and UnmarshalKey are read, the keys checked by Validate are not. It
+// requires the WithAccessTracking option, otherwise all keys of the config
+// files are returned.
+func (c *Config) UnusedKeys() []string {
+	// 读取配置可能触发重新加载和访问回调，复制后释放锁再遍历
+	data := c.data()
+	c.accessMu.Lock()
+	accessed := make(map[string]bool, len(c.accessed))
+	for k := range c.accessed {
+		accessed[k] = true
+	}
+	c.accessMu.Unlock()
+
+	unused := make([]string, 0)
+	walkLeaves(data, "", c.Delimiter, func(key string, v interface{}) error {
+		switch c.Source(key).Layer {
+		case LayerFile, LayerInclude:
+		default:
+			return nil
+		}
+
+		for k, ok := key, true; ok; k, ok = c.parentKey(k) {
+			// include由加载配置时读取
+			if accessed[k] || k == "include" {
+				return nil
+			}
+		}
+		unused = append(unused, key)
+		return nil
+	})
+	return unused
+}
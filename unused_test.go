@@ -0,0 +1,116 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUnusedKeys(t *testing.T) {
+	yaml := "server: {host: localhost, port: 80, debug: true}\nlimits: {rate: 10}\ntags: [a, b]\n"
+	schema := Schema{"server.port": {Type: TypeInt, Required: true}, "limits.rate": {Type: TypeInt}}
+
+	tests := []struct {
+		name string
+		read func(c *Config) error
+		want []string
+	}{
+		{
+			name: "validate",
+			read: func(c *Config) error { return c.Validate(schema) },
+			want: []string{"limits.rate", "server.debug", "server.host", "server.port", "tags[0]", "tags[1]"},
+		},
+		{
+			name: "get",
+			read: func(c *Config) error {
+				_, err := c.Get("server")
+				return err
+			},
+			want: []string{"limits.rate", "tags[0]", "tags[1]"},
+		},
+		{
+			name: "unmarshal",
+			read: func(c *Config) error {
+				var v struct {
+					Server struct {
+						Host string
+						Port int
+					}
+					Tags []string
+				}
+				return c.Unmarshal(&v)
+			},
+			want: []string{"limits.rate", "server.debug"},
+		},
+		{
+			name: "unmarshal key",
+			read: func(c *Config) error {
+				var v struct {
+					Host string
+				}
+				return c.UnmarshalKey("server", &v)
+			},
+			want: []string{"limits.rate", "server.debug", "server.port", "tags[0]", "tags[1]"},
+		},
+		{
+			name: "unmarshal map",
+			read: func(c *Config) error {
+				var v map[string]int
+				return c.UnmarshalKey("limits", &v)
+			},
+			want: []string{"server.debug", "server.host", "server.port", "tags[0]", "tags[1]"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := FromString(yaml, WithAccessTracking(), WithSchema(schema))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.read(c); err != nil {
+				t.Fatal(err)
+			}
+			if got := c.UnusedKeys(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnusedKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnusedKeysReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(file, []byte("a: 1\nb: 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := FromFile(file, WithAccessTracking(), WithStatReload(time.Nanosecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.OnChange(func([]Change) {
+		c.Get("a")
+	})
+	if err := ioutil.WriteFile(file, []byte("a: 3\nb: 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(file, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新加载触发的回调读取配置时不应死锁
+	done := make(chan []string)
+	go func() {
+		done <- c.UnusedKeys()
+	}()
+	select {
+	case got := <-done:
+		if want := []string{"b"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("UnusedKeys() = %v, want %v", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("UnusedKeys() deadlocks when the config is reloaded")
+	}
+}
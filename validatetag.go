@@ -0,0 +1,164 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// validateStruct checks the `validate` tags of the fields of a decoded
+// value, such as `validate:"required,gte=1,lte=65535"`. Supported rules:
+//
+//	required    the value is not the zero value
+//	gt, gte     a number is greater than (or equal to) the parameter, or the
+//	lt, lte     length of a string, slice or map for the other comparisons
+//	min, max    same as gte and lte
+//	len         the length of a string, slice or map, or a number, equals
+//	oneof       the value is one of the parameters separated by spaces
+//	omitempty   the other rules are skipped for the zero value
+func (c *Config) validateStruct(rv reflect.Value, key string) []error {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	var errs []error
+	switch rv.Kind() {
+	case reflect.Struct:
+		if rv.Type() == reflect.TypeOf(time.Time{}) {
+			return nil
+		}
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" && !field.Anonymous {
+				// 未导出字段
+				continue
+			}
+			name, ok := fieldKey(field)
+			if !ok {
+				continue
+			}
+			fKey := key
+			if !field.Anonymous || field.Tag.Get("config") != "" {
+				fKey = joinKey(key, name, c.Delimiter)
+			}
+			fv := rv.Field(i)
+			if tag := field.Tag.Get("validate"); tag != "" && tag != "-" {
+				errs = append(errs, c.validateTag(fv, fKey, tag)...)
+			}
+			errs = append(errs, c.validateStruct(fv, fKey)...)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			errs = append(errs, c.validateStruct(rv.Index(i), fmt.Sprintf("%s[%d]", key, i))...)
+		}
+	case reflect.Map:
+		for _, k := range rv.MapKeys() {
+			errs = append(errs, c.validateStruct(rv.MapIndex(k), joinKey(key, fmt.Sprint(k), c.Delimiter))...)
+		}
+	}
+	return errs
+}
+
+// validateTag checks a value against the rules of a `validate` tag.
+func (c *Config) validateTag(rv reflect.Value, key string, tag string) []error {
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	rules := strings.Split(tag, ",")
+	for _, rule := range rules {
+		if rule == "omitempty" && rv.IsZero() {
+			return nil
+		}
+	}
+	got := formatValue(c.redactKey(rv.Interface(), key))
+
+	var errs []error
+	for _, rule := range rules {
+		name, param := rule, ""
+		if pos := strings.IndexByte(rule, '='); pos != -1 {
+			name, param = rule[:pos], rule[pos+1:]
+		}
+
+		switch name {
+		case "required":
+			if rv.IsZero() {
+				errs = append(errs, requiredError(key))
+				// 缺失时不再检查其他规则
+				return errs
+			}
+		case "gt", "gte", "lt", "lte", "min", "max", "len":
+			bound, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid validate rule `%s` of `%s`", rule, key))
+				continue
+			}
+			n, ok := measureValue(rv)
+			if !ok {
+				continue
+			}
+			var failed bool
+			var desc string
+			switch name {
+			case "gt":
+				failed, desc = n <= bound, "greater than"
+			case "gte", "min":
+				failed, desc = n < bound, "less than"
+			case "lt":
+				failed, desc = n >= bound, "less than"
+			case "lte", "max":
+				failed, desc = n > bound, "greater than"
+			case "len":
+				failed, desc = n != bound, "of length"
+			}
+			if !failed {
+				continue
+			}
+			switch name {
+			case "gte", "min", "lte", "max":
+				errs = append(errs, fmt.Errorf("value of `%s` should not be %s %v, got %s", key, desc, bound, got))
+			default:
+				errs = append(errs, fmt.Errorf("value of `%s` should be %s %v, got %s", key, desc, bound, got))
+			}
+		case "oneof":
+			options := strings.Fields(param)
+			str := fmt.Sprint(rv.Interface())
+			found := false
+			for _, option := range options {
+				if option == str {
+					found = true
+					break
+				}
+			}
+			if !found {
+				errs = append(errs, fmt.Errorf("value of `%s` should be one of %v, got %s", key, options, got))
+			}
+		case "", "omitempty":
+		default:
+			errs = append(errs, fmt.Errorf("unknown validate rule `%s` of `%s`", name, key))
+		}
+	}
+	return errs
+}
+
+// measureValue returns the number to compare with a bound: numbers
+// themselves, lengths of strings, slices and maps.
+func measureValue(rv reflect.Value) (float64, bool) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return float64(rv.Len()), true
+	default:
+		return 0, false
+	}
+}
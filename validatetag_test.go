@@ -0,0 +1,65 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+type validateServer struct {
+	Host  string   `config:"host" validate:"required"`
+	Port  int      `config:"port" validate:"gte=1,lte=65535"`
+	Mode  string   `config:"mode" validate:"oneof=dev prod"`
+	Hosts []string `config:"hosts" validate:"min=1,max=2"`
+	Code  string   `config:"code" validate:"omitempty,len=3"`
+	Ratio float64  `config:"ratio" validate:"gt=0,lt=1"`
+}
+
+func TestValidateTags(t *testing.T) {
+	tests := []struct {
+		name   string
+		yaml   string
+		errors int
+	}{
+		{
+			name: "valid",
+			yaml: "server: {host: localhost, port: 8080, mode: dev, hosts: [a], ratio: 0.5}\n",
+		},
+		{
+			name: "omitempty",
+			yaml: "server: {host: localhost, port: 8080, mode: prod, hosts: [a, b], code: abc, ratio: 0.5}\n",
+		},
+		{
+			name:   "all violations",
+			yaml:   "server: {port: 70000, mode: test, hosts: [a, b, c], code: ab, ratio: 1}\n",
+			errors: 6,
+		},
+		{
+			name:   "list of structs",
+			yaml:   "servers: [{host: a, port: 1, mode: dev, hosts: [a], ratio: 0.5}, {host: b, port: 0, mode: dev, hosts: [a], ratio: 0.5}]\n",
+			errors: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := FromString(tt.yaml)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var v struct {
+				Server  *validateServer  `config:"server"`
+				Servers []validateServer `config:"servers"`
+			}
+			err = c.Unmarshal(&v)
+			if tt.errors == 0 {
+				if err != nil {
+					t.Errorf("Unmarshal() error = %v", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) || len(verr.Errors) != tt.errors {
+				t.Errorf("Unmarshal() error = %v, want %d violations", err, tt.errors)
+			}
+		})
+	}
+}
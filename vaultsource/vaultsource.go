@@ -0,0 +1,250 @@
+// Package vaultsource mounts the secrets of a HashiCorp Vault path under a
+// config key, and rotates them by renewing or reading again their leases.
+package vaultsource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// Source is a config.RemoteWatcher backed by Vault.
+type Source struct {
+	client  *api.Client
+	path    string
+	key     []string
+	refresh time.Duration
+
+	roleID   string
+	secretID string
+	auth     *api.Secret // AppRole登录得到的token
+	authMu   sync.Mutex
+}
+
+// Option configures a Source.
+type Option func(*Source)
+
+// WithAppRole logs in with AppRole instead of using the token of the client,
+// the token is renewed while watching and the source logs in again when it
+// can not be renewed.
+func WithAppRole(roleID, secretID string) Option {
+	return func(s *Source) {
+		s.roleID = roleID
+		s.secretID = secretID
+	}
+}
+
+// WithRefresh reads the secrets again at the interval while watching if
+// they have no lease, such as the secrets of the KV v2 engine. The default
+// 0 reads them only once.
+func WithRefresh(d time.Duration) Option {
+	return func(s *Source) {
+		s.refresh = d
+	}
+}
+
+// New create a source reading the secrets at path, they are mounted under
+// key, which is split into levels by dots. For example, the secrets at
+// `secret/data/db` mounted under `secrets.db` are read by
+// GetString("secrets.db.password"). Both the KV v1 and v2 engines are
+// supported.
+func New(client *api.Client, path string, key string, opts ...Option) *Source {
+	s := &Source{client: client, path: path}
+	for _, name := range strings.Split(key, ".") {
+		if name != "" {
+			s.key = append(s.key, name)
+		}
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Fetch implements config.RemoteProvider.
+func (s *Source) Fetch(ctx context.Context) (map[interface{}]interface{}, error) {
+	data, _, err := s.fetch(ctx)
+	return data, err
+}
+
+// Watch implements config.RemoteWatcher. Renewable secrets are renewed
+// until their leases end, then they are read again, as well as secrets
+// having a lease but not renewable.
+func (s *Source) Watch(ctx context.Context, update func(map[interface{}]interface{})) error {
+	if s.roleID != "" {
+		go s.renewAuth(ctx)
+	}
+
+	_, secret, err := s.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	for {
+		if err := s.waitLease(ctx, secret); err != nil {
+			return err
+		}
+		var data map[interface{}]interface{}
+		if data, secret, err = s.fetch(ctx); err != nil {
+			return err
+		}
+		update(data)
+	}
+}
+
+// waitLease blocks until the secret should be read again.
+func (s *Source) waitLease(ctx context.Context, secret *api.Secret) error {
+	if secret.Renewable {
+		watcher, err := s.client.NewLifetimeWatcher(&api.LifetimeWatcherInput{Secret: secret})
+		if err != nil {
+			return err
+		}
+		go watcher.Start()
+		defer watcher.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-watcher.DoneCh():
+				// 无法再续期，重新读取
+				return nil
+			case <-watcher.RenewCh():
+			}
+		}
+	}
+
+	d := time.Duration(secret.LeaseDuration) * time.Second
+	if d <= 0 {
+		d = s.refresh
+	}
+	if d <= 0 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// fetch reads the secrets and returns them mounted under the key.
+func (s *Source) fetch(ctx context.Context) (map[interface{}]interface{}, *api.Secret, error) {
+	if err := s.login(ctx, false); err != nil {
+		return nil, nil, err
+	}
+	secret, err := s.client.Logical().ReadWithContext(ctx, s.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil, errors.New("vault secret `" + s.path + "` not found")
+	}
+
+	values := secret.Data
+	// KV v2的数据在data中，版本信息在metadata中
+	if inner, ok := values["data"].(map[string]interface{}); ok {
+		if _, ok := values["metadata"]; ok {
+			values = inner
+		}
+	}
+
+	var data interface{} = convert(values)
+	for i := len(s.key) - 1; i >= 0; i-- {
+		data = map[interface{}]interface{}{s.key[i]: data}
+	}
+	return data.(map[interface{}]interface{}), secret, nil
+}
+
+// login logs in with AppRole if it is configured and the source has not
+// logged in, or force is true.
+func (s *Source) login(ctx context.Context, force bool) error {
+	if s.roleID == "" {
+		return nil
+	}
+	s.authMu.Lock()
+	defer s.authMu.Unlock()
+	if s.auth != nil && !force {
+		return nil
+	}
+
+	secret, err := s.client.Logical().WriteWithContext(ctx, "auth/approle/login", map[string]interface{}{
+		"role_id":   s.roleID,
+		"secret_id": s.secretID,
+	})
+	if err != nil {
+		return err
+	}
+	if secret == nil || secret.Auth == nil {
+		return errors.New("vault approle login returned no token")
+	}
+	s.client.SetToken(secret.Auth.ClientToken)
+	s.auth = secret
+	return nil
+}
+
+// renewAuth renews the token of AppRole until ctx is done, and logs in
+// again when the token can not be renewed.
+func (s *Source) renewAuth(ctx context.Context) {
+	for ctx.Err() == nil {
+		s.authMu.Lock()
+		auth := s.auth
+		s.authMu.Unlock()
+		if auth == nil || !auth.Auth.Renewable {
+			return
+		}
+
+		watcher, err := s.client.NewLifetimeWatcher(&api.LifetimeWatcherInput{Secret: auth})
+		if err != nil {
+			return
+		}
+		go watcher.Start()
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				watcher.Stop()
+				return
+			case <-watcher.DoneCh():
+				break wait
+			case <-watcher.RenewCh():
+			}
+		}
+		watcher.Stop()
+
+		if err := s.login(ctx, true); err != nil {
+			return
+		}
+	}
+}
+
+// convert converts the decoded json values of Vault to config values.
+func convert(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		m := make(map[interface{}]interface{}, len(vv))
+		for k, item := range vv {
+			m[k] = convert(item)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(vv))
+		for i, item := range vv {
+			s[i] = convert(item)
+		}
+		return s
+	case json.Number:
+		if i, err := vv.Int64(); err == nil {
+			return int(i)
+		}
+		f, _ := vv.Float64()
+		return f
+	default:
+		return v
+	}
+}
@@ -0,0 +1,186 @@
+package vaultsource
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// fakeVault serves secrets and the AppRole login from memory.
+type fakeVault struct {
+	mu      sync.Mutex
+	secrets map[string]map[string]interface{}
+	token   string // 读取secret需要的token，为空时不检查
+	logins  int
+}
+
+func newFakeVault(t *testing.T, secrets map[string]map[string]interface{}) (*fakeVault, *api.Client) {
+	f := &fakeVault{secrets: secrets}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	client, err := api.NewClient(&api.Config{Address: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken("")
+	return f, client
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	if path == "auth/approle/login" {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["role_id"] != "role" || body["secret_id"] != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"invalid role or secret"}})
+			return
+		}
+		f.logins++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": "approle-token"},
+		})
+		return
+	}
+
+	if f.token != "" && r.Header.Get("X-Vault-Token") != f.token {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+	data, ok := f.secrets[path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{}})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+func (f *fakeVault) set(path string, data map[string]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.secrets[path] = data
+}
+
+func TestFetch(t *testing.T) {
+	_, client := newFakeVault(t, map[string]map[string]interface{}{
+		"secret/db": {"password": "hunter2", "port": 5432},
+		"kv/data/db": {
+			"data":     map[string]interface{}{"password": "v2", "ratio": 0.5},
+			"metadata": map[string]interface{}{"version": 3},
+		},
+	})
+	tests := []struct {
+		name    string
+		source  *Source
+		want    map[interface{}]interface{}
+		wantErr bool
+	}{
+		{
+			name:   "kv v1",
+			source: New(client, "secret/db", "secrets.db"),
+			want: map[interface{}]interface{}{"secrets": map[interface{}]interface{}{
+				"db": map[interface{}]interface{}{"password": "hunter2", "port": 5432},
+			}},
+		},
+		{
+			name:   "kv v2",
+			source: New(client, "kv/data/db", "db"),
+			want: map[interface{}]interface{}{
+				"db": map[interface{}]interface{}{"password": "v2", "ratio": 0.5},
+			},
+		},
+		{
+			name:    "missing secret",
+			source:  New(client, "secret/missing", "db"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.source.Fetch(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Fetch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppRole(t *testing.T) {
+	f, client := newFakeVault(t, map[string]map[string]interface{}{"secret/db": {"password": "hunter2"}})
+	f.mu.Lock()
+	f.token = "approle-token"
+	f.mu.Unlock()
+
+	if _, err := New(client, "secret/db", "db").Fetch(context.Background()); err == nil {
+		t.Error("Fetch() error = nil without the token, want an error")
+	}
+	s := New(client, "secret/db", "db", WithAppRole("role", "secret"))
+	for i := 0; i < 2; i++ {
+		if _, err := s.Fetch(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.mu.Lock()
+	logins := f.logins
+	f.mu.Unlock()
+	if logins != 1 {
+		t.Errorf("logged in %d times, want 1", logins)
+	}
+	if _, err := New(client, "secret/db", "db", WithAppRole("role", "wrong")).Fetch(context.Background()); err == nil {
+		t.Error("Fetch() error = nil with a wrong secret id, want an error")
+	}
+}
+
+func TestWatchRefresh(t *testing.T) {
+	f, client := newFakeVault(t, map[string]map[string]interface{}{"secret/db": {"password": "old"}})
+	s := New(client, "secret/db", "db", WithRefresh(10*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates := make(chan map[interface{}]interface{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Watch(ctx, func(data map[interface{}]interface{}) {
+			select {
+			case updates <- data:
+			default:
+			}
+		})
+	}()
+
+	f.set("secret/db", map[string]interface{}{"password": "new"})
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case data := <-updates:
+			if db, _ := data["db"].(map[interface{}]interface{}); db["password"] != "new" {
+				continue
+			}
+		case <-deadline:
+			t.Fatal("no update with the new secret")
+		}
+		break
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Watch() error = %v, want context.Canceled", err)
+	}
+}
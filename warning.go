@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Kinds of warnings.
+const (
+	WarningDeprecatedKey  = "deprecated_key"
+	WarningMissingInclude = "missing_include"
+	WarningCoercedValue   = "coerced_value"
+	WarningDuplicateKey   = "duplicate_key"
+)
+
+// Warning is a non-fatal problem found while loading or reading the config,
+// such as a deprecated key being used.
+type Warning struct {
+	Kind    string // the kind of the warning, such as WarningDeprecatedKey
+	Key     string // the key concerned, empty if none
+	Source  string // the file concerned, empty if none
+	Message string
+}
+
+func (w Warning) String() string {
+	return w.Message
+}
+
+// Warnings returns the warnings found since the config was created, in the
+// order they were found, each warning is reported once. Warnings are found
+// for deprecated keys being used, optional includes missing, duplicate keys
+// in yaml documents, strings converted to numbers or booleans by the
+// getters, and remote sources loaded from their caches.
+func (c *Config) Warnings() []Warning {
+	c.warnMu.Lock()
+	defer c.warnMu.Unlock()
+
+	return append([]Warning(nil), c.warnings...)
+}
+
+// warn records a warning, it may be called with c.mu locked.
+func (c *Config) warn(w Warning) {
+	c.warnMu.Lock()
+	defer c.warnMu.Unlock()
+
+	if c.warned[w] {
+		return
+	}
+	if c.warned == nil {
+		c.warned = make(map[Warning]bool)
+	}
+	c.warned[w] = true
+	c.warnings = append(c.warnings, w)
+}
+
+// warnCoerced records a warning for a string value of a given key converted
+// to typ by a getter. It doesn't allocate once the key is warned, since the
+// getters call it on every read.
+func (c *Config) warnCoerced(key, typ string) {
+	if warned, ok := c.coerced.Load(key); ok && warned == typ {
+		return
+	}
+	c.coerced.Store(key, typ)
+	c.warn(Warning{
+		Kind:    WarningCoercedValue,
+		Key:     key,
+		Message: "value of `" + key + "` is a string converted to " + typ,
+	})
+}
+
+// checkDuplicates records warnings for the keys set more than once in a
+// map of the nodes of a yaml document, the yaml decoder keeps the last value
+// silently.
+func (c *Config) checkDuplicates(root *yamlv3.Node, name string) {
+
+	var walk func(node *yamlv3.Node, pKey string)
+	walk = func(node *yamlv3.Node, pKey string) {
+		switch node.Kind {
+		case yamlv3.DocumentNode:
+			for _, item := range node.Content {
+				walk(item, pKey)
+			}
+		case yamlv3.MappingNode:
+			seen := make(map[string]bool, len(node.Content)/2)
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				k := node.Content[i].Value
+				cKey := joinKey(pKey, k, c.Delimiter)
+				// `<<`合并多个map时可以重复
+				if seen[k] && k != "<<" {
+					msg := fmt.Sprintf("key `%s` is set more than once, the last value is used", cKey)
+					if name != "" {
+						msg = fmt.Sprintf("key `%s` is set more than once in `%s`, the last value is used", cKey, name)
+					}
+					c.warn(Warning{Kind: WarningDuplicateKey, Key: cKey, Source: name, Message: msg})
+				}
+				seen[k] = true
+				walk(node.Content[i+1], cKey)
+			}
+		case yamlv3.SequenceNode:
+			for i, item := range node.Content {
+				walk(item, fmt.Sprintf("%s[%d]", pKey, i))
+			}
+		}
+	}
+	walk(root, "")
+}
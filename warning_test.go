@@ -0,0 +1,55 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestWarnings(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	doc := "include:\n  - {file: local, optional: true}\nserver: {port: \"8080\", port: \"9090\"}\nold: 1\n"
+	if err := ioutil.WriteFile(file, []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := FromFile(file, WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.DeprecateKey("old", "new")
+	for i := 0; i < 2; i++ {
+		c.GetInt("server.port")
+		c.GetInt("new")
+	}
+
+	kinds := make(map[string]Warning)
+	for _, w := range c.Warnings() {
+		if _, ok := kinds[w.Kind]; ok {
+			t.Errorf("warning %v is reported more than once", w)
+		}
+		kinds[w.Kind] = w
+	}
+	tests := []struct {
+		kind string
+		key  string
+	}{
+		{kind: WarningMissingInclude},
+		{kind: WarningDuplicateKey, key: "server.port"},
+		{kind: WarningCoercedValue, key: "server.port"},
+		{kind: WarningDeprecatedKey, key: "old"},
+	}
+	for _, tt := range tests {
+		w, ok := kinds[tt.kind]
+		if !ok {
+			t.Errorf("Warnings() = %v, want a warning of %s", c.Warnings(), tt.kind)
+			continue
+		}
+		if w.Key != tt.key || w.Message == "" || w.String() != w.Message {
+			t.Errorf("warning of %s = %+v, want key %q", tt.kind, w, tt.key)
+		}
+	}
+	if w := kinds[WarningDuplicateKey]; w.Source != file {
+		t.Errorf("warning of the duplicate key = %+v, want the source %s", w, file)
+	}
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchFiles reloads the config files when they change until ctx is done,
+// the callbacks registered by OnChange are called with the changed keys.
+// The config file, the files included by it and the files merged over it
+// by FromFileForEnv are all watched, the includes are discovered again on
+// every reload, so that adding an include starts watching the file.
+// The lazy includes are loaded on reloading. Bursts of events are coalesced
+// into one reload, see WithDebounce.
+func (c *Config) WatchFiles(ctx context.Context, opts ...WatchOption) error {
+	wo := watchOptions{debounce: defaultDebounce}
+	for _, opt := range opts {
+		opt(&wo)
+	}
+	if c.configFile == "" {
+		return errors.New("config is not loaded from a file")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	dirs := make(map[string]bool)
+	files, err := c.watchFiles(watcher, dirs)
+	if err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+		b := NewDebouncer(wo.debounce)
+		defer b.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				c.log().Printf("watch config files failed: %s", err)
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				// 只关心被监视的文件，编辑器可能通过重命名替换文件
+				if !files[filepath.Clean(event.Name)] ||
+					event.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) == 0 {
+					continue
+				}
+				b.Reset()
+			case <-b.C:
+				b.C = nil
+				if _, err := c.reload(false); err != nil {
+					c.log().Printf("reload config files failed: %s", err)
+				}
+				if files, err = c.watchFiles(watcher, dirs); err != nil {
+					c.log().Printf("watch config files failed: %s", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// WatchOption configures WatchFiles.
+type WatchOption func(*watchOptions)
+
+type watchOptions struct {
+	debounce time.Duration
+}
+
+// the default window of coalescing file events
+const defaultDebounce = 100 * time.Millisecond
+
+// WithDebounce reloads once the files have no events for d, so that bursts
+// of events, such as editors saving a file in steps, symlink swaps or
+// rsync, are coalesced into one reload and half written files are not
+// loaded. The default is 100ms, 0 reloads on every event.
+func WithDebounce(d time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		o.debounce = d
+	}
+}
+
+// Debouncer coalesces bursts of events, such as file events, C receives once
+// there are no events for a given window. It is used by WatchFiles and the
+// packages of remote sources watching files, it must not be used
+// concurrently.
+type Debouncer struct {
+	d     time.Duration
+	timer *time.Timer
+	C     <-chan time.Time // 为nil时没有等待中的事件
+}
+
+// NewDebouncer returns a Debouncer of the window d.
+func NewDebouncer(d time.Duration) *Debouncer {
+	return &Debouncer{d: d}
+}
+
+// Reset restarts the window on an event, C receives once it passes.
+func (b *Debouncer) Reset() {
+	if b.timer == nil {
+		b.timer = time.NewTimer(b.d)
+	} else {
+		// 清除已触发但未接收的事件
+		if !b.timer.Stop() {
+			select {
+			case <-b.timer.C:
+			default:
+			}
+		}
+		b.timer.Reset(b.d)
+	}
+	b.C = b.timer.C
+}
+
+// Stop stops the window, C doesn't receive until Reset.
+func (b *Debouncer) Stop() {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	b.C = nil
+}
+
+// watchFiles makes watcher watch the directories of the current config
+// files, and stop watching the directories no longer needed. It returns
+// the files to watch.
+func (c *Config) watchFiles(watcher *fsnotify.Watcher, dirs map[string]bool) (map[string]bool, error) {
+	// 监视文件所在的目录，文件被删除后重新创建也能收到事件
+	files := c.configFiles()
+	needed := make(map[string]bool)
+	for file := range files {
+		needed[filepath.Dir(file)] = true
+	}
+	for dir := range needed {
+		if !dirs[dir] {
+			if err := watcher.Add(dir); err != nil {
+				return files, err
+			}
+			dirs[dir] = true
+		}
+	}
+	for dir := range dirs {
+		if !needed[dir] {
+			watcher.Remove(dir)
+			delete(dirs, dir)
+		}
+	}
+	return files, nil
+}
+
+// configFiles returns the current config files, including the included
+// files and the overlays.
+func (c *Config) configFiles() map[string]bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files := make(map[string]bool)
+	for _, l := range c.fileLayers {
+		files[filepath.Clean(l.source.Name)] = true
+	}
+	for _, file := range c.overlays {
+		files[filepath.Clean(file)] = true
+	}
+	return files
+}
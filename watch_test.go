@@ -0,0 +1,102 @@
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDebouncer(t *testing.T) {
+	b := NewDebouncer(50 * time.Millisecond)
+	defer b.Stop()
+	if b.C != nil {
+		t.Fatal("C is not nil before Reset")
+	}
+
+	// 连续的事件只触发一次
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		b.Reset()
+		time.Sleep(10 * time.Millisecond)
+	}
+	<-b.C
+	if elapsed := time.Since(start); elapsed < 85*time.Millisecond {
+		t.Errorf("C received after %v, want after the window following the last Reset", elapsed)
+	}
+	select {
+	case <-b.C:
+		t.Error("C received twice for one burst")
+	case <-time.After(80 * time.Millisecond):
+	}
+
+	b.Reset()
+	b.Stop()
+	if b.C != nil {
+		t.Error("C is not nil after Stop")
+	}
+}
+
+func TestWatchFiles(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	write := func(name, doc string) {
+		t.Helper()
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(doc), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("config.yaml", "port: 8080\n")
+	c, err := FromFile(file, WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := make(chan []Change, 10)
+	c.OnChange(func(cs []Change) {
+		changed <- cs
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := c.WatchFiles(ctx, WithDebounce(20*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	wait := func(key string) {
+		t.Helper()
+		select {
+		case cs := <-changed:
+			for _, change := range cs {
+				if change.Key == key {
+					return
+				}
+			}
+			t.Errorf("changes = %v, want a change of %s", cs, key)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("the change of %s is not watched", key)
+		}
+	}
+
+	write("config.yaml", "port: 9090\n")
+	wait("port")
+	if got, _ := c.GetInt("port"); got != 9090 {
+		t.Errorf("GetInt(port) = %d, want 9090", got)
+	}
+
+	// 新增的include文件也被监视
+	write("db.yaml", "db: {host: localhost}\n")
+	write("config.yaml", "include: [db]\nport: 9090\n")
+	wait("db.host")
+	write("db.yaml", "db: {host: db}\n")
+	wait("db.host")
+	if got, _ := c.GetString("db.host"); got != "db" {
+		t.Errorf("GetString(db.host) = %q, want db", got)
+	}
+
+	s, err := FromString("a: 1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WatchFiles(ctx); err == nil {
+		t.Error("WatchFiles() error = nil for a config from a string, want an error")
+	}
+}
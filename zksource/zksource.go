@@ -0,0 +1,126 @@
+// Package zksource loads config from a ZooKeeper znode tree.
+package zksource
+
+import (
+	"context"
+	"path"
+
+	"github.com/go-apibox/config"
+	"github.com/go-zookeeper/zk"
+)
+
+// Conn is the part of *zk.Conn used by the source.
+type Conn interface {
+	Children(path string) ([]string, *zk.Stat, error)
+	ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error)
+	Get(path string) ([]byte, *zk.Stat, error)
+	GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error)
+}
+
+// Source is a config.RemoteWatcher backed by ZooKeeper.
+type Source struct {
+	conn Conn
+	root string
+}
+
+// New create a source reading the znodes under root. The names of znodes
+// are the config levels, such as `/app/db/port` with root `/app` for
+// `db.port`. The data of leaf znodes are the values, they are converted to
+// int, float64 or bool if possible.
+func New(conn Conn, root string) *Source {
+	return &Source{conn: conn, root: path.Clean("/" + root)}
+}
+
+// Fetch implements config.RemoteProvider.
+func (s *Source) Fetch(ctx context.Context) (map[interface{}]interface{}, error) {
+	kvs := make(map[string]string)
+	if err := s.fetch(s.root, "", kvs, nil); err != nil {
+		return nil, err
+	}
+	return config.TreeFromKeys(kvs, "/"), nil
+}
+
+// Watch implements config.RemoteWatcher, watches are set on every znode of
+// the tree and the tree is read again when any of them fires.
+func (s *Source) Watch(ctx context.Context, update func(map[interface{}]interface{})) error {
+	first := true
+	for {
+		kvs := make(map[string]string)
+		var events []<-chan zk.Event
+		if err := s.fetch(s.root, "", kvs, &events); err != nil {
+			return err
+		}
+		if !first {
+			update(config.TreeFromKeys(kvs, "/"))
+		}
+		first = false
+
+		// 任一znode变化时重新读取
+		changed := make(chan struct{}, 1)
+		stop := make(chan struct{})
+		for _, ch := range events {
+			go func(ch <-chan zk.Event) {
+				select {
+				case <-ch:
+					select {
+					case changed <- struct{}{}:
+					default:
+					}
+				case <-stop:
+				}
+			}(ch)
+		}
+		select {
+		case <-ctx.Done():
+			close(stop)
+			return ctx.Err()
+		case <-changed:
+			close(stop)
+		}
+	}
+}
+
+// fetch reads the znode at p and its children into kvs, the watches are
+// set and appended to events if it is not nil.
+func (s *Source) fetch(p string, key string, kvs map[string]string, events *[]<-chan zk.Event) error {
+	var children []string
+	var data []byte
+	var err error
+	if events == nil {
+		children, _, err = s.conn.Children(p)
+		if err == nil && len(children) == 0 {
+			data, _, err = s.conn.Get(p)
+		}
+	} else {
+		var ch <-chan zk.Event
+		children, _, ch, err = s.conn.ChildrenW(p)
+		if err == nil {
+			*events = append(*events, ch)
+			if len(children) == 0 {
+				if data, _, ch, err = s.conn.GetW(p); err == nil {
+					*events = append(*events, ch)
+				}
+			}
+		}
+	}
+	if err == zk.ErrNoNode && key != "" {
+		// 读取期间被删除的znode
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(children) == 0 {
+		if key != "" {
+			kvs[key] = string(data)
+		}
+		return nil
+	}
+	for _, child := range children {
+		if err := s.fetch(path.Join(p, child), path.Join(key, child), kvs, events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
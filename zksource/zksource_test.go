@@ -0,0 +1,165 @@
+package zksource
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-zookeeper/zk"
+)
+
+var _ Conn = (*zk.Conn)(nil)
+
+// fakeConn is an in-memory znode tree, watches fire once on the next change
+// of any znode.
+type fakeConn struct {
+	mu      sync.Mutex
+	nodes   map[string]string
+	watches []chan zk.Event
+}
+
+func (f *fakeConn) Children(p string) ([]string, *zk.Stat, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.nodes[p]; !ok && p != "/" {
+		return nil, nil, zk.ErrNoNode
+	}
+	var children []string
+	prefix := strings.TrimSuffix(p, "/") + "/"
+	for node := range f.nodes {
+		if strings.HasPrefix(node, prefix) && !strings.Contains(node[len(prefix):], "/") {
+			children = append(children, node[len(prefix):])
+		}
+	}
+	sort.Strings(children)
+	return children, &zk.Stat{}, nil
+}
+
+func (f *fakeConn) ChildrenW(p string) ([]string, *zk.Stat, <-chan zk.Event, error) {
+	children, stat, err := f.Children(p)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return children, stat, f.watch(), nil
+}
+
+func (f *fakeConn) Get(p string) ([]byte, *zk.Stat, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, ok := f.nodes[p]
+	if !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	return []byte(data), &zk.Stat{}, nil
+}
+
+func (f *fakeConn) GetW(p string) ([]byte, *zk.Stat, <-chan zk.Event, error) {
+	data, stat, err := f.Get(p)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return data, stat, f.watch(), nil
+}
+
+func (f *fakeConn) watch() <-chan zk.Event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch := make(chan zk.Event, 1)
+	f.watches = append(f.watches, ch)
+	return ch
+}
+
+func (f *fakeConn) set(p, data string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.nodes[p] = data
+	for _, ch := range f.watches {
+		ch <- zk.Event{Type: zk.EventNodeDataChanged, Path: p}
+	}
+	f.watches = nil
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{nodes: map[string]string{
+		"/app":         "",
+		"/app/db":      "",
+		"/app/db/host": "localhost",
+		"/app/db/port": "3306",
+		"/app/debug":   "true",
+	}}
+}
+
+func TestFetch(t *testing.T) {
+	conn := newFakeConn()
+	got, err := New(conn, "app").Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[interface{}]interface{}{
+		"db":    map[interface{}]interface{}{"host": "localhost", "port": 3306},
+		"debug": true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Fetch() = %v, want %v", got, want)
+	}
+
+	if _, err := New(conn, "/missing").Fetch(context.Background()); err != zk.ErrNoNode {
+		t.Errorf("Fetch() error = %v, want zk.ErrNoNode", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	conn := newFakeConn()
+	s := New(conn, "/app")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates := make(chan map[interface{}]interface{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Watch(ctx, func(data map[interface{}]interface{}) {
+			updates <- data
+		})
+	}()
+	// 等待设置监视
+	for {
+		conn.mu.Lock()
+		n := len(conn.watches)
+		conn.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	conn.set("/app/db/port", "3307")
+	select {
+	case data := <-updates:
+		if db, _ := data["db"].(map[interface{}]interface{}); db["port"] != 3307 {
+			t.Errorf("update = %v, want db.port 3307", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update after the znode changed")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Watch() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for root, want := range map[string]string{"app": "/app", "/app/": "/app", "": "/"} {
+		if got := New(nil, root).root; got != want {
+			t.Errorf("New(%q).root = %q, want %q", root, got, want)
+		}
+	}
+}